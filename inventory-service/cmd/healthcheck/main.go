@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fardannozami/golang-microservice/inventory-service/config"
+	inventorypb "github.com/fardannozami/golang-microservice/inventory-service/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// main exits successfully when the inventory service listening on SERVER_PORT
+// reports SERVING through grpc.health.v1; it serves as the container health check
+func main() {
+	// Load configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Connect to the local server
+	conn, err := grpc.NewClient(fmt.Sprintf("localhost:%d", cfg.ServerPort), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer conn.Close()
+
+	// Check the inventory service
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: inventorypb.InventoryService_ServiceDesc.ServiceName,
+	})
+	if err != nil {
+		log.Fatalf("Health check failed: %v", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		log.Fatalf("Inventory service is %s", resp.Status)
+	}
+}
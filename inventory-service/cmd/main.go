@@ -1,69 +1,178 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/fardannozami/golang-microservice/inventory-service/config"
+	"github.com/fardannozami/golang-microservice/inventory-service/logging"
+	"github.com/fardannozami/golang-microservice/inventory-service/metrics"
 	inventorypb "github.com/fardannozami/golang-microservice/inventory-service/proto"
 	"github.com/fardannozami/golang-microservice/inventory-service/repository"
 	"github.com/fardannozami/golang-microservice/inventory-service/server"
 	"github.com/fardannozami/golang-microservice/inventory-service/service"
+	"github.com/fardannozami/golang-microservice/inventory-service/tracing"
+	"github.com/fardannozami/golang-microservice/inventory-service/worker"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 func main() {
 	// Load configuration
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		fatal("Failed to load configuration", err)
+	}
+
+	// Log structured records carrying the request ID of each call
+	logger, err := logging.New(os.Stdout, logging.Config{Level: cfg.LogLevel, Format: cfg.LogFormat})
+	if err != nil {
+		fatal("Failed to initialize logging", err)
+	}
+	slog.SetDefault(logger.With("service", tracing.ServiceName))
+	slog.Info("Loaded configuration", "config", cfg)
+
+	// Initialize tracing before anything that records spans
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.TracingExporter,
+		Endpoint:    cfg.TracingEndpoint,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		fatal("Failed to initialize tracing", err)
 	}
-	fmt.Println(cfg)
 
 	// Initialize database connection
 	db, err := repository.NewPostgresConnection(cfg.DatabaseURL)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal("Failed to connect to database", err)
 	}
 	defer db.Close()
 
 	// Initialize repositories
-	inventoryRepo := repository.NewInventoryRepository(db)
+	allocation, err := repository.NewAllocationStrategy(cfg.AllocationStrategy)
+	if err != nil {
+		fatal("Failed to configure allocation strategy", err)
+	}
+	inventoryRepo := repository.NewInventoryRepository(db, repository.WithAllocationStrategy(allocation))
+
+	// Initialize metrics
+	appMetrics := metrics.New()
+	appMetrics.RegisterDB(db, "inventory")
+	appMetrics.RegisterStock(inventoryRepo)
 
 	// Initialize services
-	inventoryService := service.NewInventoryService(inventoryRepo)
+	serviceOpts := []service.Option{service.WithReservationTTL(cfg.ReservationTTL)}
+	if cfg.StockNotifications {
+		serviceOpts = append(serviceOpts, service.WithSharedStockNotifications())
+	}
+	inventoryService := service.NewInventoryService(inventoryRepo, serviceOpts...)
+
+	// Start reservation reaper in the background
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	reaper := worker.NewReservationReaper(inventoryService, cfg.ReservationReaperInterval)
+	go reaper.Run(repository.WithActor(workerCtx, "reservation-reaper"))
+
+	// Feed stock changes from every replica to stock watchers
+	if cfg.StockNotifications {
+		listener := repository.NewStockChangeListener(cfg.DatabaseURL)
+		go listener.Run(workerCtx, func(productID string) {
+			if productID == "" {
+				inventoryService.PublishStockChanges(workerCtx)
+				return
+			}
+			inventoryService.PublishStockChanges(workerCtx, productID)
+		})
+	}
 
 	// Initialize gRPC server
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.ServerPort))
 	if err != nil {
-		log.Fatalf("Failed to listen: %v", err)
+		fatal("Failed to listen", err)
 	}
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithFilter(filters.Not(filters.HealthCheck())))),
+		grpc.ChainUnaryInterceptor(server.UnaryMetricsInterceptor(appMetrics), server.UnaryRequestIDInterceptor, server.UnaryActorInterceptor),
+	)
 
 	// Register inventory service
 	inventoryServer := server.NewInventoryServer(inventoryService)
 	inventorypb.RegisterInventoryServiceServer(grpcServer, inventoryServer)
 
+	// Register health checking, serving only while the database answers
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	healthReporter := worker.NewHealthReporter(db, healthServer, cfg.HealthCheckInterval, "", inventorypb.InventoryService_ServiceDesc.ServiceName)
+	go healthReporter.Run(workerCtx)
+
+	// Register server reflection for tools such as grpcurl
+	reflection.Register(grpcServer)
+
 	// Start gRPC server in a goroutine
 	go func() {
-		log.Printf("Starting inventory service on port %d", cfg.ServerPort)
+		slog.Info("Starting inventory service", "port", cfg.ServerPort)
 		if err := grpcServer.Serve(lis); err != nil {
-			log.Fatalf("Failed to serve: %v", err)
+			fatal("Failed to serve", err)
 		}
 	}()
 
+	// Serve Prometheus metrics on a separate HTTP listener
+	var metricsServer *http.Server
+	if cfg.MetricsPort > 0 {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", appMetrics.Handler())
+		metricsServer = &http.Server{
+			Addr:    fmt.Sprintf(":%d", cfg.MetricsPort),
+			Handler: mux,
+		}
+		go func() {
+			slog.Info("Serving metrics", "port", cfg.MetricsPort)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fatal("Failed to serve metrics", err)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	// Gracefully stop the gRPC server
-	log.Println("Shutting down server...")
+	slog.Info("Shutting down server")
+	stopWorkers()
+	healthServer.Shutdown()
 	grpcServer.GracefulStop()
-	log.Println("Server exited properly")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			slog.Error("Failed to stop metrics server", "error", err)
+		}
+	}
+
+	// Flush the spans of the last calls
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+	slog.Info("Server exited properly")
+}
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
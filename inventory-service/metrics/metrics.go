@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds the Prometheus metrics of the inventory service in a registry
+// of its own, together with the Go runtime and process metrics
+type Metrics struct {
+	registry *prometheus.Registry
+
+	rpcRequests        *prometheus.CounterVec
+	rpcRequestDuration *prometheus.HistogramVec
+}
+
+// New creates and registers the inventory service metrics
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		rpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_handled_total",
+			Help: "Unary gRPC calls handled, by method and status code.",
+		}, []string{"method", "code"}),
+		rpcRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_server_handling_seconds",
+			Help:    "Time taken to handle unary gRPC calls, by method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.rpcRequests,
+		m.rpcRequestDuration,
+	)
+	return m
+}
+
+// RegisterDB exposes the connection pool statistics of db, labelled with name
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterStock exposes the quantity and reservations of every product,
+// read from stock whenever the metrics are scraped
+func (m *Metrics) RegisterStock(stock StockLister) {
+	m.registry.MustRegister(newStockCollector(stock))
+}
+
+// Handler serves the metrics in the Prometheus exposition format. Metrics
+// that cannot be collected, e.g. stock levels while the database is down, are
+// left out rather than failing the whole scrape.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		Registry:      m.registry,
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+// ObserveRPC records a handled unary gRPC call
+func (m *Metrics) ObserveRPC(method, code string, duration time.Duration) {
+	m.rpcRequests.WithLabelValues(method, code).Inc()
+	m.rpcRequestDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
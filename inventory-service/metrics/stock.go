@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stockScrapeTimeout bounds the stock query of a single scrape
+const stockScrapeTimeout = 5 * time.Second
+
+// StockLister lists the stock of every product summed over all warehouses
+type StockLister interface {
+	ListStockLevels(ctx context.Context) ([]*repository.Inventory, error)
+}
+
+// stockCollector reports stock levels as gauges. The levels are read at
+// scrape time, so the gauges always match the database, including products
+// changed by other replicas.
+type stockCollector struct {
+	stock    StockLister
+	quantity *prometheus.Desc
+	reserved *prometheus.Desc
+}
+
+// newStockCollector creates a collector reading stock levels from stock
+func newStockCollector(stock StockLister) *stockCollector {
+	return &stockCollector{
+		stock: stock,
+		quantity: prometheus.NewDesc(
+			"inventory_stock_quantity",
+			"Units of a product on hand, summed over all warehouses.",
+			[]string{"product_id"}, nil,
+		),
+		reserved: prometheus.NewDesc(
+			"inventory_stock_reserved",
+			"Units of a product reserved for orders, summed over all warehouses.",
+			[]string{"product_id"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *stockCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.quantity
+	ch <- c.reserved
+}
+
+// Collect implements prometheus.Collector. A failed query is reported as an
+// invalid metric, which leaves the gauges out of the scrape and counts the
+// error in promhttp_metric_handler_errors_total.
+func (c *stockCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), stockScrapeTimeout)
+	defer cancel()
+
+	levels, err := c.stock.ListStockLevels(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to collect stock metrics", "error", err)
+		ch <- prometheus.NewInvalidMetric(c.quantity, err)
+		return
+	}
+
+	for _, level := range levels {
+		ch <- prometheus.MustNewConstMetric(c.quantity, prometheus.GaugeValue, float64(level.Quantity), level.ProductID)
+		ch <- prometheus.MustNewConstMetric(c.reserved, prometheus.GaugeValue, float64(level.Reserved), level.ProductID)
+	}
+}
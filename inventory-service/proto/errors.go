@@ -0,0 +1,10 @@
+package inventorypb
+
+// ErrorDomain is the domain of the google.rpc.ErrorInfo details attached to
+// inventory service errors
+const ErrorDomain = "inventory-service"
+
+// ReasonInsufficientStock is the ErrorInfo reason of FailedPrecondition errors
+// caused by insufficient stock. Its metadata carries product_id, available and
+// requested.
+const ReasonInsufficientStock = "INSUFFICIENT_STOCK"
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Names of the built-in allocation strategies
+const (
+	// AllocationSingleWarehouseFirst names SingleWarehouseFirst
+	AllocationSingleWarehouseFirst = "single-warehouse-first"
+	// AllocationSplit names SplitAcrossWarehouses
+	AllocationSplit = "split"
+	// AllocationPriority names PriorityOrder
+	AllocationPriority = "priority"
+)
+
+// WarehouseStock is the stock a single warehouse holds for a product
+type WarehouseStock struct {
+	WarehouseID string
+	Priority    int // lower values are allocated first
+	Quantity    int
+	Reserved    int
+	UpdatedAt   time.Time
+}
+
+// Available returns the quantity that can still be reserved in the warehouse
+func (s WarehouseStock) Available() int {
+	return s.Quantity - s.Reserved
+}
+
+// Allocation is the part of a reservation taken from a single warehouse
+type Allocation struct {
+	WarehouseID string
+	Quantity    int
+}
+
+// AllocationStrategy decides which warehouses a reservation is taken from.
+// Allocate receives the stock of every warehouse that holds the product,
+// ordered by warehouse ID, and a quantity no larger than their combined
+// availability. It returns allocations that add up to exactly that quantity.
+type AllocationStrategy interface {
+	Allocate(stock []WarehouseStock, quantity int) []Allocation
+}
+
+// AllocationStrategyFunc adapts a function to AllocationStrategy
+type AllocationStrategyFunc func(stock []WarehouseStock, quantity int) []Allocation
+
+// Allocate calls f
+func (f AllocationStrategyFunc) Allocate(stock []WarehouseStock, quantity int) []Allocation {
+	return f(stock, quantity)
+}
+
+// PriorityOrder fills a reservation from warehouses in priority order, moving
+// on to the next warehouse only when the previous one runs out
+var PriorityOrder AllocationStrategy = AllocationStrategyFunc(func(stock []WarehouseStock, quantity int) []Allocation {
+	return fillInOrder(byPriority(stock), quantity)
+})
+
+// SingleWarehouseFirst takes the whole reservation from the highest priority
+// warehouse that can cover it alone, so the order ships from one location.
+// When no warehouse can, it falls back to PriorityOrder.
+var SingleWarehouseFirst AllocationStrategy = AllocationStrategyFunc(func(stock []WarehouseStock, quantity int) []Allocation {
+	for _, s := range byPriority(stock) {
+		if s.Available() >= quantity {
+			return []Allocation{{WarehouseID: s.WarehouseID, Quantity: quantity}}
+		}
+	}
+	return PriorityOrder.Allocate(stock, quantity)
+})
+
+// SplitAcrossWarehouses spreads a reservation over every warehouse with stock
+// in proportion to what each has available, so no location is drained first
+var SplitAcrossWarehouses AllocationStrategy = AllocationStrategyFunc(func(stock []WarehouseStock, quantity int) []Allocation {
+	total := 0
+	for _, s := range stock {
+		if s.Available() > 0 {
+			total += s.Available()
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	// Give each warehouse its proportional share, rounded down
+	shares := make([]int, len(stock))
+	allocated := 0
+	for i, s := range stock {
+		if s.Available() > 0 {
+			shares[i] = quantity * s.Available() / total
+			allocated += shares[i]
+		}
+	}
+
+	// Hand out the units lost to rounding, largest fraction first
+	order := make([]int, len(stock))
+	for i := range order {
+		order[i] = i
+	}
+	fraction := func(i int) int {
+		return quantity * max(stock[i].Available(), 0) % total
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		if fraction(order[a]) != fraction(order[b]) {
+			return fraction(order[a]) > fraction(order[b])
+		}
+		return stock[order[a]].Priority < stock[order[b]].Priority
+	})
+	for _, i := range order {
+		if allocated == quantity {
+			break
+		}
+		if stock[i].Available()-shares[i] > 0 {
+			shares[i]++
+			allocated++
+		}
+	}
+
+	allocations := []Allocation{}
+	for i, s := range stock {
+		if shares[i] > 0 {
+			allocations = append(allocations, Allocation{WarehouseID: s.WarehouseID, Quantity: shares[i]})
+		}
+	}
+	return allocations
+})
+
+// NewAllocationStrategy returns the built-in allocation strategy with the given name
+func NewAllocationStrategy(name string) (AllocationStrategy, error) {
+	switch name {
+	case AllocationSingleWarehouseFirst:
+		return SingleWarehouseFirst, nil
+	case AllocationSplit:
+		return SplitAcrossWarehouses, nil
+	case AllocationPriority:
+		return PriorityOrder, nil
+	default:
+		return nil, fmt.Errorf("unknown allocation strategy %q: must be one of %s, %s, %s", name, AllocationSingleWarehouseFirst, AllocationSplit, AllocationPriority)
+	}
+}
+
+// byPriority returns the stock ordered by warehouse priority, then warehouse ID
+func byPriority(stock []WarehouseStock) []WarehouseStock {
+	sorted := append([]WarehouseStock(nil), stock...)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		if sorted[a].Priority != sorted[b].Priority {
+			return sorted[a].Priority < sorted[b].Priority
+		}
+		return sorted[a].WarehouseID < sorted[b].WarehouseID
+	})
+	return sorted
+}
+
+// fillInOrder takes as much as possible from each warehouse in turn
+func fillInOrder(stock []WarehouseStock, quantity int) []Allocation {
+	allocations := []Allocation{}
+	for _, s := range stock {
+		if quantity == 0 {
+			break
+		}
+		take := min(s.Available(), quantity)
+		if take <= 0 {
+			continue
+		}
+		allocations = append(allocations, Allocation{WarehouseID: s.WarehouseID, Quantity: take})
+		quantity -= take
+	}
+	return allocations
+}
+
+// checkAllocations verifies that allocations add up to quantity without
+// exceeding what any warehouse has available
+func checkAllocations(stock []WarehouseStock, allocations []Allocation, quantity int) error {
+	available := make(map[string]int, len(stock))
+	for _, s := range stock {
+		available[s.WarehouseID] = s.Available()
+	}
+
+	total := 0
+	for _, allocation := range allocations {
+		if allocation.Quantity <= 0 || allocation.Quantity > available[allocation.WarehouseID] {
+			return fmt.Errorf("invalid allocation of %d units from warehouse %s", allocation.Quantity, allocation.WarehouseID)
+		}
+		available[allocation.WarehouseID] -= allocation.Quantity
+		total += allocation.Quantity
+	}
+	if total != quantity {
+		return fmt.Errorf("allocations cover %d units, expected %d", total, quantity)
+	}
+	return nil
+}
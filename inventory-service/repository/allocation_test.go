@@ -0,0 +1,67 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// stock has three warehouses ordered by ID, as the repository passes them
+var stock = []repository.WarehouseStock{
+	{WarehouseID: "bdg", Priority: 2, Quantity: 10, Reserved: 4},
+	{WarehouseID: "jkt", Priority: 1, Quantity: 5},
+	{WarehouseID: "sby", Priority: 3, Quantity: 12},
+}
+
+func TestSingleWarehouseFirst_OneWarehouse(t *testing.T) {
+	allocations := repository.SingleWarehouseFirst.Allocate(stock, 6)
+
+	assert.Equal(t, []repository.Allocation{{WarehouseID: "bdg", Quantity: 6}}, allocations)
+}
+
+func TestSingleWarehouseFirst_FallsBackToPriority(t *testing.T) {
+	allocations := repository.SingleWarehouseFirst.Allocate(stock, 15)
+
+	assert.Equal(t, []repository.Allocation{
+		{WarehouseID: "jkt", Quantity: 5},
+		{WarehouseID: "bdg", Quantity: 6},
+		{WarehouseID: "sby", Quantity: 4},
+	}, allocations)
+}
+
+func TestPriorityOrder(t *testing.T) {
+	allocations := repository.PriorityOrder.Allocate(stock, 7)
+
+	assert.Equal(t, []repository.Allocation{
+		{WarehouseID: "jkt", Quantity: 5},
+		{WarehouseID: "bdg", Quantity: 2},
+	}, allocations)
+}
+
+func TestSplitAcrossWarehouses(t *testing.T) {
+	allocations := repository.SplitAcrossWarehouses.Allocate(stock, 12)
+
+	// Available stock is 6, 5 and 12 out of 23
+	assert.Equal(t, []repository.Allocation{
+		{WarehouseID: "bdg", Quantity: 3},
+		{WarehouseID: "jkt", Quantity: 3},
+		{WarehouseID: "sby", Quantity: 6},
+	}, allocations)
+}
+
+func TestSplitAcrossWarehouses_AllStock(t *testing.T) {
+	allocations := repository.SplitAcrossWarehouses.Allocate(stock, 23)
+
+	assert.Equal(t, []repository.Allocation{
+		{WarehouseID: "bdg", Quantity: 6},
+		{WarehouseID: "jkt", Quantity: 5},
+		{WarehouseID: "sby", Quantity: 12},
+	}, allocations)
+}
+
+func TestNewAllocationStrategy_Unknown(t *testing.T) {
+	_, err := repository.NewAllocationStrategy("random")
+
+	assert.Error(t, err)
+}
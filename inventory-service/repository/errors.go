@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/lib/pq"
+)
+
+// Error kinds returned by the repository; match them with errors.Is
+var (
+	// ErrNotFound is returned when a product, its inventory or a reservation does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrFailedPrecondition is returned when the current stock does not allow a change
+	ErrFailedPrecondition = errors.New("failed precondition")
+)
+
+// InsufficientStockError reports that a product has less available stock than requested.
+// It matches ErrFailedPrecondition.
+type InsufficientStockError struct {
+	ProductID string
+	Available int
+	Requested int
+}
+
+// Error implements error
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock: available %d, requested %d", e.Available, e.Requested)
+}
+
+// Is reports whether target is ErrFailedPrecondition
+func (e *InsufficientStockError) Is(target error) bool {
+	return target == ErrFailedPrecondition
+}
+
+// kindError attaches an error kind to an error without changing its message
+type kindError struct {
+	kind error
+	err  error
+}
+
+// Error implements error
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the kind and the underlying error
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// notFoundf formats an error that matches ErrNotFound
+func notFoundf(format string, args ...any) error {
+	return &kindError{kind: ErrNotFound, err: fmt.Errorf(format, args...)}
+}
+
+// failedPreconditionf formats an error that matches ErrFailedPrecondition
+func failedPreconditionf(format string, args ...any) error {
+	return &kindError{kind: ErrFailedPrecondition, err: fmt.Errorf(format, args...)}
+}
+
+// hasCode reports whether err was caused by a PostgreSQL error with the given code
+func hasCode(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
+
+// IsTransient reports whether err was caused by the database being unreachable
+// or by a transaction conflict, both of which may succeed when retried
+func IsTransient(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "08", // connection exception
+			"40", // transaction rollback, e.g. serialization failure or deadlock
+			"53", // insufficient resources
+			"57": // operator intervention, e.g. the server is shutting down
+			return true
+		}
+	}
+
+	return false
+}
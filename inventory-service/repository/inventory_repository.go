@@ -3,7 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 )
 
@@ -15,60 +17,137 @@ type Product struct {
 	Price       float64
 }
 
-// Inventory represents an inventory entity
+// Inventory represents an inventory entity. Aggregated stock levels leave
+// WarehouseID empty.
 type Inventory struct {
+	ProductID   string
+	WarehouseID string
+	Quantity    int
+	Reserved    int
+	UpdatedAt   time.Time
+}
+
+// Reservation represents stock held for an order
+type Reservation struct {
+	OrderID     string
+	ProductID   string
+	WarehouseID string
+	Quantity    int
+	CreatedAt   time.Time
+	ExpiresAt   time.Time // zero if the reservation never expires
+}
+
+// StockCommit represents stock permanently deducted for an order
+type StockCommit struct {
+	OrderID     string
+	ProductID   string
+	WarehouseID string
+	Quantity    int
+	CommittedAt time.Time
+}
+
+// ReservationItem represents a single line of a multi-item reservation
+type ReservationItem struct {
+	ProductID string
+	Quantity  int
+}
+
+// ReservationResult represents the outcome of reserving a single line
+type ReservationResult struct {
 	ProductID string
 	Quantity  int
-	Reserved  int
-	UpdatedAt time.Time
+	Success   bool
+	Available int
+	Message   string
 }
 
 // InventoryRepository defines the interface for inventory repository operations
 type InventoryRepository interface {
-	CheckStock(ctx context.Context, productID string, quantity int) (bool, error)
-	ReserveStock(ctx context.Context, productID string, quantity int, orderID string) error
+	CheckStock(ctx context.Context, productID, warehouseID string, quantity int) (bool, error)
+	ReserveStock(ctx context.Context, productID string, quantity int, orderID string, expiresAt time.Time) error
 	ReleaseStock(ctx context.Context, productID string, quantity int, orderID string) error
+	ReserveItems(ctx context.Context, orderID string, items []ReservationItem, expiresAt time.Time) ([]ReservationResult, error)
+	ExtendReservation(ctx context.Context, orderID string, expiresAt time.Time) (int, error)
+	ListExpiredReservations(ctx context.Context, now time.Time, limit int) ([]Reservation, error)
+	ReleaseExpiredReservation(ctx context.Context, reservation Reservation, now time.Time) (bool, error)
+	CommitStock(ctx context.Context, orderID string) ([]StockCommit, error)
 	GetProduct(ctx context.Context, productID string) (*Product, error)
 	CreateProduct(ctx context.Context, product *Product) error
+	GetProducts(ctx context.Context, productIDs []string) ([]*Product, error)
+	ListProducts(ctx context.Context, filter ProductFilter) ([]*Product, error)
+	CreateProductWithStock(ctx context.Context, product *Product, quantity int) error
+	UpdateProduct(ctx context.Context, product *Product) error
+	DeleteProduct(ctx context.Context, productID string) error
+	GetProductWithStock(ctx context.Context, productID string) (*ProductWithStock, error)
+	ListMovements(ctx context.Context, filter MovementFilter) ([]Movement, error)
+	AdjustStock(ctx context.Context, productID, warehouseID string, delta int, reason MovementReason, idempotencyKey string) (*Inventory, error)
+	SetStock(ctx context.Context, productID, warehouseID string, quantity int, reason MovementReason, idempotencyKey string) (*Inventory, error)
+	GetStockLevels(ctx context.Context, productIDs []string) ([]*Inventory, error)
+	ListStockLevels(ctx context.Context) ([]*Inventory, error)
 	CreateInventory(ctx context.Context, inventory *Inventory) error
+	CreateWarehouse(ctx context.Context, warehouse *Warehouse) error
+	ListWarehouses(ctx context.Context) ([]*Warehouse, error)
 }
 
 // inventoryRepository implements InventoryRepository interface
 type inventoryRepository struct {
-	db *sql.DB
+	db         *sql.DB
+	allocation AllocationStrategy
+}
+
+// Option configures an inventory repository
+type Option func(*inventoryRepository)
+
+// WithAllocationStrategy sets how reservations are spread across warehouses
+func WithAllocationStrategy(strategy AllocationStrategy) Option {
+	return func(r *inventoryRepository) {
+		r.allocation = strategy
+	}
 }
 
-// NewInventoryRepository creates a new inventory repository
-func NewInventoryRepository(db *sql.DB) InventoryRepository {
-	return &inventoryRepository{db: db}
+// NewInventoryRepository creates a new inventory repository. Reservations are
+// allocated with SingleWarehouseFirst unless another strategy is configured.
+func NewInventoryRepository(db *sql.DB, opts ...Option) InventoryRepository {
+	r := &inventoryRepository{
+		db:         db,
+		allocation: SingleWarehouseFirst,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
-// CheckStock checks if a product is available in inventory
-func (r *inventoryRepository) CheckStock(ctx context.Context, productID string, quantity int) (bool, error) {
-	// Query inventory
+// CheckStock checks if a product is available in inventory. An empty
+// warehouseID checks the stock of all warehouses combined.
+func (r *inventoryRepository) CheckStock(ctx context.Context, productID, warehouseID string, quantity int) (bool, error) {
+	// Query inventory, summed over the requested warehouses
 	row := r.db.QueryRowContext(
 		ctx,
-		"SELECT quantity, reserved FROM inventory WHERE product_id = $1",
-		productID,
+		"SELECT COUNT(*), COALESCE(SUM(quantity - reserved), 0) FROM inventory WHERE product_id = $1 AND ($2::varchar = '' OR warehouse_id = $2)",
+		productID, warehouseID,
 	)
 
 	// Scan inventory
-	var inventoryQuantity, reserved int
-	err := row.Scan(&inventoryQuantity, &reserved)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return false, nil
-		}
+	var locations, available int
+	if err := row.Scan(&locations, &available); err != nil {
 		return false, fmt.Errorf("failed to scan inventory: %w", err)
 	}
+	if locations == 0 {
+		if warehouseID != "" {
+			return false, notFoundf("product %s is not stocked in warehouse %s", productID, warehouseID)
+		}
+		return false, notFoundf("product not found: %s", productID)
+	}
 
 	// Check if available quantity is sufficient
-	available := inventoryQuantity - reserved
 	return available >= quantity, nil
 }
 
-// ReserveStock reserves stock for an order
-func (r *inventoryRepository) ReserveStock(ctx context.Context, productID string, quantity int, orderID string) error {
+// ReserveStock sets the stock an order holds of a product to quantity until
+// expiresAt (zero means no expiry), reserving or releasing the difference to
+// what it already holds
+func (r *inventoryRepository) ReserveStock(ctx context.Context, productID string, quantity int, orderID string, expiresAt time.Time) error {
 	// Start a transaction
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
 		Isolation: sql.LevelSerializable,
@@ -78,62 +157,250 @@ func (r *inventoryRepository) ReserveStock(ctx context.Context, productID string
 	}
 	defer tx.Rollback()
 
-	// Query inventory with lock
-	row := tx.QueryRowContext(
+	// Reserve stock within the transaction
+	if _, err := r.reserveInTx(ctx, tx, productID, quantity, orderID, expiresAt); err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ReserveItems reserves stock for every item of an order in a single transaction.
+// Either all items are reserved or none are; the per-line results describe
+// which lines could not be satisfied. A database error ends the call without
+// results.
+func (r *inventoryRepository) ReserveItems(ctx context.Context, orderID string, items []ReservationItem, expiresAt time.Time) ([]ReservationResult, error) {
+	// Start a transaction
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock rows in a stable order so concurrent orders cannot deadlock
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return items[order[a]].ProductID < items[order[b]].ProductID
+	})
+
+	// Reserve each line, collecting results in request order
+	results := make([]ReservationResult, len(items))
+	var firstErr error
+	for _, i := range order {
+		item := items[i]
+		available, err := r.reserveInTx(ctx, tx, item.ProductID, item.Quantity, orderID, expiresAt)
+		results[i] = ReservationResult{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Success:   err == nil,
+			Available: available,
+		}
+		if err != nil {
+			// A database error aborts the transaction, so the remaining
+			// lines cannot be checked
+			if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrFailedPrecondition) {
+				return nil, fmt.Errorf("product %s: %w", item.ProductID, err)
+			}
+			results[i].Message = err.Error()
+			if firstErr == nil {
+				firstErr = fmt.Errorf("product %s: %w", item.ProductID, err)
+			}
+		}
+	}
+
+	// Roll back every line if any of them failed
+	if firstErr != nil {
+		for i := range results {
+			results[i].Success = false
+		}
+		return results, firstErr
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return results, nil
+}
+
+// lockStock reads the stock a product has in every warehouse and locks those
+// rows until the transaction ends. Rows are locked in warehouse order so
+// concurrent transactions cannot deadlock.
+func lockStock(ctx context.Context, tx *sql.Tx, productID string) ([]WarehouseStock, error) {
+	rows, err := tx.QueryContext(
 		ctx,
-		"SELECT quantity, reserved FROM inventory WHERE product_id = $1 FOR UPDATE",
+		`SELECT i.warehouse_id, w.priority, i.quantity, i.reserved, i.updated_at
+		 FROM inventory i JOIN warehouses w ON w.id = i.warehouse_id
+		 WHERE i.product_id = $1 ORDER BY i.warehouse_id FOR UPDATE OF i`,
 		productID,
 	)
-
-	// Scan inventory
-	var inventoryQuantity, reserved int
-	err = row.Scan(&inventoryQuantity, &reserved)
 	if err != nil {
-		return fmt.Errorf("failed to scan inventory: %w", err)
+		return nil, fmt.Errorf("failed to query inventory: %w", err)
 	}
+	defer rows.Close()
 
-	// Check if available quantity is sufficient
-	available := inventoryQuantity - reserved
-	if available < quantity {
-		return fmt.Errorf("insufficient stock: available %d, requested %d", available, quantity)
+	stock := []WarehouseStock{}
+	for rows.Next() {
+		var s WarehouseStock
+		if err := rows.Scan(&s.WarehouseID, &s.Priority, &s.Quantity, &s.Reserved, &s.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan inventory: %w", err)
+		}
+		stock = append(stock, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate inventory: %w", err)
 	}
+	if len(stock) == 0 {
+		return nil, notFoundf("product not found: %s", productID)
+	}
+	return stock, nil
+}
 
-	// Idempotent reservation per order: compute delta against existing reservation
-	var previousReserved int
-	err = tx.QueryRowContext(
+// reservedByOrder returns what an order holds of a product in each warehouse, in warehouse order
+func reservedByOrder(ctx context.Context, tx *sql.Tx, orderID, productID string) ([]Allocation, int, error) {
+	rows, err := tx.QueryContext(
 		ctx,
-		"SELECT COALESCE((SELECT quantity FROM reservations WHERE order_id = $1 AND product_id = $2), 0)",
+		"SELECT warehouse_id, quantity FROM reservations WHERE order_id = $1 AND product_id = $2 ORDER BY warehouse_id",
 		orderID, productID,
-	).Scan(&previousReserved)
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read previous reservation: %w", err)
+	}
+	defer rows.Close()
+
+	held := []Allocation{}
+	total := 0
+	for rows.Next() {
+		var allocation Allocation
+		if err := rows.Scan(&allocation.WarehouseID, &allocation.Quantity); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan reservation: %w", err)
+		}
+		held = append(held, allocation)
+		total += allocation.Quantity
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate reservations: %w", err)
+	}
+	return held, total, nil
+}
+
+// reserveInTx sets the stock an order holds of a product to quantity inside an
+// existing transaction and returns the quantity that was available to the
+// order before the reservation. Stock the order does not hold yet is taken from
+// the warehouses chosen by the allocation strategy; stock it no longer needs is
+// released. A quantity of zero releases everything the order holds.
+func (r *inventoryRepository) reserveInTx(ctx context.Context, tx *sql.Tx, productID string, quantity int, orderID string, expiresAt time.Time) (int, error) {
+	// Query inventory of every warehouse with lock
+	stock, err := lockStock(ctx, tx, productID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Idempotent reservation per order: compute delta against existing reservations
+	_, previousReserved, err := reservedByOrder(ctx, tx, orderID, productID)
 	if err != nil {
-		return fmt.Errorf("failed to read previous reservation: %w", err)
+		return 0, err
 	}
 
+	// Stock already held by this order counts towards what it may reserve
+	available := previousReserved
+	for _, s := range stock {
+		available += s.Available()
+	}
+	if available < quantity {
+		return available, &InsufficientStockError{ProductID: productID, Available: available, Requested: quantity}
+	}
+
+	// A smaller quantity than already held gives the difference back
 	delta := quantity - previousReserved
 	if delta < 0 {
-		return fmt.Errorf("invalid reservation: requested less than already reserved")
+		if _, err := releaseInTx(ctx, tx, productID, "", -delta, orderID, ReasonRelease); err != nil {
+			return available, err
+		}
 	}
 
 	if delta > 0 {
-		// Apply delta to inventory
-		_, err = tx.ExecContext(
-			ctx,
-			"UPDATE inventory SET reserved = reserved + $1, updated_at = $2 WHERE product_id = $3",
-			delta, time.Now(), productID,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to update inventory: %w", err)
+		// Decide which warehouses the additional stock comes from
+		allocations := r.allocation.Allocate(stock, delta)
+		if err := checkAllocations(stock, allocations, delta); err != nil {
+			return available, fmt.Errorf("failed to allocate stock: %w", err)
+		}
+
+		now := time.Now()
+		for _, allocation := range allocations {
+			// Apply allocation to the warehouse's inventory
+			_, err = tx.ExecContext(
+				ctx,
+				"UPDATE inventory SET reserved = reserved + $1, updated_at = $2 WHERE warehouse_id = $3 AND product_id = $4",
+				allocation.Quantity, now, allocation.WarehouseID, productID,
+			)
+			if err != nil {
+				return available, fmt.Errorf("failed to update inventory: %w", err)
+			}
+
+			// Add allocation to the order's reservation in that warehouse
+			_, err = tx.ExecContext(
+				ctx,
+				"INSERT INTO reservations(order_id, product_id, warehouse_id, quantity) VALUES($1,$2,$3,$4) ON CONFLICT(order_id, product_id, warehouse_id) DO UPDATE SET quantity = reservations.quantity + EXCLUDED.quantity",
+				orderID, productID, allocation.WarehouseID, allocation.Quantity,
+			)
+			if err != nil {
+				return available, fmt.Errorf("failed to upsert reservation: %w", err)
+			}
+
+			// Record the change in the ledger
+			err = recordMovement(ctx, tx, Movement{
+				ProductID:     productID,
+				WarehouseID:   allocation.WarehouseID,
+				ReservedDelta: allocation.Quantity,
+				OrderID:       orderID,
+				Reason:        ReasonReserve,
+				CreatedAt:     now,
+			})
+			if err != nil {
+				return available, err
+			}
 		}
 	}
 
-	// Upsert reservation record
+	// Refresh the expiry of everything the order holds of this product
 	_, err = tx.ExecContext(
 		ctx,
-		"INSERT INTO reservations(order_id, product_id, quantity) VALUES($1,$2,$3) ON CONFLICT(order_id, product_id) DO UPDATE SET quantity = EXCLUDED.quantity",
-		orderID, productID, quantity,
+		"UPDATE reservations SET expires_at = $1 WHERE order_id = $2 AND product_id = $3",
+		nullTime(expiresAt), orderID, productID,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to upsert reservation: %w", err)
+		return available, fmt.Errorf("failed to update reservation expiry: %w", err)
+	}
+
+	return available, nil
+}
+
+// ReleaseStock releases reserved stock
+func (r *inventoryRepository) ReleaseStock(ctx context.Context, productID string, quantity int, orderID string) error {
+	// Start a transaction
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Release stock within the transaction
+	if _, err := releaseInTx(ctx, tx, productID, "", quantity, orderID, ReasonRelease); err != nil {
+		return err
 	}
 
 	// Commit transaction
@@ -144,76 +411,309 @@ func (r *inventoryRepository) ReserveStock(ctx context.Context, productID string
 	return nil
 }
 
-// ReleaseStock releases reserved stock
-func (r *inventoryRepository) ReleaseStock(ctx context.Context, productID string, quantity int, orderID string) error {
+// releaseInTx releases stock reserved by an order inside an existing transaction
+// and returns the quantity that was actually released. The order's reservations
+// are released in warehouse order; a non-empty warehouseID only releases the
+// reservation taken from that warehouse.
+func releaseInTx(ctx context.Context, tx *sql.Tx, productID, warehouseID string, quantity int, orderID string, reason MovementReason) (int, error) {
+	// Query inventory with lock, then the reservations of this order
+	if _, err := lockStock(ctx, tx, productID); err != nil {
+		return 0, err
+	}
+	held, _, err := reservedByOrder(ctx, tx, orderID, productID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Release up to quantity, never more than reserved by this order
+	released := 0
+	now := time.Now()
+	for _, reservation := range held {
+		if warehouseID != "" && reservation.WarehouseID != warehouseID {
+			continue
+		}
+		releaseAmount := min(quantity-released, reservation.Quantity)
+		if releaseAmount <= 0 {
+			break
+		}
+
+		// Update reserved quantity
+		_, err = tx.ExecContext(
+			ctx,
+			"UPDATE inventory SET reserved = reserved - $1, updated_at = $2 WHERE warehouse_id = $3 AND product_id = $4",
+			releaseAmount, now, reservation.WarehouseID, productID,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update inventory: %w", err)
+		}
+
+		// Update or delete reservation record
+		remaining := reservation.Quantity - releaseAmount
+		if remaining > 0 {
+			_, err = tx.ExecContext(
+				ctx,
+				"UPDATE reservations SET quantity = $1 WHERE order_id = $2 AND product_id = $3 AND warehouse_id = $4",
+				remaining, orderID, productID, reservation.WarehouseID,
+			)
+		} else {
+			_, err = tx.ExecContext(
+				ctx,
+				"DELETE FROM reservations WHERE order_id = $1 AND product_id = $2 AND warehouse_id = $3",
+				orderID, productID, reservation.WarehouseID,
+			)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to update reservation record: %w", err)
+		}
+
+		// Record the change in the ledger
+		err = recordMovement(ctx, tx, Movement{
+			ProductID:     productID,
+			WarehouseID:   reservation.WarehouseID,
+			ReservedDelta: -releaseAmount,
+			OrderID:       orderID,
+			Reason:        reason,
+			CreatedAt:     now,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		released += releaseAmount
+	}
+
+	return released, nil
+}
+
+// ExtendReservation moves the expiry of every reservation held by an order
+// and returns the number of reservations that were extended
+func (r *inventoryRepository) ExtendReservation(ctx context.Context, orderID string, expiresAt time.Time) (int, error) {
+	// Update expiry
+	result, err := r.db.ExecContext(
+		ctx,
+		"UPDATE reservations SET expires_at = $1 WHERE order_id = $2",
+		nullTime(expiresAt), orderID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to extend reservation: %w", err)
+	}
+
+	// Count extended reservations
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read affected rows: %w", err)
+	}
+
+	return int(affected), nil
+}
+
+// ListExpiredReservations lists reservations whose expiry is at or before now
+func (r *inventoryRepository) ListExpiredReservations(ctx context.Context, now time.Time, limit int) ([]Reservation, error) {
+	// Query expired reservations
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT order_id, product_id, warehouse_id, quantity, created_at, expires_at FROM reservations WHERE expires_at <= $1 ORDER BY expires_at LIMIT $2",
+		now, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expired reservations: %w", err)
+	}
+	defer rows.Close()
+
+	// Scan reservations
+	reservations := []Reservation{}
+	for rows.Next() {
+		var reservation Reservation
+		var expiresAt sql.NullTime
+		err := rows.Scan(&reservation.OrderID, &reservation.ProductID, &reservation.WarehouseID, &reservation.Quantity, &reservation.CreatedAt, &expiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reservation: %w", err)
+		}
+		reservation.ExpiresAt = expiresAt.Time
+		reservations = append(reservations, reservation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate reservations: %w", err)
+	}
+
+	return reservations, nil
+}
+
+// ReleaseExpiredReservation releases a reservation if it is still expired at now.
+// It reports false when the reservation was extended or released in the meantime.
+func (r *inventoryRepository) ReleaseExpiredReservation(ctx context.Context, reservation Reservation, now time.Time) (bool, error) {
 	// Start a transaction
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
 		Isolation: sql.LevelSerializable,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Query inventory with lock and reservation by this order
-	row := tx.QueryRowContext(
+	// Lock inventory before reading the reservation, in the same order as ReserveStock
+	if _, err := lockStock(ctx, tx, reservation.ProductID); err != nil {
+		return false, err
+	}
+
+	// Re-check expiry under lock
+	var quantity int
+	err = tx.QueryRowContext(
 		ctx,
-		"SELECT i.reserved, COALESCE((SELECT quantity FROM reservations WHERE order_id = $1 AND product_id = $2), 0) AS reserved_by_order FROM inventory i WHERE i.product_id = $2 FOR UPDATE",
-		orderID, productID,
-	)
+		"SELECT quantity FROM reservations WHERE order_id = $1 AND product_id = $2 AND warehouse_id = $3 AND expires_at <= $4",
+		reservation.OrderID, reservation.ProductID, reservation.WarehouseID, now,
+	).Scan(&quantity)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read reservation: %w", err)
+	}
 
-	// Scan inventory
-	var reserved, reservedByOrder int
-	err = row.Scan(&reserved, &reservedByOrder)
+	// Release the whole reservation
+	released, err := releaseInTx(ctx, tx, reservation.ProductID, reservation.WarehouseID, quantity, reservation.OrderID, ReasonExpiry)
 	if err != nil {
-		return fmt.Errorf("failed to scan inventory: %w", err)
+		return false, err
 	}
 
-	// Determine actual release amount (can't release more than reserved by this order)
-	releaseAmount := quantity
-	if releaseAmount > reservedByOrder {
-		releaseAmount = reservedByOrder
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
 	}
-	if releaseAmount <= 0 {
-		// Nothing to release
-		return nil
+
+	return released > 0, nil
+}
+
+// CommitStock converts every reservation held by an order into a permanent
+// deduction of quantity and reserved. It is idempotent: committing an order
+// again returns the lines committed previously without touching stock.
+func (r *inventoryRepository) CommitStock(ctx context.Context, orderID string) ([]StockCommit, error) {
+	// Start a transaction
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	// Update reserved quantity
-	_, err = tx.ExecContext(
+	// Query outstanding reservations in lock order
+	rows, err := tx.QueryContext(
 		ctx,
-		"UPDATE inventory SET reserved = reserved - $1, updated_at = $2 WHERE product_id = $3",
-		releaseAmount, time.Now(), productID,
+		"SELECT DISTINCT product_id FROM reservations WHERE order_id = $1 ORDER BY product_id",
+		orderID,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to update inventory: %w", err)
+		return nil, fmt.Errorf("failed to query reservations: %w", err)
+	}
+	var productIDs []string
+	for rows.Next() {
+		var productID string
+		if err := rows.Scan(&productID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan reservation: %w", err)
+		}
+		productIDs = append(productIDs, productID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate reservations: %w", err)
 	}
 
-	// Update or delete reservation record
-	remaining := reservedByOrder - releaseAmount
-	if remaining > 0 {
-		_, err = tx.ExecContext(
-			ctx,
-			"UPDATE reservations SET quantity = $1 WHERE order_id = $2 AND product_id = $3",
-			remaining, orderID, productID,
-		)
-	} else {
+	// Deduct each reservation from stock
+	now := time.Now()
+	for _, productID := range productIDs {
+		// Lock inventory before reading the reservations, in the same order as ReserveStock
+		if _, err := lockStock(ctx, tx, productID); err != nil {
+			return nil, err
+		}
+
+		held, _, err := reservedByOrder(ctx, tx, orderID, productID)
+		if err != nil {
+			return nil, err
+		}
 		_, err = tx.ExecContext(
 			ctx,
 			"DELETE FROM reservations WHERE order_id = $1 AND product_id = $2",
 			orderID, productID,
 		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete reservation: %w", err)
+		}
+
+		// Deduct from each warehouse the reservation was taken from
+		for _, reservation := range held {
+			_, err = tx.ExecContext(
+				ctx,
+				"UPDATE inventory SET quantity = quantity - $1, reserved = reserved - $1, updated_at = $2 WHERE warehouse_id = $3 AND product_id = $4",
+				reservation.Quantity, now, reservation.WarehouseID, productID,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to update inventory: %w", err)
+			}
+
+			_, err = tx.ExecContext(
+				ctx,
+				"INSERT INTO stock_commits(order_id, product_id, warehouse_id, quantity, committed_at) VALUES($1,$2,$3,$4,$5) ON CONFLICT(order_id, product_id, warehouse_id) DO UPDATE SET quantity = stock_commits.quantity + EXCLUDED.quantity, committed_at = EXCLUDED.committed_at",
+				orderID, productID, reservation.WarehouseID, reservation.Quantity, now,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to record stock commit: %w", err)
+			}
+
+			// Record the change in the ledger
+			err = recordMovement(ctx, tx, Movement{
+				ProductID:     productID,
+				WarehouseID:   reservation.WarehouseID,
+				QuantityDelta: -reservation.Quantity,
+				ReservedDelta: -reservation.Quantity,
+				OrderID:       orderID,
+				Reason:        ReasonSale,
+				CreatedAt:     now,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
+
+	// Return every line committed for this order, including earlier commits
+	commitRows, err := tx.QueryContext(
+		ctx,
+		"SELECT order_id, product_id, warehouse_id, quantity, committed_at FROM stock_commits WHERE order_id = $1 ORDER BY product_id, warehouse_id",
+		orderID,
+	)
 	if err != nil {
-		return fmt.Errorf("failed to update reservation record: %w", err)
+		return nil, fmt.Errorf("failed to query stock commits: %w", err)
+	}
+	defer commitRows.Close()
+
+	commits := []StockCommit{}
+	for commitRows.Next() {
+		var commit StockCommit
+		if err := commitRows.Scan(&commit.OrderID, &commit.ProductID, &commit.WarehouseID, &commit.Quantity, &commit.CommittedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan stock commit: %w", err)
+		}
+		commits = append(commits, commit)
+	}
+	if err := commitRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate stock commits: %w", err)
+	}
+	if len(commits) == 0 {
+		return nil, notFoundf("no reservations found for order %s", orderID)
 	}
 
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return nil
+	return commits, nil
+}
+
+// nullTime converts a zero time into a SQL NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
 }
 
 // GetProduct gets a product by ID
@@ -230,7 +730,7 @@ func (r *inventoryRepository) GetProduct(ctx context.Context, productID string)
 	err := row.Scan(&product.ID, &product.Name, &product.Description, &product.Price)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("product not found: %s", productID)
+			return nil, notFoundf("product not found: %s", productID)
 		}
 		return nil, fmt.Errorf("failed to scan product: %w", err)
 	}
@@ -253,20 +753,49 @@ func (r *inventoryRepository) CreateProduct(ctx context.Context, product *Produc
 	return nil
 }
 
-// CreateInventory creates a new inventory entry
+// CreateInventory creates a new inventory entry, in the default warehouse
+// unless the inventory names one
 func (r *inventoryRepository) CreateInventory(ctx context.Context, inventory *Inventory) error {
-	// Set timestamp
+	// Start a transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Set defaults
 	inventory.UpdatedAt = time.Now()
+	if inventory.WarehouseID == "" {
+		inventory.WarehouseID = DefaultWarehouseID
+	}
 
 	// Insert inventory
-	_, err := r.db.ExecContext(
+	_, err = tx.ExecContext(
 		ctx,
-		"INSERT INTO inventory (product_id, quantity, reserved, updated_at) VALUES ($1, $2, $3, $4)",
-		inventory.ProductID, inventory.Quantity, inventory.Reserved, inventory.UpdatedAt,
+		"INSERT INTO inventory (warehouse_id, product_id, quantity, reserved, updated_at) VALUES ($1, $2, $3, $4, $5)",
+		inventory.WarehouseID, inventory.ProductID, inventory.Quantity, inventory.Reserved, inventory.UpdatedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert inventory: %w", err)
 	}
 
+	// Record the initial stock in the ledger
+	err = recordMovement(ctx, tx, Movement{
+		ProductID:     inventory.ProductID,
+		WarehouseID:   inventory.WarehouseID,
+		QuantityDelta: inventory.Quantity,
+		ReservedDelta: inventory.Reserved,
+		Reason:        ReasonRestock,
+		CreatedAt:     inventory.UpdatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MovementReason explains why stock levels changed
+type MovementReason string
+
+const (
+	// ReasonReserve records stock reserved for an order
+	ReasonReserve MovementReason = "reserve"
+	// ReasonRelease records reserved stock given back by an order
+	ReasonRelease MovementReason = "release"
+	// ReasonExpiry records a reservation released because it expired
+	ReasonExpiry MovementReason = "expiry"
+	// ReasonSale records reserved stock permanently deducted for an order
+	ReasonSale MovementReason = "sale"
+	// ReasonRestock records goods received into stock
+	ReasonRestock MovementReason = "restock"
+	// ReasonDamage records stock written off as damaged
+	ReasonDamage MovementReason = "damage"
+	// ReasonShrinkage records stock lost to theft or unexplained loss
+	ReasonShrinkage MovementReason = "shrinkage"
+	// ReasonCorrection records a correction after a stock count
+	ReasonCorrection MovementReason = "correction"
+	// ReasonRemoval records the stock removed with its deleted product
+	ReasonRemoval MovementReason = "removal"
+)
+
+// defaultActor is recorded when no actor is attached to the context
+const defaultActor = "system"
+
+// actorKey is the context key for the actor responsible for a change
+type actorKey struct{}
+
+// Movement represents a single change to the stock levels of a product
+type Movement struct {
+	ID            int64
+	ProductID     string
+	WarehouseID   string
+	QuantityDelta int
+	ReservedDelta int
+	OrderID       string
+	Reason        MovementReason
+	Actor         string
+	CreatedAt     time.Time
+
+	// IdempotencyKey deduplicates manual adjustments; empty for other movements
+	IdempotencyKey string
+	// IdempotencyRequest describes the adjustment made under IdempotencyKey,
+	// e.g. "adjust 5" or "set 10"
+	IdempotencyRequest string
+}
+
+// MovementFilter narrows and pages a movement listing
+type MovementFilter struct {
+	ProductID string
+	Since     time.Time // inclusive; zero means unbounded
+	Until     time.Time // exclusive; zero means unbounded
+	AfterID   int64     // only movements with an ID greater than this are returned
+	Limit     int
+}
+
+// WithActor returns a context that records actor on every stock movement
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor attached to the context, or "system"
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return defaultActor
+}
+
+// ListMovements lists stock movements in the order they happened
+func (r *inventoryRepository) ListMovements(ctx context.Context, filter MovementFilter) ([]Movement, error) {
+	// Build filter conditions
+	conditions := []string{"id > $1"}
+	args := []interface{}{filter.AfterID}
+	if filter.ProductID != "" {
+		args = append(args, filter.ProductID)
+		conditions = append(conditions, fmt.Sprintf("product_id = $%d", len(args)))
+	}
+	if !filter.Since.IsZero() {
+		args = append(args, filter.Since)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if !filter.Until.IsZero() {
+		args = append(args, filter.Until)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	args = append(args, filter.Limit)
+
+	// Query movements
+	rows, err := r.db.QueryContext(
+		ctx,
+		fmt.Sprintf(
+			"SELECT id, product_id, warehouse_id, quantity_delta, reserved_delta, order_id, reason, actor, created_at FROM inventory_movements WHERE %s ORDER BY id LIMIT $%d",
+			strings.Join(conditions, " AND "), len(args),
+		),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query movements: %w", err)
+	}
+	defer rows.Close()
+
+	// Scan movements
+	movements := []Movement{}
+	for rows.Next() {
+		var movement Movement
+		var orderID sql.NullString
+		err := rows.Scan(
+			&movement.ID, &movement.ProductID, &movement.WarehouseID, &movement.QuantityDelta, &movement.ReservedDelta,
+			&orderID, &movement.Reason, &movement.Actor, &movement.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan movement: %w", err)
+		}
+		movement.OrderID = orderID.String
+		movements = append(movements, movement)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate movements: %w", err)
+	}
+
+	return movements, nil
+}
+
+// recordMovement appends a stock movement inside the transaction that changed the stock
+func recordMovement(ctx context.Context, tx *sql.Tx, movement Movement) error {
+	if movement.Actor == "" {
+		movement.Actor = ActorFromContext(ctx)
+	}
+	if movement.CreatedAt.IsZero() {
+		movement.CreatedAt = time.Now()
+	}
+	if movement.WarehouseID == "" {
+		movement.WarehouseID = DefaultWarehouseID
+	}
+
+	_, err := tx.ExecContext(
+		ctx,
+		"INSERT INTO inventory_movements (product_id, warehouse_id, quantity_delta, reserved_delta, order_id, reason, actor, created_at, idempotency_key, idempotency_request) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		movement.ProductID, movement.WarehouseID, movement.QuantityDelta, movement.ReservedDelta,
+		sql.NullString{String: movement.OrderID, Valid: movement.OrderID != ""},
+		string(movement.Reason), movement.Actor, movement.CreatedAt,
+		sql.NullString{String: movement.IdempotencyKey, Valid: movement.IdempotencyKey != ""},
+		sql.NullString{String: movement.IdempotencyRequest, Valid: movement.IdempotencyRequest != ""},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record stock movement: %w", err)
+	}
+
+	// Let other replicas know the stock of this product changed
+	return notifyStockChanged(ctx, tx, movement.ProductID)
+}
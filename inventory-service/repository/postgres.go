@@ -1,15 +1,29 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// NewPostgresConnection creates a new PostgreSQL connection
+// NewPostgresConnection creates a new PostgreSQL connection. Statements run
+// within a trace, including the serializable reservation transactions, are
+// recorded as spans of that trace.
 func NewPostgresConnection(databaseURL string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", databaseURL)
+	db, err := otelsql.Open("postgres", databaseURL,
+		otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			SpanFilter:           inTrace,
+		}),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
@@ -27,6 +41,12 @@ func NewPostgresConnection(databaseURL string) (*sql.DB, error) {
 	return db, nil
 }
 
+// inTrace only lets statements issued within a trace create spans, so that
+// the reservation reaper and health checks do not start a trace on every pass
+func inTrace(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+	return trace.SpanContextFromContext(ctx).IsValid()
+}
+
 // createTables creates the necessary tables if they don't exist
 func createTables(db *sql.DB) error {
 	// Create products table
@@ -69,5 +89,188 @@ func createTables(db *sql.DB) error {
 		return err
 	}
 
+	// Add reservation expiry for databases created before it existed
+	_, err = db.Exec(`
+		ALTER TABLE reservations ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Index expiry so the reaper can find expired reservations cheaply
+	_, err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_reservations_expires_at ON reservations(expires_at) WHERE expires_at IS NOT NULL
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create stock_commits table recording reservations turned into deductions
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS stock_commits (
+			order_id VARCHAR(255) NOT NULL,
+			product_id VARCHAR(255) NOT NULL REFERENCES products(id),
+			quantity INT NOT NULL,
+			committed_at TIMESTAMP NOT NULL,
+			PRIMARY KEY(order_id, product_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create inventory_movements table, an append-only ledger of stock changes
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS inventory_movements (
+			id BIGSERIAL PRIMARY KEY,
+			product_id VARCHAR(255) NOT NULL,
+			quantity_delta INT NOT NULL,
+			reserved_delta INT NOT NULL,
+			order_id VARCHAR(255),
+			reason VARCHAR(50) NOT NULL,
+			actor VARCHAR(255) NOT NULL,
+			created_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Index movements for per-product history queries
+	_, err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_inventory_movements_product_created ON inventory_movements(product_id, created_at)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add idempotency keys for manual stock adjustments
+	_, err = db.Exec(`
+		ALTER TABLE inventory_movements ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Remember what was requested under an idempotency key, so that reusing
+	// the key for a different adjustment is rejected
+	_, err = db.Exec(`
+		ALTER TABLE inventory_movements ADD COLUMN IF NOT EXISTS idempotency_request VARCHAR(255)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Ensure an idempotency key is applied at most once
+	_, err = db.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_inventory_movements_idempotency_key ON inventory_movements(idempotency_key) WHERE idempotency_key IS NOT NULL
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create warehouses table for the locations stock is held in
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS warehouses (
+			id VARCHAR(255) PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			priority INT NOT NULL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Ensure the default warehouse exists; stock from before warehouses existed lives there
+	_, err = db.Exec(`
+		INSERT INTO warehouses (id, name) VALUES ('default', 'Default warehouse') ON CONFLICT (id) DO NOTHING
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Key inventory by warehouse and product
+	_, err = db.Exec(`
+		ALTER TABLE inventory ADD COLUMN IF NOT EXISTS warehouse_id VARCHAR(255) NOT NULL DEFAULT 'default' REFERENCES warehouses(id)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_index x JOIN pg_attribute a ON a.attrelid = x.indrelid AND a.attnum = ANY(x.indkey)
+				WHERE x.indrelid = 'inventory'::regclass AND x.indisprimary AND a.attname = 'warehouse_id'
+			) THEN
+				ALTER TABLE inventory DROP CONSTRAINT IF EXISTS inventory_pkey;
+				ALTER TABLE inventory ADD PRIMARY KEY (warehouse_id, product_id);
+			END IF;
+		END $$
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Index inventory by product for aggregated stock queries
+	_, err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_inventory_product_id ON inventory(product_id)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Record which warehouse each reservation was taken from
+	_, err = db.Exec(`
+		ALTER TABLE reservations ADD COLUMN IF NOT EXISTS warehouse_id VARCHAR(255) NOT NULL DEFAULT 'default' REFERENCES warehouses(id)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		ALTER TABLE reservations DROP CONSTRAINT IF EXISTS reservations_order_id_product_id_key
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_reservations_order_product_warehouse ON reservations(order_id, product_id, warehouse_id)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Record which warehouse committed stock was deducted from
+	_, err = db.Exec(`
+		ALTER TABLE stock_commits ADD COLUMN IF NOT EXISTS warehouse_id VARCHAR(255) NOT NULL DEFAULT 'default'
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_index x JOIN pg_attribute a ON a.attrelid = x.indrelid AND a.attnum = ANY(x.indkey)
+				WHERE x.indrelid = 'stock_commits'::regclass AND x.indisprimary AND a.attname = 'warehouse_id'
+			) THEN
+				ALTER TABLE stock_commits DROP CONSTRAINT IF EXISTS stock_commits_pkey;
+				ALTER TABLE stock_commits ADD PRIMARY KEY (order_id, product_id, warehouse_id);
+			END IF;
+		END $$
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Record which warehouse each movement happened in
+	_, err = db.Exec(`
+		ALTER TABLE inventory_movements ADD COLUMN IF NOT EXISTS warehouse_id VARCHAR(255) NOT NULL DEFAULT 'default'
+	`)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ProductFilter narrows and pages a product listing
+type ProductFilter struct {
+	NamePrefix string
+	AfterID    string // only products with an ID greater than this are returned
+	Limit      int
+}
+
+// ProductWithStock represents a product together with its inventory. The
+// stock levels are summed over every warehouse in Locations.
+type ProductWithStock struct {
+	Product   Product
+	Quantity  int
+	Reserved  int
+	UpdatedAt time.Time
+	Locations []WarehouseStock
+}
+
+// Available returns the quantity that can still be reserved
+func (p *ProductWithStock) Available() int {
+	return p.Quantity - p.Reserved
+}
+
+// ListProducts lists products ordered by ID
+func (r *inventoryRepository) ListProducts(ctx context.Context, filter ProductFilter) ([]*Product, error) {
+	// Query products after the cursor, matching the name prefix
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, name, description, price FROM products
+		 WHERE id > $1 AND name ILIKE $2 ESCAPE '\'
+		 ORDER BY id LIMIT $3`,
+		filter.AfterID, escapeLike(filter.NamePrefix)+"%", filter.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query products: %w", err)
+	}
+	defer rows.Close()
+
+	// Scan products
+	products := []*Product{}
+	for rows.Next() {
+		product := &Product{}
+		var description sql.NullString
+		err := rows.Scan(&product.ID, &product.Name, &description, &product.Price)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		product.Description = description.String
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate products: %w", err)
+	}
+
+	return products, nil
+}
+
+// GetProducts gets the products with the given IDs; unknown IDs are skipped
+func (r *inventoryRepository) GetProducts(ctx context.Context, productIDs []string) ([]*Product, error) {
+	// Query products
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT id, name, description, price FROM products WHERE id = ANY($1) ORDER BY id",
+		pq.Array(productIDs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query products: %w", err)
+	}
+	defer rows.Close()
+
+	// Scan products
+	products := []*Product{}
+	for rows.Next() {
+		product := &Product{}
+		var description sql.NullString
+		err := rows.Scan(&product.ID, &product.Name, &description, &product.Price)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		product.Description = description.String
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate products: %w", err)
+	}
+
+	return products, nil
+}
+
+// CreateProductWithStock creates a product and its inventory row in a single transaction
+func (r *inventoryRepository) CreateProductWithStock(ctx context.Context, product *Product, quantity int) error {
+	// Start a transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Insert product
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO products (id, name, description, price) VALUES ($1, $2, $3, $4)",
+		product.ID, product.Name, product.Description, product.Price,
+	)
+	if err != nil {
+		if hasCode(err, "23505") {
+			return failedPreconditionf("product already exists: %s", product.ID)
+		}
+		return fmt.Errorf("failed to insert product: %w", err)
+	}
+
+	// Insert inventory
+	now := time.Now()
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO inventory (warehouse_id, product_id, quantity, reserved, updated_at) VALUES ($1, $2, $3, 0, $4)",
+		DefaultWarehouseID, product.ID, quantity, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert inventory: %w", err)
+	}
+
+	// Record the initial stock in the ledger
+	err = recordMovement(ctx, tx, Movement{
+		ProductID:     product.ID,
+		QuantityDelta: quantity,
+		Reason:        ReasonRestock,
+		CreatedAt:     now,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateProduct updates the catalog details of a product
+func (r *inventoryRepository) UpdateProduct(ctx context.Context, product *Product) error {
+	// Update product
+	result, err := r.db.ExecContext(
+		ctx,
+		"UPDATE products SET name = $1, description = $2, price = $3 WHERE id = $4",
+		product.Name, product.Description, product.Price, product.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update product: %w", err)
+	}
+
+	// Check the product existed
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return notFoundf("product not found: %s", product.ID)
+	}
+
+	return nil
+}
+
+// DeleteProduct deletes a product and its inventory, recording the removed
+// stock in the ledger. Products with outstanding reservations, or that orders
+// have reserved or bought before, cannot be deleted.
+func (r *inventoryRepository) DeleteProduct(ctx context.Context, productID string) error {
+	// Start a transaction
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock inventory so no reservation can be taken while deleting
+	stock, err := lockStock(ctx, tx, productID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	reserved := 0
+	for _, s := range stock {
+		reserved += s.Reserved
+	}
+	if reserved > 0 {
+		return failedPreconditionf("product %s has %d reserved units and cannot be deleted", productID, reserved)
+	}
+
+	// Record the removed stock in the ledger
+	for _, s := range stock {
+		if s.Quantity == 0 {
+			continue
+		}
+		err = recordMovement(ctx, tx, Movement{
+			ProductID:     productID,
+			WarehouseID:   s.WarehouseID,
+			QuantityDelta: -s.Quantity,
+			Reason:        ReasonRemoval,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Delete inventory
+	_, err = tx.ExecContext(ctx, "DELETE FROM inventory WHERE product_id = $1", productID)
+	if err != nil {
+		return fmt.Errorf("failed to delete inventory: %w", err)
+	}
+
+	// Delete product
+	result, err := tx.ExecContext(ctx, "DELETE FROM products WHERE id = $1", productID)
+	if err != nil {
+		if hasCode(err, "23503") {
+			return failedPreconditionf("product %s has order history and cannot be deleted", productID)
+		}
+		return fmt.Errorf("failed to delete product: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return notFoundf("product not found: %s", productID)
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetProductWithStock gets a product together with its stock levels in every
+// warehouse. Products without an inventory row are reported with zero stock.
+func (r *inventoryRepository) GetProductWithStock(ctx context.Context, productID string) (*ProductWithStock, error) {
+	// Query product
+	result := &ProductWithStock{Locations: []WarehouseStock{}}
+	var description sql.NullString
+	err := r.db.QueryRowContext(
+		ctx,
+		"SELECT id, name, description, price FROM products WHERE id = $1",
+		productID,
+	).Scan(&result.Product.ID, &result.Product.Name, &description, &result.Product.Price)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, notFoundf("product not found: %s", productID)
+		}
+		return nil, fmt.Errorf("failed to scan product: %w", err)
+	}
+	result.Product.Description = description.String
+
+	// Query stock per warehouse
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT i.warehouse_id, w.priority, i.quantity, i.reserved, i.updated_at
+		 FROM inventory i JOIN warehouses w ON w.id = i.warehouse_id
+		 WHERE i.product_id = $1 ORDER BY i.warehouse_id`,
+		productID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query inventory: %w", err)
+	}
+	defer rows.Close()
+
+	// Scan stock, summing it over warehouses
+	for rows.Next() {
+		var stock WarehouseStock
+		if err := rows.Scan(&stock.WarehouseID, &stock.Priority, &stock.Quantity, &stock.Reserved, &stock.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan inventory: %w", err)
+		}
+		result.Quantity += stock.Quantity
+		result.Reserved += stock.Reserved
+		if stock.UpdatedAt.After(result.UpdatedAt) {
+			result.UpdatedAt = stock.UpdatedAt
+		}
+		result.Locations = append(result.Locations, stock)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate inventory: %w", err)
+	}
+
+	return result, nil
+}
+
+// escapeLike escapes LIKE wildcards so s is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// AdjustStock adds delta (which may be negative) to the on-hand quantity of a
+// product in a warehouse. Repeating a call with the same idempotency key returns the current
+// stock without applying the change again; reusing the key for a different change fails.
+func (r *inventoryRepository) AdjustStock(ctx context.Context, productID, warehouseID string, delta int, reason MovementReason, idempotencyKey string) (*Inventory, error) {
+	return r.adjustStock(ctx, productID, warehouseID, reason, idempotencyKey, fmt.Sprintf("adjust %d", delta), func(quantity int) int {
+		return quantity + delta
+	})
+}
+
+// SetStock sets the on-hand quantity of a product in a warehouse to an absolute
+// value, e.g. after a stock count. Repeating a call with the same idempotency key returns
+// the current stock without applying the change again; reusing the key for a different
+// change fails.
+func (r *inventoryRepository) SetStock(ctx context.Context, productID, warehouseID string, quantity int, reason MovementReason, idempotencyKey string) (*Inventory, error) {
+	return r.adjustStock(ctx, productID, warehouseID, reason, idempotencyKey, fmt.Sprintf("set %d", quantity), func(int) int {
+		return quantity
+	})
+}
+
+// adjustStock locks the inventory row of a product in a warehouse, computes its
+// new quantity and records the change as a movement, refusing to drop below the
+// reserved quantity. A warehouse that does not stock the product yet starts at zero.
+// request describes the change, e.g. "adjust 5", and is stored with the idempotency
+// key so that a replay can be told apart from a different change under the same key.
+func (r *inventoryRepository) adjustStock(ctx context.Context, productID, warehouseID string, reason MovementReason, idempotencyKey, request string, newQuantity func(quantity int) int) (*Inventory, error) {
+	inventory, err := r.tryAdjustStock(ctx, productID, warehouseID, reason, idempotencyKey, request, newQuantity)
+	if idempotencyKey != "" && hasCode(err, "23505") {
+		// A concurrent request with the same key stored its movement first;
+		// try again so that this one is answered as a replay of it
+		return r.tryAdjustStock(ctx, productID, warehouseID, reason, idempotencyKey, request, newQuantity)
+	}
+	return inventory, err
+}
+
+// tryAdjustStock makes a single attempt at adjustStock in its own transaction
+func (r *inventoryRepository) tryAdjustStock(ctx context.Context, productID, warehouseID string, reason MovementReason, idempotencyKey, request string, newQuantity func(quantity int) int) (*Inventory, error) {
+	// Start a transaction
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Query the warehouse's inventory with lock
+	quantity, reserved, err := lockWarehouseInventory(ctx, tx, productID, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	inventory := &Inventory{ProductID: productID, WarehouseID: warehouseID, Quantity: quantity, Reserved: reserved}
+
+	// Return the current stock if this adjustment was already applied
+	if idempotencyKey != "" {
+		var appliedTo, appliedIn, appliedReason string
+		var appliedRequest sql.NullString
+		err := tx.QueryRowContext(
+			ctx,
+			"SELECT product_id, warehouse_id, reason, idempotency_request FROM inventory_movements WHERE idempotency_key = $1",
+			idempotencyKey,
+		).Scan(&appliedTo, &appliedIn, &appliedReason, &appliedRequest)
+		if err == nil {
+			if appliedTo != productID || appliedIn != warehouseID {
+				return nil, failedPreconditionf("idempotency key %s was already used for product %s in warehouse %s", idempotencyKey, appliedTo, appliedIn)
+			}
+			// Keys stored before requests were recorded can only be checked by reason
+			if appliedReason != string(reason) || (appliedRequest.Valid && appliedRequest.String != request) {
+				return nil, failedPreconditionf("idempotency key %s was already used for %s with reason %s", idempotencyKey, appliedRequest.String, appliedReason)
+			}
+			return inventory, nil
+		}
+		if err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to read idempotency key: %w", err)
+		}
+	}
+
+	// Never drop below what is already promised to orders
+	target := newQuantity(quantity)
+	if target < reserved {
+		return nil, failedPreconditionf("quantity %d would drop below reserved %d", target, reserved)
+	}
+
+	// Apply the change
+	delta := target - quantity
+	now := time.Now()
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO inventory (warehouse_id, product_id, quantity, reserved, updated_at) VALUES ($1, $2, $3, 0, $4) ON CONFLICT (warehouse_id, product_id) DO UPDATE SET quantity = EXCLUDED.quantity, updated_at = EXCLUDED.updated_at",
+		warehouseID, productID, target, now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update inventory: %w", err)
+	}
+
+	// Record the change in the ledger, even when the quantity did not move,
+	// so the idempotency key is remembered
+	err = recordMovement(ctx, tx, Movement{
+		ProductID:          productID,
+		WarehouseID:        warehouseID,
+		QuantityDelta:      delta,
+		Reason:             reason,
+		CreatedAt:          now,
+		IdempotencyKey:     idempotencyKey,
+		IdempotencyRequest: request,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	inventory.Quantity = target
+	inventory.UpdatedAt = now
+	return inventory, nil
+}
+
+// lockWarehouseInventory reads the stock of a product in a warehouse and locks
+// its row until the transaction ends. A warehouse that does not stock the
+// product yet reports zero, provided both the product and the warehouse exist.
+func lockWarehouseInventory(ctx context.Context, tx *sql.Tx, productID, warehouseID string) (quantity, reserved int, err error) {
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT quantity, reserved FROM inventory WHERE warehouse_id = $1 AND product_id = $2 FOR UPDATE",
+		warehouseID, productID,
+	).Scan(&quantity, &reserved)
+	if err == nil {
+		return quantity, reserved, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, 0, fmt.Errorf("failed to scan inventory: %w", err)
+	}
+
+	// Check the product and the warehouse exist before stocking one in the other
+	var productExists, warehouseExists bool
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT EXISTS(SELECT 1 FROM products WHERE id = $1), EXISTS(SELECT 1 FROM warehouses WHERE id = $2)",
+		productID, warehouseID,
+	).Scan(&productExists, &warehouseExists)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to check product and warehouse: %w", err)
+	}
+	if !productExists {
+		return 0, 0, notFoundf("product not found: %s", productID)
+	}
+	if !warehouseExists {
+		return 0, 0, notFoundf("warehouse not found: %s", warehouseID)
+	}
+	return 0, 0, nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// StockChangedChannel is the PostgreSQL NOTIFY channel that carries the ID of
+// every product whose stock changed; notifications are delivered on commit
+const StockChangedChannel = "inventory_stock_changed"
+
+// GetStockLevels returns the stock of the given products summed over all
+// warehouses; unknown products are omitted
+func (r *inventoryRepository) GetStockLevels(ctx context.Context, productIDs []string) ([]*Inventory, error) {
+	// Query inventory, aggregated per product
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT product_id, SUM(quantity), SUM(reserved), MAX(updated_at) FROM inventory WHERE product_id = ANY($1) GROUP BY product_id ORDER BY product_id",
+		pq.Array(productIDs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stock levels: %w", err)
+	}
+	defer rows.Close()
+
+	// Scan inventory
+	levels := []*Inventory{}
+	for rows.Next() {
+		inventory := &Inventory{}
+		if err := rows.Scan(&inventory.ProductID, &inventory.Quantity, &inventory.Reserved, &inventory.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan stock level: %w", err)
+		}
+		levels = append(levels, inventory)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate stock levels: %w", err)
+	}
+
+	return levels, nil
+}
+
+// ListStockLevels returns the stock of every product that has inventory,
+// summed over all warehouses
+func (r *inventoryRepository) ListStockLevels(ctx context.Context) ([]*Inventory, error) {
+	// Query inventory, aggregated per product
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT product_id, SUM(quantity), SUM(reserved), MAX(updated_at) FROM inventory GROUP BY product_id ORDER BY product_id",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stock levels: %w", err)
+	}
+	defer rows.Close()
+
+	// Scan inventory
+	levels := []*Inventory{}
+	for rows.Next() {
+		inventory := &Inventory{}
+		if err := rows.Scan(&inventory.ProductID, &inventory.Quantity, &inventory.Reserved, &inventory.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan stock level: %w", err)
+		}
+		levels = append(levels, inventory)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate stock levels: %w", err)
+	}
+
+	return levels, nil
+}
+
+// notifyStockChanged queues a stock change notification for a product within a transaction
+func notifyStockChanged(ctx context.Context, tx *sql.Tx, productID string) error {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_notify($1, $2)", StockChangedChannel, productID); err != nil {
+		return fmt.Errorf("failed to notify stock change: %w", err)
+	}
+	return nil
+}
+
+// stockChangeRetryInterval is how long the listener waits before listening
+// again after LISTEN failed
+const stockChangeRetryInterval = 5 * time.Second
+
+// StockChangeListener receives stock change notifications from every replica
+// sharing the database
+type StockChangeListener struct {
+	databaseURL string
+}
+
+// NewStockChangeListener creates a new stock change listener
+func NewStockChangeListener(databaseURL string) *StockChangeListener {
+	return &StockChangeListener{databaseURL: databaseURL}
+}
+
+// Run listens on StockChangedChannel and calls handle with the product ID of
+// each notification until the context is cancelled. Whenever listening starts,
+// including after a reconnect, handle is called with an empty product ID, since
+// notifications may have been missed. A failed LISTEN is retried rather than
+// ending the listener.
+func (l *StockChangeListener) Run(ctx context.Context, handle func(productID string)) {
+	for {
+		err := l.listen(ctx, handle)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.WarnContext(ctx, "Failed to listen for stock changes, retrying", "error", err, "retry_in", stockChangeRetryInterval)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(stockChangeRetryInterval):
+		}
+	}
+}
+
+// listen delivers notifications over a single listener until the context is
+// cancelled or LISTEN fails
+func (l *StockChangeListener) listen(ctx context.Context, handle func(productID string)) error {
+	listener := pq.NewListener(l.databaseURL, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.WarnContext(ctx, "Stock change listener error", "error", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(StockChangedChannel); err != nil {
+		return fmt.Errorf("failed to listen for stock changes: %w", err)
+	}
+	handle("")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-listener.Notify:
+			// A nil notification means the connection was re-established
+			if notification == nil {
+				handle("")
+				continue
+			}
+			handle(notification.Extra)
+		}
+	}
+}
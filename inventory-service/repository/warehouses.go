@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultWarehouseID is the warehouse that holds stock created without a location
+const DefaultWarehouseID = "default"
+
+// Warehouse represents a location stock is held in
+type Warehouse struct {
+	ID        string
+	Name      string
+	Priority  int // lower values are allocated first
+	CreatedAt time.Time
+}
+
+// CreateWarehouse creates a new warehouse
+func (r *inventoryRepository) CreateWarehouse(ctx context.Context, warehouse *Warehouse) error {
+	// Set timestamp
+	warehouse.CreatedAt = time.Now()
+
+	// Insert warehouse
+	_, err := r.db.ExecContext(
+		ctx,
+		"INSERT INTO warehouses (id, name, priority, created_at) VALUES ($1, $2, $3, $4)",
+		warehouse.ID, warehouse.Name, warehouse.Priority, warehouse.CreatedAt,
+	)
+	if err != nil {
+		if hasCode(err, "23505") {
+			return failedPreconditionf("warehouse already exists: %s", warehouse.ID)
+		}
+		return fmt.Errorf("failed to insert warehouse: %w", err)
+	}
+
+	return nil
+}
+
+// ListWarehouses lists warehouses in allocation priority order
+func (r *inventoryRepository) ListWarehouses(ctx context.Context) ([]*Warehouse, error) {
+	// Query warehouses
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT id, name, priority, created_at FROM warehouses ORDER BY priority, id",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query warehouses: %w", err)
+	}
+	defer rows.Close()
+
+	// Scan warehouses
+	warehouses := []*Warehouse{}
+	for rows.Next() {
+		warehouse := &Warehouse{}
+		if err := rows.Scan(&warehouse.ID, &warehouse.Name, &warehouse.Priority, &warehouse.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan warehouse: %w", err)
+		}
+		warehouses = append(warehouses, warehouse)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate warehouses: %w", err)
+	}
+
+	return warehouses, nil
+}
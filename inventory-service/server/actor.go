@@ -0,0 +1,23 @@
+package server
+
+import (
+	"context"
+
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// ActorMetadataKey is the gRPC metadata key naming the caller responsible for a stock change
+const ActorMetadataKey = "x-actor"
+
+// UnaryActorInterceptor attaches the actor from the request metadata to the context
+// so that stock movements record who caused them
+func UnaryActorInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if actors := md.Get(ActorMetadataKey); len(actors) > 0 && actors[0] != "" {
+			ctx = repository.WithActor(ctx, actors[0])
+		}
+	}
+	return handler(ctx, req)
+}
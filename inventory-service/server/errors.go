@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	inventorypb "github.com/fardannozami/golang-microservice/inventory-service/proto"
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+	"github.com/fardannozami/golang-microservice/inventory-service/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// internalMessage is sent to callers instead of the text of unexpected errors,
+// which may describe database internals
+const internalMessage = "internal error"
+
+// statusError converts a service error into a gRPC status error; errors that
+// already carry a status, such as stream send failures, are returned as they
+// are. Unexpected errors are logged and reported with a generic message.
+func statusError(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var insufficient *service.InsufficientStockError
+	switch {
+	case errors.As(err, &insufficient):
+		st, detailErr := status.New(codes.FailedPrecondition, err.Error()).WithDetails(&errdetails.ErrorInfo{
+			Reason: inventorypb.ReasonInsufficientStock,
+			Domain: inventorypb.ErrorDomain,
+			Metadata: map[string]string{
+				"product_id": insufficient.ProductID,
+				"available":  strconv.Itoa(insufficient.Available),
+				"requested":  strconv.Itoa(insufficient.Requested),
+			},
+		})
+		if detailErr != nil {
+			return status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return st.Err()
+	case errors.Is(err, service.ErrInvalidArgument):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrFailedPrecondition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, service.ErrUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		slog.ErrorContext(ctx, "Call failed", "error", err)
+		return status.Error(codes.Internal, internalMessage)
+	}
+}
+
+// reservationError converts a failed ReserveItems call into a gRPC status error.
+// Since none of the lines were reserved, the result of every line is attached
+// as a ReservationResult detail, after the ErrorInfo of the first line that
+// lacked stock, so that callers can report all of them at once.
+func reservationError(ctx context.Context, err error, results []repository.ReservationResult) error {
+	statusErr := statusError(ctx, err)
+	if len(results) == 0 {
+		return statusErr
+	}
+
+	st := status.Convert(statusErr)
+	for _, result := range results {
+		withResult, detailErr := st.WithDetails(toReservationResultProto(result))
+		if detailErr != nil {
+			return statusErr
+		}
+		st = withResult
+	}
+	return st.Err()
+}
@@ -2,10 +2,13 @@ package server
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"time"
 
 	inventorypb "github.com/fardannozami/golang-microservice/inventory-service/proto"
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
 	"github.com/fardannozami/golang-microservice/inventory-service/service"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // InventoryServer implements the gRPC server for inventory service
@@ -21,14 +24,11 @@ func NewInventoryServer(service service.InventoryService) *InventoryServer {
 
 // CheckStock checks if a product is available in inventory
 func (s *InventoryServer) CheckStock(ctx context.Context, req *inventorypb.CheckStockRequest) (*inventorypb.CheckStockResponse, error) {
-	log.Printf("[inventory-service] CheckStock product_id=%s qty=%d", req.ProductId, req.Quantity)
+	slog.InfoContext(ctx, "CheckStock", "product_id", req.ProductId, "quantity", req.Quantity, "warehouse_id", req.WarehouseId)
 	// Call service
-	available, err := s.service.CheckStock(ctx, req.ProductId, int(req.Quantity))
+	available, err := s.service.CheckStock(ctx, req.ProductId, req.WarehouseId, int(req.Quantity))
 	if err != nil {
-		return &inventorypb.CheckStockResponse{
-			Available: false,
-			Message:   err.Error(),
-		}, nil
+		return nil, statusError(ctx, err)
 	}
 
 	// Return response
@@ -40,14 +40,11 @@ func (s *InventoryServer) CheckStock(ctx context.Context, req *inventorypb.Check
 
 // ReserveStock reserves stock for an order
 func (s *InventoryServer) ReserveStock(ctx context.Context, req *inventorypb.ReserveStockRequest) (*inventorypb.ReserveStockResponse, error) {
-	log.Printf("[inventory-service] ReserveStock product_id=%s qty=%d order_id=%s", req.ProductId, req.Quantity, req.OrderId)
+	slog.InfoContext(ctx, "ReserveStock", "order_id", req.OrderId, "product_id", req.ProductId, "quantity", req.Quantity, "ttl", seconds(req.TtlSeconds), "no_expiry", req.NoExpiry)
 	// Call service
-	err := s.service.ReserveStock(ctx, req.ProductId, int(req.Quantity), req.OrderId)
+	err := s.service.ReserveStock(ctx, req.ProductId, int(req.Quantity), req.OrderId, reservationTTL(req.TtlSeconds, req.NoExpiry))
 	if err != nil {
-		return &inventorypb.ReserveStockResponse{
-			Success: false,
-			Message: err.Error(),
-		}, nil
+		return nil, statusError(ctx, err)
 	}
 
 	// Return response
@@ -59,14 +56,11 @@ func (s *InventoryServer) ReserveStock(ctx context.Context, req *inventorypb.Res
 
 // ReleaseStock releases reserved stock
 func (s *InventoryServer) ReleaseStock(ctx context.Context, req *inventorypb.ReleaseStockRequest) (*inventorypb.ReleaseStockResponse, error) {
-	log.Printf("[inventory-service] ReleaseStock product_id=%s qty=%d order_id=%s", req.ProductId, req.Quantity, req.OrderId)
+	slog.InfoContext(ctx, "ReleaseStock", "order_id", req.OrderId, "product_id", req.ProductId, "quantity", req.Quantity)
 	// Call service
 	err := s.service.ReleaseStock(ctx, req.ProductId, int(req.Quantity), req.OrderId)
 	if err != nil {
-		return &inventorypb.ReleaseStockResponse{
-			Success: false,
-			Message: err.Error(),
-		}, nil
+		return nil, statusError(ctx, err)
 	}
 
 	// Return response
@@ -75,3 +69,148 @@ func (s *InventoryServer) ReleaseStock(ctx context.Context, req *inventorypb.Rel
 		Message: "",
 	}, nil
 }
+
+// ReserveItems reserves stock for all items of an order in a single transaction
+func (s *InventoryServer) ReserveItems(ctx context.Context, req *inventorypb.ReserveItemsRequest) (*inventorypb.ReserveItemsResponse, error) {
+	slog.InfoContext(ctx, "ReserveItems", "order_id", req.OrderId, "items", len(req.Items), "no_expiry", req.NoExpiry)
+	// Convert request items
+	items := make([]repository.ReservationItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = repository.ReservationItem{
+			ProductID: item.ProductId,
+			Quantity:  int(item.Quantity),
+		}
+	}
+
+	// Call service
+	results, err := s.service.ReserveItems(ctx, req.OrderId, items, reservationTTL(req.TtlSeconds, req.NoExpiry))
+	if err != nil {
+		return nil, reservationError(ctx, err, results)
+	}
+
+	// Convert per-line results
+	resp := &inventorypb.ReserveItemsResponse{
+		Success: true,
+		Results: make([]*inventorypb.ReservationResult, len(results)),
+	}
+	for i, result := range results {
+		resp.Results[i] = toReservationResultProto(result)
+	}
+
+	// Return response
+	return resp, nil
+}
+
+// ExtendReservation extends the expiry of every reservation held by an order
+func (s *InventoryServer) ExtendReservation(ctx context.Context, req *inventorypb.ExtendReservationRequest) (*inventorypb.ExtendReservationResponse, error) {
+	slog.InfoContext(ctx, "ExtendReservation", "order_id", req.OrderId, "ttl", seconds(req.TtlSeconds))
+	// Call service
+	expiresAt, err := s.service.ExtendReservation(ctx, req.OrderId, seconds(req.TtlSeconds))
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	// Return response
+	return &inventorypb.ExtendReservationResponse{
+		Success:   true,
+		Message:   "",
+		ExpiresAt: timestamppb.New(expiresAt),
+	}, nil
+}
+
+// CommitStock turns an order's reservations into a permanent stock deduction
+func (s *InventoryServer) CommitStock(ctx context.Context, req *inventorypb.CommitStockRequest) (*inventorypb.CommitStockResponse, error) {
+	slog.InfoContext(ctx, "CommitStock", "order_id", req.OrderId)
+	// Call service
+	commits, err := s.service.CommitStock(ctx, req.OrderId)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	// Convert committed lines, merging the warehouses of each product
+	items := []*inventorypb.ReservationItem{}
+	for _, commit := range commits {
+		if n := len(items); n > 0 && items[n-1].ProductId == commit.ProductID {
+			items[n-1].Quantity += int32(commit.Quantity)
+			continue
+		}
+		items = append(items, &inventorypb.ReservationItem{
+			ProductId: commit.ProductID,
+			Quantity:  int32(commit.Quantity),
+		})
+	}
+
+	// Return response
+	return &inventorypb.CommitStockResponse{
+		Success: true,
+		Message: "",
+		Items:   items,
+	}, nil
+}
+
+// ListMovements lists the stock movement ledger
+func (s *InventoryServer) ListMovements(ctx context.Context, req *inventorypb.ListMovementsRequest) (*inventorypb.ListMovementsResponse, error) {
+	slog.InfoContext(ctx, "ListMovements", "product_id", req.ProductId, "page_size", req.PageSize)
+	// Convert time range
+	var since, until time.Time
+	if req.Since != nil {
+		since = req.Since.AsTime()
+	}
+	if req.Until != nil {
+		until = req.Until.AsTime()
+	}
+
+	// Call service
+	page, err := s.service.ListMovements(ctx, req.ProductId, since, until, int(req.PageSize), req.PageToken)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	// Convert movements
+	movements := make([]*inventorypb.StockMovement, len(page.Movements))
+	for i, movement := range page.Movements {
+		movements[i] = &inventorypb.StockMovement{
+			Id:            movement.ID,
+			ProductId:     movement.ProductID,
+			QuantityDelta: int32(movement.QuantityDelta),
+			ReservedDelta: int32(movement.ReservedDelta),
+			OrderId:       movement.OrderID,
+			WarehouseId:   movement.WarehouseID,
+			Reason:        string(movement.Reason),
+			Actor:         movement.Actor,
+			CreatedAt:     timestamppb.New(movement.CreatedAt),
+		}
+	}
+
+	// Return response
+	return &inventorypb.ListMovementsResponse{
+		Success:       true,
+		Message:       "",
+		Movements:     movements,
+		NextPageToken: page.NextPageToken,
+	}, nil
+}
+
+// toReservationResultProto converts the result of one reservation line
+func toReservationResultProto(result repository.ReservationResult) *inventorypb.ReservationResult {
+	return &inventorypb.ReservationResult{
+		ProductId: result.ProductID,
+		Quantity:  int32(result.Quantity),
+		Success:   result.Success,
+		Available: int32(result.Available),
+		Message:   result.Message,
+	}
+}
+
+// seconds converts a protobuf seconds field into a duration
+func seconds(s int32) time.Duration {
+	return time.Duration(s) * time.Second
+}
+
+// reservationTTL converts the lifetime a reservation request asks for
+func reservationTTL(ttlSeconds int32, noExpiry bool) time.Duration {
+	if noExpiry {
+		return service.NoExpiry
+	}
+	return seconds(ttlSeconds)
+}
@@ -0,0 +1,25 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// RPCMetrics records handled gRPC calls, e.g. as Prometheus metrics
+type RPCMetrics interface {
+	ObserveRPC(method, code string, duration time.Duration)
+}
+
+// UnaryMetricsInterceptor records the status code and duration of every unary
+// call by its full method name, e.g. /inventory.InventoryService/ReserveItems
+func UnaryMetricsInterceptor(metrics RPCMetrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		metrics.ObserveRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
+		return resp, err
+	}
+}
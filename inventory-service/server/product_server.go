@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+
+	inventorypb "github.com/fardannozami/golang-microservice/inventory-service/proto"
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GetProduct gets a product from the catalog
+func (s *InventoryServer) GetProduct(ctx context.Context, req *inventorypb.GetProductRequest) (*inventorypb.GetProductResponse, error) {
+	slog.InfoContext(ctx, "GetProduct", "product_id", req.Id)
+	// Call service
+	product, err := s.service.GetProduct(ctx, req.Id)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	// Return response
+	return &inventorypb.GetProductResponse{
+		Success: true,
+		Message: "",
+		Product: toProductProto(product),
+	}, nil
+}
+
+// BatchGetProducts gets several products at once
+func (s *InventoryServer) BatchGetProducts(ctx context.Context, req *inventorypb.BatchGetProductsRequest) (*inventorypb.BatchGetProductsResponse, error) {
+	slog.InfoContext(ctx, "BatchGetProducts", "product_ids", req.Ids)
+	// Call service
+	products, err := s.service.BatchGetProducts(ctx, req.Ids)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	// Convert products
+	result := make([]*inventorypb.Product, len(products))
+	for i, product := range products {
+		result[i] = toProductProto(product)
+	}
+
+	// Return response
+	return &inventorypb.BatchGetProductsResponse{
+		Success:  true,
+		Message:  "",
+		Products: result,
+	}, nil
+}
+
+// ListProducts lists catalog products page by page
+func (s *InventoryServer) ListProducts(ctx context.Context, req *inventorypb.ListProductsRequest) (*inventorypb.ListProductsResponse, error) {
+	slog.InfoContext(ctx, "ListProducts", "page_size", req.PageSize, "name_prefix", req.NamePrefix)
+	// Call service
+	page, err := s.service.ListProducts(ctx, int(req.PageSize), req.PageToken, req.NamePrefix)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	// Convert products
+	products := make([]*inventorypb.Product, len(page.Products))
+	for i, product := range page.Products {
+		products[i] = toProductProto(product)
+	}
+
+	// Return response
+	return &inventorypb.ListProductsResponse{
+		Success:       true,
+		Message:       "",
+		Products:      products,
+		NextPageToken: page.NextPageToken,
+	}, nil
+}
+
+// CreateProduct creates a product together with its initial stock
+func (s *InventoryServer) CreateProduct(ctx context.Context, req *inventorypb.CreateProductRequest) (*inventorypb.CreateProductResponse, error) {
+	slog.InfoContext(ctx, "CreateProduct", "product_id", req.GetProduct().GetId(), "initial_quantity", req.InitialQuantity)
+	// Call service
+	product := fromProductProto(req.Product)
+	err := s.service.CreateProduct(ctx, product, int(req.InitialQuantity))
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	// Return response
+	return &inventorypb.CreateProductResponse{
+		Success: true,
+		Message: "",
+		Product: toProductProto(product),
+	}, nil
+}
+
+// UpdateProduct updates the catalog details of a product
+func (s *InventoryServer) UpdateProduct(ctx context.Context, req *inventorypb.UpdateProductRequest) (*inventorypb.UpdateProductResponse, error) {
+	slog.InfoContext(ctx, "UpdateProduct", "product_id", req.GetProduct().GetId())
+	// Call service
+	product := fromProductProto(req.Product)
+	err := s.service.UpdateProduct(ctx, product)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	// Return response
+	return &inventorypb.UpdateProductResponse{
+		Success: true,
+		Message: "",
+		Product: toProductProto(product),
+	}, nil
+}
+
+// DeleteProduct deletes a product and its inventory
+func (s *InventoryServer) DeleteProduct(ctx context.Context, req *inventorypb.DeleteProductRequest) (*inventorypb.DeleteProductResponse, error) {
+	slog.InfoContext(ctx, "DeleteProduct", "product_id", req.Id)
+	// Call service
+	err := s.service.DeleteProduct(ctx, req.Id)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	// Return response
+	return &inventorypb.DeleteProductResponse{
+		Success: true,
+		Message: "",
+	}, nil
+}
+
+// GetProductWithStock gets a product together with its stock levels
+func (s *InventoryServer) GetProductWithStock(ctx context.Context, req *inventorypb.GetProductWithStockRequest) (*inventorypb.GetProductWithStockResponse, error) {
+	slog.InfoContext(ctx, "GetProductWithStock", "product_id", req.Id)
+	// Call service
+	result, err := s.service.GetProductWithStock(ctx, req.Id)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	// Convert stock levels
+	stock := &inventorypb.StockLevel{
+		Quantity:  int32(result.Quantity),
+		Reserved:  int32(result.Reserved),
+		Available: int32(result.Available()),
+	}
+	if !result.UpdatedAt.IsZero() {
+		stock.UpdatedAt = timestamppb.New(result.UpdatedAt)
+	}
+	locations := make([]*inventorypb.StockLevel, len(result.Locations))
+	for i, location := range result.Locations {
+		locations[i] = toWarehouseStockProto(location)
+	}
+
+	// Return response
+	return &inventorypb.GetProductWithStockResponse{
+		Success:   true,
+		Message:   "",
+		Product:   toProductProto(&result.Product),
+		Stock:     stock,
+		Locations: locations,
+	}, nil
+}
+
+// toProductProto converts a product into its protobuf representation
+func toProductProto(product *repository.Product) *inventorypb.Product {
+	return &inventorypb.Product{
+		Id:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+	}
+}
+
+// fromProductProto converts a protobuf product into a product, treating nil as empty
+func fromProductProto(product *inventorypb.Product) *repository.Product {
+	return &repository.Product{
+		ID:          product.GetId(),
+		Name:        product.GetName(),
+		Description: product.GetDescription(),
+		Price:       product.GetPrice(),
+	}
+}
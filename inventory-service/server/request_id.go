@@ -0,0 +1,27 @@
+package server
+
+import (
+	"context"
+
+	"github.com/fardannozami/golang-microservice/inventory-service/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDMetadataKey is the gRPC metadata key carrying the ID of the request
+// that made the call, e.g. the X-Request-ID of an order service HTTP request
+const RequestIDMetadataKey = "x-request-id"
+
+// maxRequestIDLength is the longest request ID taken over from a caller
+const maxRequestIDLength = 128
+
+// UnaryRequestIDInterceptor attaches the request ID from the request metadata
+// to the context so that every record logged for the call carries it
+func UnaryRequestIDInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(RequestIDMetadataKey); len(ids) > 0 && ids[0] != "" && len(ids[0]) <= maxRequestIDLength {
+			ctx = logging.WithRequestID(ctx, ids[0])
+		}
+	}
+	return handler(ctx, req)
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+
+	inventorypb "github.com/fardannozami/golang-microservice/inventory-service/proto"
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// AdjustStock adds or removes on-hand stock
+func (s *InventoryServer) AdjustStock(ctx context.Context, req *inventorypb.AdjustStockRequest) (*inventorypb.AdjustStockResponse, error) {
+	slog.InfoContext(ctx, "AdjustStock", "product_id", req.ProductId, "warehouse_id", req.WarehouseId, "delta", req.Delta, "reason", req.Reason, "actor", repository.ActorFromContext(ctx))
+	// Call service
+	inventory, err := s.service.AdjustStock(ctx, req.ProductId, req.WarehouseId, int(req.Delta), req.Reason, req.IdempotencyKey)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	// Return response
+	return &inventorypb.AdjustStockResponse{
+		Success: true,
+		Message: "",
+		Stock:   toStockLevelProto(inventory),
+	}, nil
+}
+
+// SetStock sets on-hand stock to a counted quantity
+func (s *InventoryServer) SetStock(ctx context.Context, req *inventorypb.SetStockRequest) (*inventorypb.SetStockResponse, error) {
+	slog.InfoContext(ctx, "SetStock", "product_id", req.ProductId, "warehouse_id", req.WarehouseId, "quantity", req.Quantity, "reason", req.Reason, "actor", repository.ActorFromContext(ctx))
+	// Call service
+	inventory, err := s.service.SetStock(ctx, req.ProductId, req.WarehouseId, int(req.Quantity), req.Reason, req.IdempotencyKey)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	// Return response
+	return &inventorypb.SetStockResponse{
+		Success: true,
+		Message: "",
+		Stock:   toStockLevelProto(inventory),
+	}, nil
+}
+
+// toStockLevelProto converts an inventory entry into its protobuf stock level
+func toStockLevelProto(inventory *repository.Inventory) *inventorypb.StockLevel {
+	stock := &inventorypb.StockLevel{
+		Quantity:    int32(inventory.Quantity),
+		Reserved:    int32(inventory.Reserved),
+		Available:   int32(inventory.Quantity - inventory.Reserved),
+		WarehouseId: inventory.WarehouseID,
+	}
+	if !inventory.UpdatedAt.IsZero() {
+		stock.UpdatedAt = timestamppb.New(inventory.UpdatedAt)
+	}
+	return stock
+}
+
+// toWarehouseStockProto converts the stock of one warehouse into its protobuf stock level
+func toWarehouseStockProto(location repository.WarehouseStock) *inventorypb.StockLevel {
+	return toStockLevelProto(&repository.Inventory{
+		WarehouseID: location.WarehouseID,
+		Quantity:    location.Quantity,
+		Reserved:    location.Reserved,
+		UpdatedAt:   location.UpdatedAt,
+	})
+}
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"log/slog"
+
+	inventorypb "github.com/fardannozami/golang-microservice/inventory-service/proto"
+	"github.com/fardannozami/golang-microservice/inventory-service/service"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// WatchStock streams the available quantity of products as it changes
+func (s *InventoryServer) WatchStock(req *inventorypb.WatchStockRequest, stream grpc.ServerStreamingServer[inventorypb.StockUpdate]) error {
+	slog.InfoContext(stream.Context(), "WatchStock", "product_ids", req.ProductIds)
+	// Call service; it returns once the client goes away
+	err := s.service.WatchStock(stream.Context(), req.ProductIds, func(update service.StockUpdate) error {
+		return stream.Send(&inventorypb.StockUpdate{
+			ProductId: update.ProductID,
+			Available: int32(update.Available),
+			UpdatedAt: timestamppb.New(update.UpdatedAt),
+		})
+	})
+	if err != nil {
+		return statusError(stream.Context(), err)
+	}
+
+	return nil
+}
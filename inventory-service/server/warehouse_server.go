@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+
+	inventorypb "github.com/fardannozami/golang-microservice/inventory-service/proto"
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CreateWarehouse creates a warehouse that stock can be held in
+func (s *InventoryServer) CreateWarehouse(ctx context.Context, req *inventorypb.CreateWarehouseRequest) (*inventorypb.CreateWarehouseResponse, error) {
+	slog.InfoContext(ctx, "CreateWarehouse", "warehouse_id", req.GetWarehouse().GetId(), "priority", req.GetWarehouse().GetPriority())
+	// Call service
+	warehouse := &repository.Warehouse{
+		ID:       req.GetWarehouse().GetId(),
+		Name:     req.GetWarehouse().GetName(),
+		Priority: int(req.GetWarehouse().GetPriority()),
+	}
+	if err := s.service.CreateWarehouse(ctx, warehouse); err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	// Return response
+	return &inventorypb.CreateWarehouseResponse{
+		Success:   true,
+		Message:   "",
+		Warehouse: toWarehouseProto(warehouse),
+	}, nil
+}
+
+// ListWarehouses lists warehouses in allocation priority order
+func (s *InventoryServer) ListWarehouses(ctx context.Context, req *inventorypb.ListWarehousesRequest) (*inventorypb.ListWarehousesResponse, error) {
+	slog.InfoContext(ctx, "ListWarehouses")
+	// Call service
+	warehouses, err := s.service.ListWarehouses(ctx)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	// Convert warehouses
+	result := make([]*inventorypb.Warehouse, len(warehouses))
+	for i, warehouse := range warehouses {
+		result[i] = toWarehouseProto(warehouse)
+	}
+
+	// Return response
+	return &inventorypb.ListWarehousesResponse{
+		Success:    true,
+		Message:    "",
+		Warehouses: result,
+	}, nil
+}
+
+// toWarehouseProto converts a warehouse into its protobuf representation
+func toWarehouseProto(warehouse *repository.Warehouse) *inventorypb.Warehouse {
+	return &inventorypb.Warehouse{
+		Id:        warehouse.ID,
+		Name:      warehouse.Name,
+		Priority:  int32(warehouse.Priority),
+		CreatedAt: timestamppb.New(warehouse.CreatedAt),
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+)
+
+// Error kinds returned by InventoryService; match them with errors.Is
+var (
+	// ErrInvalidArgument is returned when a request fails validation
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrNotFound is returned when a product or reservation does not exist
+	ErrNotFound = repository.ErrNotFound
+	// ErrFailedPrecondition is returned when the current stock does not allow a change
+	ErrFailedPrecondition = repository.ErrFailedPrecondition
+	// ErrUnavailable is returned when the database cannot be reached or a
+	// transaction conflicted; the request may succeed when retried
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// InsufficientStockError reports the available and requested quantity when a
+// reservation cannot be made. It matches ErrFailedPrecondition.
+type InsufficientStockError = repository.InsufficientStockError
+
+// kindError attaches an error kind to an error without changing its message
+type kindError struct {
+	kind error
+	err  error
+}
+
+// Error implements error
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the kind and the underlying error
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// invalidArgumentf formats an error that matches ErrInvalidArgument
+func invalidArgumentf(format string, args ...any) error {
+	return &kindError{kind: ErrInvalidArgument, err: fmt.Errorf(format, args...)}
+}
+
+// notFoundf formats an error that matches ErrNotFound
+func notFoundf(format string, args ...any) error {
+	return &kindError{kind: ErrNotFound, err: fmt.Errorf(format, args...)}
+}
+
+// repoError marks transient repository failures as ErrUnavailable
+func repoError(err error) error {
+	if err != nil && repository.IsTransient(err) {
+		return &kindError{kind: ErrUnavailable, err: err}
+	}
+	return err
+}
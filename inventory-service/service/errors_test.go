@@ -0,0 +1,74 @@
+package service_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+	"github.com/fardannozami/golang-microservice/inventory-service/service"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestErrors_InvalidArgument(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	err := inventoryService.ReleaseStock(context.Background(), "", 1, "order123")
+
+	assert.ErrorIs(t, err, service.ErrInvalidArgument)
+	assert.Equal(t, "product ID is required", err.Error())
+}
+
+func TestErrors_NotFoundPassesThrough(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	notFound := fmt.Errorf("product not found: product123: %w", repository.ErrNotFound)
+	repo.On("GetProduct", mock.Anything, "product123").Return(nil, notFound)
+
+	_, err := inventoryService.GetProduct(context.Background(), "product123")
+
+	assert.ErrorIs(t, err, service.ErrNotFound)
+	assert.NotErrorIs(t, err, service.ErrUnavailable)
+}
+
+func TestErrors_DatabaseDownIsUnavailable(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	repo.On("ReleaseStock", mock.Anything, "product123", 1, "order123").Return(fmt.Errorf("failed to begin transaction: %w", driver.ErrBadConn))
+
+	err := inventoryService.ReleaseStock(context.Background(), "product123", 1, "order123")
+
+	assert.ErrorIs(t, err, service.ErrUnavailable)
+	assert.ErrorIs(t, err, driver.ErrBadConn)
+}
+
+func TestErrors_SerializationFailureIsUnavailable(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	conflict := fmt.Errorf("failed to commit transaction: %w", &pq.Error{Code: "40001"})
+	repo.On("CommitStock", mock.Anything, "order123").Return(nil, conflict)
+
+	_, err := inventoryService.CommitStock(context.Background(), "order123")
+
+	assert.ErrorIs(t, err, service.ErrUnavailable)
+}
+
+func TestErrors_ConstraintViolationIsNotUnavailable(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	violation := fmt.Errorf("failed to update inventory: %w", &pq.Error{Code: "23514"})
+	repo.On("ReleaseStock", mock.Anything, "product123", 1, "order123").Return(violation)
+
+	err := inventoryService.ReleaseStock(context.Background(), "product123", 1, "order123")
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, service.ErrUnavailable)
+}
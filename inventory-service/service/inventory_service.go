@@ -2,81 +2,287 @@ package service
 
 import (
 	"context"
-	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/fardannozami/golang-microservice/inventory-service/repository"
 )
 
 // InventoryService defines the interface for inventory service operations
 type InventoryService interface {
-	CheckStock(ctx context.Context, productID string, quantity int) (bool, error)
-	ReserveStock(ctx context.Context, productID string, quantity int, orderID string) error
+	CheckStock(ctx context.Context, productID, warehouseID string, quantity int) (bool, error)
+	ReserveStock(ctx context.Context, productID string, quantity int, orderID string, ttl time.Duration) error
 	ReleaseStock(ctx context.Context, productID string, quantity int, orderID string) error
+	ReserveItems(ctx context.Context, orderID string, items []repository.ReservationItem, ttl time.Duration) ([]repository.ReservationResult, error)
+	ExtendReservation(ctx context.Context, orderID string, ttl time.Duration) (time.Time, error)
+	ReleaseExpiredReservations(ctx context.Context, now time.Time) (int, error)
+	CommitStock(ctx context.Context, orderID string) ([]repository.StockCommit, error)
+	GetProduct(ctx context.Context, productID string) (*repository.Product, error)
+	BatchGetProducts(ctx context.Context, productIDs []string) ([]*repository.Product, error)
+	ListProducts(ctx context.Context, pageSize int, pageToken, namePrefix string) (*ProductPage, error)
+	CreateProduct(ctx context.Context, product *repository.Product, initialQuantity int) error
+	UpdateProduct(ctx context.Context, product *repository.Product) error
+	DeleteProduct(ctx context.Context, productID string) error
+	GetProductWithStock(ctx context.Context, productID string) (*repository.ProductWithStock, error)
+	ListMovements(ctx context.Context, productID string, since, until time.Time, pageSize int, pageToken string) (*MovementPage, error)
+	AdjustStock(ctx context.Context, productID, warehouseID string, delta int, reason, idempotencyKey string) (*repository.Inventory, error)
+	SetStock(ctx context.Context, productID, warehouseID string, quantity int, reason, idempotencyKey string) (*repository.Inventory, error)
+	CreateWarehouse(ctx context.Context, warehouse *repository.Warehouse) error
+	ListWarehouses(ctx context.Context) ([]*repository.Warehouse, error)
+	WatchStock(ctx context.Context, productIDs []string, send func(StockUpdate) error) error
+	PublishStockChanges(ctx context.Context, productIDs ...string)
 }
 
+// expiredReservationBatchSize limits how many expired reservations are released per batch
+const expiredReservationBatchSize = 100
+
+// NoExpiry as a reservation ttl holds the reservation until it is released or
+// committed, regardless of the configured default
+const NoExpiry time.Duration = -1
+
 // inventoryService implements InventoryService interface
 type inventoryService struct {
-	repo repository.InventoryRepository
+	repo           repository.InventoryRepository
+	reservationTTL time.Duration
+
+	// watchers receives the availability of products after their stock changes
+	watchers                 *stockBroadcaster
+	changes                  stockChanges
+	sharedStockNotifications bool
+}
+
+// Option configures an inventory service
+type Option func(*inventoryService)
+
+// WithReservationTTL sets the lifetime used for reservations that do not specify one
+func WithReservationTTL(ttl time.Duration) Option {
+	return func(s *inventoryService) {
+		s.reservationTTL = ttl
+	}
+}
+
+// WithSharedStockNotifications stops the service from publishing its own stock
+// changes to watchers; they are expected to arrive through PublishStockChanges
+// from a feed shared by all replicas, such as PostgreSQL LISTEN/NOTIFY
+func WithSharedStockNotifications() Option {
+	return func(s *inventoryService) {
+		s.sharedStockNotifications = true
+	}
 }
 
 // NewInventoryService creates a new inventory service
-func NewInventoryService(repo repository.InventoryRepository) InventoryService {
-	return &inventoryService{repo: repo}
+func NewInventoryService(repo repository.InventoryRepository, opts ...Option) InventoryService {
+	s := &inventoryService{
+		repo:     repo,
+		watchers: newStockBroadcaster(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-// CheckStock checks if a product is available in inventory
-func (s *inventoryService) CheckStock(ctx context.Context, productID string, quantity int) (bool, error) {
+// CheckStock checks if a product is available in a warehouse, or across all
+// warehouses when warehouseID is empty
+func (s *inventoryService) CheckStock(ctx context.Context, productID, warehouseID string, quantity int) (bool, error) {
 	// Validate input
 	if productID == "" {
-		return false, fmt.Errorf("product ID is required")
+		return false, invalidArgumentf("product ID is required")
 	}
 	if quantity <= 0 {
-		return false, fmt.Errorf("quantity must be positive")
+		return false, invalidArgumentf("quantity must be positive")
 	}
 
 	// Check stock in repository
-	return s.repo.CheckStock(ctx, productID, quantity)
+	available, err := s.repo.CheckStock(ctx, productID, warehouseID, quantity)
+	if err != nil {
+		return false, repoError(err)
+	}
+	return available, nil
 }
 
-// ReserveStock reserves stock for an order
-func (s *inventoryService) ReserveStock(ctx context.Context, productID string, quantity int, orderID string) error {
+// ReserveStock sets the stock an order holds of a product to quantity, reserving
+// more or releasing the difference; a zero quantity releases everything the
+// order holds, a zero ttl uses the configured default and NoExpiry never expires
+func (s *inventoryService) ReserveStock(ctx context.Context, productID string, quantity int, orderID string, ttl time.Duration) error {
 	// Validate input
 	if productID == "" {
-		return fmt.Errorf("product ID is required")
+		return invalidArgumentf("product ID is required")
 	}
-	if quantity <= 0 {
-		return fmt.Errorf("quantity must be positive")
+	if quantity < 0 {
+		return invalidArgumentf("quantity must not be negative")
 	}
 	if orderID == "" {
-		return fmt.Errorf("order ID is required")
+		return invalidArgumentf("order ID is required")
 	}
-
-	// Check stock availability
-	available, err := s.repo.CheckStock(ctx, productID, quantity)
-	if err != nil {
-		return err
+	if ttl < 0 && ttl != NoExpiry {
+		return invalidArgumentf("ttl must not be negative")
 	}
-	if !available {
-		return fmt.Errorf("insufficient stock")
+
+	// Reserve stock; availability is checked against the order's own
+	// reservation inside the transaction
+	if err := s.repo.ReserveStock(ctx, productID, quantity, orderID, s.expiresAt(ttl)); err != nil {
+		return repoError(err)
 	}
 
-	// Reserve stock if available
-	return s.repo.ReserveStock(ctx, productID, quantity, orderID)
+	s.stockChanged(ctx, productID)
+	return nil
 }
 
 // ReleaseStock releases reserved stock
 func (s *inventoryService) ReleaseStock(ctx context.Context, productID string, quantity int, orderID string) error {
 	// Validate input
 	if productID == "" {
-		return fmt.Errorf("product ID is required")
+		return invalidArgumentf("product ID is required")
 	}
 	if quantity <= 0 {
-		return fmt.Errorf("quantity must be positive")
+		return invalidArgumentf("quantity must be positive")
 	}
 	if orderID == "" {
-		return fmt.Errorf("order ID is required")
+		return invalidArgumentf("order ID is required")
 	}
 
 	// Release stock in repository
-	return s.repo.ReleaseStock(ctx, productID, quantity, orderID)
+	if err := s.repo.ReleaseStock(ctx, productID, quantity, orderID); err != nil {
+		return repoError(err)
+	}
+
+	s.stockChanged(ctx, productID)
+	return nil
+}
+
+// ReserveItems sets the stock an order holds of every item atomically, like
+// ReserveStock does for a single product; a zero ttl uses the configured default
+// and NoExpiry never expires
+func (s *inventoryService) ReserveItems(ctx context.Context, orderID string, items []repository.ReservationItem, ttl time.Duration) ([]repository.ReservationResult, error) {
+	// Validate input
+	if orderID == "" {
+		return nil, invalidArgumentf("order ID is required")
+	}
+	if len(items) == 0 {
+		return nil, invalidArgumentf("at least one item is required")
+	}
+	if ttl < 0 && ttl != NoExpiry {
+		return nil, invalidArgumentf("ttl must not be negative")
+	}
+	seen := make(map[string]bool, len(items))
+	for i, item := range items {
+		if item.ProductID == "" {
+			return nil, invalidArgumentf("product ID is required for item %d", i)
+		}
+		if item.Quantity < 0 {
+			return nil, invalidArgumentf("quantity must not be negative for item %d", i)
+		}
+		if seen[item.ProductID] {
+			return nil, invalidArgumentf("duplicate product ID %s for item %d", item.ProductID, i)
+		}
+		seen[item.ProductID] = true
+	}
+
+	// Reserve all items in a single transaction
+	results, err := s.repo.ReserveItems(ctx, orderID, items, s.expiresAt(ttl))
+	if err != nil {
+		return results, repoError(err)
+	}
+
+	productIDs := make([]string, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+	s.stockChanged(ctx, productIDs...)
+	return results, nil
+}
+
+// ExtendReservation extends every reservation held by an order by ttl from now
+func (s *inventoryService) ExtendReservation(ctx context.Context, orderID string, ttl time.Duration) (time.Time, error) {
+	// Validate input
+	if orderID == "" {
+		return time.Time{}, invalidArgumentf("order ID is required")
+	}
+	if ttl <= 0 {
+		return time.Time{}, invalidArgumentf("ttl must be positive")
+	}
+
+	// Extend reservations in repository
+	expiresAt := time.Now().Add(ttl)
+	extended, err := s.repo.ExtendReservation(ctx, orderID, expiresAt)
+	if err != nil {
+		return time.Time{}, repoError(err)
+	}
+	if extended == 0 {
+		return time.Time{}, notFoundf("no reservations found for order %s", orderID)
+	}
+
+	return expiresAt, nil
+}
+
+// ReleaseExpiredReservations releases reservations that expired at or before now
+// and returns how many were released. Reservations are released in batches
+// until a batch comes back short or releases nothing, so that a batch of
+// reservations that keep failing is not retried forever.
+func (s *inventoryService) ReleaseExpiredReservations(ctx context.Context, now time.Time) (int, error) {
+	released := 0
+	for {
+		// Find expired reservations
+		reservations, err := s.repo.ListExpiredReservations(ctx, now, expiredReservationBatchSize)
+		if err != nil {
+			return released, repoError(err)
+		}
+
+		// Release each reservation independently so one failure does not block the rest
+		batch := 0
+		productIDs := []string{}
+		for _, reservation := range reservations {
+			ok, err := s.repo.ReleaseExpiredReservation(ctx, reservation, now)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to release expired reservation", "order_id", reservation.OrderID, "product_id", reservation.ProductID, "error", err)
+				continue
+			}
+			if ok {
+				batch++
+				productIDs = append(productIDs, reservation.ProductID)
+			}
+		}
+		s.stockChanged(ctx, productIDs...)
+		released += batch
+
+		if len(reservations) < expiredReservationBatchSize || batch == 0 {
+			return released, nil
+		}
+	}
+}
+
+// CommitStock permanently deducts the stock reserved by an order
+func (s *inventoryService) CommitStock(ctx context.Context, orderID string) ([]repository.StockCommit, error) {
+	// Validate input
+	if orderID == "" {
+		return nil, invalidArgumentf("order ID is required")
+	}
+
+	// Commit stock in repository
+	commits, err := s.repo.CommitStock(ctx, orderID)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	productIDs := make([]string, len(commits))
+	for i, commit := range commits {
+		productIDs[i] = commit.ProductID
+	}
+	s.stockChanged(ctx, productIDs...)
+	return commits, nil
+}
+
+// expiresAt computes the expiry for a reservation, returning zero when it never expires
+func (s *inventoryService) expiresAt(ttl time.Duration) time.Time {
+	if ttl == NoExpiry {
+		return time.Time{}
+	}
+	if ttl == 0 {
+		ttl = s.reservationTTL
+	}
+	if ttl == 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
 }
@@ -3,7 +3,9 @@ package service_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/fardannozami/golang-microservice/inventory-service/repository"
 	"github.com/fardannozami/golang-microservice/inventory-service/service"
@@ -16,13 +18,13 @@ type MockInventoryRepository struct {
 	mock.Mock
 }
 
-func (m *MockInventoryRepository) CheckStock(ctx context.Context, productID string, quantity int) (bool, error) {
-	args := m.Called(ctx, productID, quantity)
+func (m *MockInventoryRepository) CheckStock(ctx context.Context, productID, warehouseID string, quantity int) (bool, error) {
+	args := m.Called(ctx, productID, warehouseID, quantity)
 	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockInventoryRepository) ReserveStock(ctx context.Context, productID string, quantity int, orderID string) error {
-	args := m.Called(ctx, productID, quantity, orderID)
+func (m *MockInventoryRepository) ReserveStock(ctx context.Context, productID string, quantity int, orderID string, expiresAt time.Time) error {
+	args := m.Called(ctx, productID, quantity, orderID, expiresAt)
 	return args.Error(0)
 }
 
@@ -31,6 +33,40 @@ func (m *MockInventoryRepository) ReleaseStock(ctx context.Context, productID st
 	return args.Error(0)
 }
 
+func (m *MockInventoryRepository) ReserveItems(ctx context.Context, orderID string, items []repository.ReservationItem, expiresAt time.Time) ([]repository.ReservationResult, error) {
+	args := m.Called(ctx, orderID, items, expiresAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.ReservationResult), args.Error(1)
+}
+
+func (m *MockInventoryRepository) ExtendReservation(ctx context.Context, orderID string, expiresAt time.Time) (int, error) {
+	args := m.Called(ctx, orderID, expiresAt)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockInventoryRepository) ListExpiredReservations(ctx context.Context, now time.Time, limit int) ([]repository.Reservation, error) {
+	args := m.Called(ctx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.Reservation), args.Error(1)
+}
+
+func (m *MockInventoryRepository) ReleaseExpiredReservation(ctx context.Context, reservation repository.Reservation, now time.Time) (bool, error) {
+	args := m.Called(ctx, reservation, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockInventoryRepository) CommitStock(ctx context.Context, orderID string) ([]repository.StockCommit, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.StockCommit), args.Error(1)
+}
+
 func (m *MockInventoryRepository) GetProduct(ctx context.Context, productID string) (*repository.Product, error) {
 	args := m.Called(ctx, productID)
 	if args.Get(0) == nil {
@@ -44,18 +80,110 @@ func (m *MockInventoryRepository) CreateProduct(ctx context.Context, product *re
 	return args.Error(0)
 }
 
+func (m *MockInventoryRepository) GetProducts(ctx context.Context, productIDs []string) ([]*repository.Product, error) {
+	args := m.Called(ctx, productIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.Product), args.Error(1)
+}
+
+func (m *MockInventoryRepository) ListProducts(ctx context.Context, filter repository.ProductFilter) ([]*repository.Product, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.Product), args.Error(1)
+}
+
+func (m *MockInventoryRepository) CreateProductWithStock(ctx context.Context, product *repository.Product, quantity int) error {
+	args := m.Called(ctx, product, quantity)
+	return args.Error(0)
+}
+
+func (m *MockInventoryRepository) UpdateProduct(ctx context.Context, product *repository.Product) error {
+	args := m.Called(ctx, product)
+	return args.Error(0)
+}
+
+func (m *MockInventoryRepository) DeleteProduct(ctx context.Context, productID string) error {
+	args := m.Called(ctx, productID)
+	return args.Error(0)
+}
+
+func (m *MockInventoryRepository) GetProductWithStock(ctx context.Context, productID string) (*repository.ProductWithStock, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ProductWithStock), args.Error(1)
+}
+
+func (m *MockInventoryRepository) ListMovements(ctx context.Context, filter repository.MovementFilter) ([]repository.Movement, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.Movement), args.Error(1)
+}
+
+func (m *MockInventoryRepository) AdjustStock(ctx context.Context, productID, warehouseID string, delta int, reason repository.MovementReason, idempotencyKey string) (*repository.Inventory, error) {
+	args := m.Called(ctx, productID, warehouseID, delta, reason, idempotencyKey)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Inventory), args.Error(1)
+}
+
+func (m *MockInventoryRepository) SetStock(ctx context.Context, productID, warehouseID string, quantity int, reason repository.MovementReason, idempotencyKey string) (*repository.Inventory, error) {
+	args := m.Called(ctx, productID, warehouseID, quantity, reason, idempotencyKey)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Inventory), args.Error(1)
+}
+
+func (m *MockInventoryRepository) GetStockLevels(ctx context.Context, productIDs []string) ([]*repository.Inventory, error) {
+	args := m.Called(ctx, productIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.Inventory), args.Error(1)
+}
+
+func (m *MockInventoryRepository) ListStockLevels(ctx context.Context) ([]*repository.Inventory, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.Inventory), args.Error(1)
+}
+
 func (m *MockInventoryRepository) CreateInventory(ctx context.Context, inventory *repository.Inventory) error {
 	args := m.Called(ctx, inventory)
 	return args.Error(0)
 }
 
+func (m *MockInventoryRepository) CreateWarehouse(ctx context.Context, warehouse *repository.Warehouse) error {
+	args := m.Called(ctx, warehouse)
+	return args.Error(0)
+}
+
+func (m *MockInventoryRepository) ListWarehouses(ctx context.Context) ([]*repository.Warehouse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.Warehouse), args.Error(1)
+}
+
 func TestCheckStock_Success(t *testing.T) {
 	repo := new(MockInventoryRepository)
 	inventoryService := service.NewInventoryService(repo)
 
-	repo.On("CheckStock", mock.Anything, "product123", 2).Return(true, nil)
+	repo.On("CheckStock", mock.Anything, "product123", "", 2).Return(true, nil)
 
-	available, err := inventoryService.CheckStock(context.Background(), "product123", 2)
+	available, err := inventoryService.CheckStock(context.Background(), "product123", "", 2)
 
 	assert.NoError(t, err)
 	assert.True(t, available)
@@ -66,9 +194,9 @@ func TestCheckStock_Unavailable(t *testing.T) {
 	repo := new(MockInventoryRepository)
 	inventoryService := service.NewInventoryService(repo)
 
-	repo.On("CheckStock", mock.Anything, "product123", 2).Return(false, nil)
+	repo.On("CheckStock", mock.Anything, "product123", "", 2).Return(false, nil)
 
-	available, err := inventoryService.CheckStock(context.Background(), "product123", 2)
+	available, err := inventoryService.CheckStock(context.Background(), "product123", "", 2)
 
 	assert.NoError(t, err)
 	assert.False(t, available)
@@ -79,9 +207,9 @@ func TestCheckStock_Error(t *testing.T) {
 	repo := new(MockInventoryRepository)
 	inventoryService := service.NewInventoryService(repo)
 
-	repo.On("CheckStock", mock.Anything, "product123", 2).Return(false, errors.New("database error"))
+	repo.On("CheckStock", mock.Anything, "product123", "", 2).Return(false, errors.New("database error"))
 
-	available, err := inventoryService.CheckStock(context.Background(), "product123", 2)
+	available, err := inventoryService.CheckStock(context.Background(), "product123", "", 2)
 
 	assert.Error(t, err)
 	assert.False(t, available)
@@ -93,25 +221,56 @@ func TestReserveStock_Success(t *testing.T) {
 	repo := new(MockInventoryRepository)
 	inventoryService := service.NewInventoryService(repo)
 
-	repo.On("CheckStock", mock.Anything, "product123", 2).Return(true, nil)
-	repo.On("ReserveStock", mock.Anything, "product123", 2, "order123").Return(nil)
+	repo.On("ReserveStock", mock.Anything, "product123", 2, "order123", time.Time{}).Return(nil)
 
-	err := inventoryService.ReserveStock(context.Background(), "product123", 2, "order123")
+	err := inventoryService.ReserveStock(context.Background(), "product123", 2, "order123", 0)
 
 	assert.NoError(t, err)
 	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "CheckStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReserveStock_ReleaseAll(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	repo.On("ReserveStock", mock.Anything, "product123", 0, "order123", time.Time{}).Return(nil)
+
+	err := inventoryService.ReserveStock(context.Background(), "product123", 0, "order123", 0)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestReserveStock_NegativeQuantity(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	err := inventoryService.ReserveStock(context.Background(), "product123", -1, "order123", 0)
+
+	assert.ErrorIs(t, err, service.ErrInvalidArgument)
+	repo.AssertNotCalled(t, "ReserveStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestReserveStock_Unavailable(t *testing.T) {
 	repo := new(MockInventoryRepository)
 	inventoryService := service.NewInventoryService(repo)
 
-	repo.On("CheckStock", mock.Anything, "product123", 2).Return(false, nil)
+	repo.On("ReserveStock", mock.Anything, "product123", 2, "order123", time.Time{}).Return(&repository.InsufficientStockError{
+		ProductID: "product123",
+		Available: 1,
+		Requested: 2,
+	})
 
-	err := inventoryService.ReserveStock(context.Background(), "product123", 2, "order123")
+	err := inventoryService.ReserveStock(context.Background(), "product123", 2, "order123", 0)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "insufficient stock")
+	var insufficient *service.InsufficientStockError
+	assert.ErrorAs(t, err, &insufficient)
+	assert.Equal(t, 1, insufficient.Available)
+	assert.Equal(t, 2, insufficient.Requested)
+	assert.ErrorIs(t, err, service.ErrFailedPrecondition)
 	repo.AssertExpectations(t)
 }
 
@@ -119,10 +278,9 @@ func TestReserveStock_ReservationError(t *testing.T) {
 	repo := new(MockInventoryRepository)
 	inventoryService := service.NewInventoryService(repo)
 
-	repo.On("CheckStock", mock.Anything, "product123", 2).Return(true, nil)
-	repo.On("ReserveStock", mock.Anything, "product123", 2, "order123").Return(errors.New("reservation error"))
+	repo.On("ReserveStock", mock.Anything, "product123", 2, "order123", time.Time{}).Return(errors.New("reservation error"))
 
-	err := inventoryService.ReserveStock(context.Background(), "product123", 2, "order123")
+	err := inventoryService.ReserveStock(context.Background(), "product123", 2, "order123", 0)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "reservation error")
@@ -153,3 +311,201 @@ func TestReleaseStock_Error(t *testing.T) {
 	assert.Contains(t, err.Error(), "release error")
 	repo.AssertExpectations(t)
 }
+
+func TestReserveItems_Success(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	items := []repository.ReservationItem{
+		{ProductID: "product123", Quantity: 2},
+		{ProductID: "product456", Quantity: 1},
+	}
+	results := []repository.ReservationResult{
+		{ProductID: "product123", Quantity: 2, Success: true, Available: 5},
+		{ProductID: "product456", Quantity: 1, Success: true, Available: 3},
+	}
+	repo.On("ReserveItems", mock.Anything, "order123", items, time.Time{}).Return(results, nil)
+
+	got, err := inventoryService.ReserveItems(context.Background(), "order123", items, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, results, got)
+	repo.AssertExpectations(t)
+}
+
+func TestReserveItems_InsufficientStock(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	items := []repository.ReservationItem{
+		{ProductID: "product123", Quantity: 2},
+		{ProductID: "product456", Quantity: 10},
+	}
+	results := []repository.ReservationResult{
+		{ProductID: "product123", Quantity: 2, Available: 5},
+		{ProductID: "product456", Quantity: 10, Available: 3, Message: "insufficient stock: available 3, requested 10"},
+	}
+	repo.On("ReserveItems", mock.Anything, "order123", items, time.Time{}).Return(results, errors.New("product product456: insufficient stock: available 3, requested 10"))
+
+	got, err := inventoryService.ReserveItems(context.Background(), "order123", items, 0)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "insufficient stock")
+	assert.Len(t, got, 2)
+	assert.False(t, got[1].Success)
+	repo.AssertExpectations(t)
+}
+
+func TestReserveItems_DuplicateProduct(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	items := []repository.ReservationItem{
+		{ProductID: "product123", Quantity: 2},
+		{ProductID: "product123", Quantity: 1},
+	}
+
+	_, err := inventoryService.ReserveItems(context.Background(), "order123", items, 0)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate product ID")
+	repo.AssertNotCalled(t, "ReserveItems", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReserveStock_DefaultTTL(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo, service.WithReservationTTL(15*time.Minute))
+
+	repo.On("ReserveStock", mock.Anything, "product123", 2, "order123", mock.MatchedBy(func(expiresAt time.Time) bool {
+		return time.Until(expiresAt) > 14*time.Minute && time.Until(expiresAt) <= 15*time.Minute
+	})).Return(nil)
+
+	err := inventoryService.ReserveStock(context.Background(), "product123", 2, "order123", 0)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestReserveItems_RequestTTLOverridesDefault(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo, service.WithReservationTTL(15*time.Minute))
+
+	items := []repository.ReservationItem{{ProductID: "product123", Quantity: 2}}
+	repo.On("ReserveItems", mock.Anything, "order123", items, mock.MatchedBy(func(expiresAt time.Time) bool {
+		return time.Until(expiresAt) > 0 && time.Until(expiresAt) <= time.Minute
+	})).Return([]repository.ReservationResult{{ProductID: "product123", Quantity: 2, Success: true}}, nil)
+
+	_, err := inventoryService.ReserveItems(context.Background(), "order123", items, time.Minute)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestReserveItems_NoExpiryIgnoresDefault(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo, service.WithReservationTTL(15*time.Minute))
+
+	items := []repository.ReservationItem{{ProductID: "product123", Quantity: 2}}
+	repo.On("ReserveItems", mock.Anything, "order123", items, time.Time{}).Return([]repository.ReservationResult{{ProductID: "product123", Quantity: 2, Success: true}}, nil)
+
+	_, err := inventoryService.ReserveItems(context.Background(), "order123", items, service.NoExpiry)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestExtendReservation_Success(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	repo.On("ExtendReservation", mock.Anything, "order123", mock.AnythingOfType("time.Time")).Return(2, nil)
+
+	expiresAt, err := inventoryService.ExtendReservation(context.Background(), "order123", 10*time.Minute)
+
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), expiresAt, time.Second)
+	repo.AssertExpectations(t)
+}
+
+func TestExtendReservation_NotFound(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	repo.On("ExtendReservation", mock.Anything, "order123", mock.AnythingOfType("time.Time")).Return(0, nil)
+
+	_, err := inventoryService.ExtendReservation(context.Background(), "order123", 10*time.Minute)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no reservations found")
+	repo.AssertExpectations(t)
+}
+
+func TestReleaseExpiredReservations(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	now := time.Now()
+	expired := []repository.Reservation{
+		{OrderID: "order1", ProductID: "product123", Quantity: 2},
+		{OrderID: "order2", ProductID: "product123", Quantity: 1},
+		{OrderID: "order3", ProductID: "product456", Quantity: 4},
+	}
+	repo.On("ListExpiredReservations", mock.Anything, now, mock.AnythingOfType("int")).Return(expired, nil)
+	repo.On("ReleaseExpiredReservation", mock.Anything, expired[0], now).Return(true, nil)
+	repo.On("ReleaseExpiredReservation", mock.Anything, expired[1], now).Return(false, nil)
+	repo.On("ReleaseExpiredReservation", mock.Anything, expired[2], now).Return(false, errors.New("database error"))
+
+	released, err := inventoryService.ReleaseExpiredReservations(context.Background(), now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, released)
+	repo.AssertExpectations(t)
+}
+
+func TestReleaseExpiredReservations_ReleasesEveryBatch(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	now := time.Now()
+	full := make([]repository.Reservation, 100)
+	for i := range full {
+		full[i] = repository.Reservation{OrderID: fmt.Sprintf("order%d", i), ProductID: "product123", Quantity: 1}
+	}
+	short := []repository.Reservation{{OrderID: "order100", ProductID: "product123", Quantity: 1}}
+	repo.On("ListExpiredReservations", mock.Anything, now, 100).Return(full, nil).Once()
+	repo.On("ListExpiredReservations", mock.Anything, now, 100).Return(short, nil).Once()
+	repo.On("ReleaseExpiredReservation", mock.Anything, mock.Anything, now).Return(true, nil)
+
+	released, err := inventoryService.ReleaseExpiredReservations(context.Background(), now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 101, released)
+	repo.AssertExpectations(t)
+}
+
+func TestCommitStock_Success(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	commits := []repository.StockCommit{
+		{OrderID: "order123", ProductID: "product123", Quantity: 2},
+	}
+	repo.On("CommitStock", mock.Anything, "order123").Return(commits, nil)
+
+	result, err := inventoryService.CommitStock(context.Background(), "order123")
+
+	assert.NoError(t, err)
+	assert.Equal(t, commits, result)
+	repo.AssertExpectations(t)
+}
+
+func TestCommitStock_MissingOrderID(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	_, err := inventoryService.CommitStock(context.Background(), "")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "order ID is required")
+	repo.AssertNotCalled(t, "CommitStock", mock.Anything, mock.Anything)
+}
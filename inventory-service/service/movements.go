@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+)
+
+// MovementPage represents a single page of the stock movement ledger
+type MovementPage struct {
+	Movements     []repository.Movement
+	NextPageToken string
+}
+
+// ListMovements lists stock movements, optionally for one product and time range
+func (s *inventoryService) ListMovements(ctx context.Context, productID string, since, until time.Time, pageSize int, pageToken string) (*MovementPage, error) {
+	// Validate input
+	if pageSize < 0 {
+		return nil, invalidArgumentf("page size must not be negative")
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	if !since.IsZero() && !until.IsZero() && !since.Before(until) {
+		return nil, invalidArgumentf("since must be before until")
+	}
+	var afterID int64
+	if pageToken != "" {
+		token, err := decodePageToken(pageToken)
+		if err != nil {
+			return nil, err
+		}
+		afterID, err = strconv.ParseInt(token, 10, 64)
+		if err != nil {
+			return nil, invalidArgumentf("invalid page token")
+		}
+	}
+
+	// Fetch one extra movement to know whether another page exists
+	movements, err := s.repo.ListMovements(ctx, repository.MovementFilter{
+		ProductID: productID,
+		Since:     since,
+		Until:     until,
+		AfterID:   afterID,
+		Limit:     pageSize + 1,
+	})
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	page := &MovementPage{Movements: movements}
+	if len(movements) > pageSize {
+		page.Movements = movements[:pageSize]
+		page.NextPageToken = encodePageToken(strconv.FormatInt(page.Movements[pageSize-1].ID, 10))
+	}
+
+	return page, nil
+}
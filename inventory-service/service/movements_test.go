@@ -0,0 +1,62 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+	"github.com/fardannozami/golang-microservice/inventory-service/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListMovements_Paginates(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(24 * time.Hour)
+	repo.On("ListMovements", mock.Anything, repository.MovementFilter{ProductID: "prod-001", Since: since, Until: until, Limit: 3}).Return([]repository.Movement{
+		{ID: 1, ProductID: "prod-001", QuantityDelta: 10, Reason: repository.ReasonRestock},
+		{ID: 4, ProductID: "prod-001", ReservedDelta: 2, OrderID: "order123", Reason: repository.ReasonReserve},
+		{ID: 7, ProductID: "prod-001", QuantityDelta: -2, ReservedDelta: -2, OrderID: "order123", Reason: repository.ReasonSale},
+	}, nil)
+
+	page, err := inventoryService.ListMovements(context.Background(), "prod-001", since, until, 2, "")
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Movements, 2)
+	assert.NotEmpty(t, page.NextPageToken)
+
+	// The token continues after the last movement of the page
+	repo.On("ListMovements", mock.Anything, repository.MovementFilter{ProductID: "prod-001", Since: since, Until: until, AfterID: 4, Limit: 3}).Return([]repository.Movement{
+		{ID: 7, ProductID: "prod-001", QuantityDelta: -2, ReservedDelta: -2, OrderID: "order123", Reason: repository.ReasonSale},
+	}, nil)
+
+	page, err = inventoryService.ListMovements(context.Background(), "prod-001", since, until, 2, page.NextPageToken)
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Movements, 1)
+	assert.Empty(t, page.NextPageToken)
+	repo.AssertExpectations(t)
+}
+
+func TestListMovements_InvalidTimeRange(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	since := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	until := since.Add(-time.Hour)
+
+	_, err := inventoryService.ListMovements(context.Background(), "", since, until, 10, "")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "since must be before until")
+	repo.AssertNotCalled(t, "ListMovements", mock.Anything, mock.Anything)
+}
+
+func TestActorFromContext(t *testing.T) {
+	assert.Equal(t, "system", repository.ActorFromContext(context.Background()))
+	assert.Equal(t, "warehouse-1", repository.ActorFromContext(repository.WithActor(context.Background(), "warehouse-1")))
+}
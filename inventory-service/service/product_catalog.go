@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+)
+
+const (
+	// defaultPageSize is used when a listing does not specify a page size
+	defaultPageSize = 50
+	// maxPageSize caps the number of entries returned per page
+	maxPageSize = 100
+)
+
+// ProductPage represents a single page of a product listing
+type ProductPage struct {
+	Products      []*repository.Product
+	NextPageToken string
+}
+
+// GetProduct gets a product from the catalog
+func (s *inventoryService) GetProduct(ctx context.Context, productID string) (*repository.Product, error) {
+	// Validate input
+	if productID == "" {
+		return nil, invalidArgumentf("product ID is required")
+	}
+
+	// Get product from repository
+	product, err := s.repo.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	return product, nil
+}
+
+// BatchGetProducts gets several products at once and fails if any of them does not exist
+func (s *inventoryService) BatchGetProducts(ctx context.Context, productIDs []string) ([]*repository.Product, error) {
+	// Validate input
+	if len(productIDs) == 0 {
+		return nil, invalidArgumentf("at least one product ID is required")
+	}
+	for i, productID := range productIDs {
+		if productID == "" {
+			return nil, invalidArgumentf("product ID is required for item %d", i)
+		}
+	}
+
+	// Get products from repository
+	products, err := s.repo.GetProducts(ctx, productIDs)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	// Report the first product that does not exist
+	found := make(map[string]bool, len(products))
+	for _, product := range products {
+		found[product.ID] = true
+	}
+	for _, productID := range productIDs {
+		if !found[productID] {
+			return nil, notFoundf("product not found: %s", productID)
+		}
+	}
+
+	return products, nil
+}
+
+// ListProducts lists catalog products page by page
+func (s *inventoryService) ListProducts(ctx context.Context, pageSize int, pageToken, namePrefix string) (*ProductPage, error) {
+	// Validate input
+	if pageSize < 0 {
+		return nil, invalidArgumentf("page size must not be negative")
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	afterID, err := decodePageToken(pageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra product to know whether another page exists
+	products, err := s.repo.ListProducts(ctx, repository.ProductFilter{
+		NamePrefix: namePrefix,
+		AfterID:    afterID,
+		Limit:      pageSize + 1,
+	})
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	page := &ProductPage{Products: products}
+	if len(products) > pageSize {
+		page.Products = products[:pageSize]
+		page.NextPageToken = encodePageToken(page.Products[pageSize-1].ID)
+	}
+
+	return page, nil
+}
+
+// CreateProduct creates a product together with its initial stock
+func (s *inventoryService) CreateProduct(ctx context.Context, product *repository.Product, initialQuantity int) error {
+	// Validate input
+	if product == nil || product.ID == "" {
+		return invalidArgumentf("product ID is required")
+	}
+	if err := validateProduct(product); err != nil {
+		return err
+	}
+	if initialQuantity < 0 {
+		return invalidArgumentf("initial quantity must not be negative")
+	}
+
+	// Create product in repository
+	return repoError(s.repo.CreateProductWithStock(ctx, product, initialQuantity))
+}
+
+// UpdateProduct updates the catalog details of a product
+func (s *inventoryService) UpdateProduct(ctx context.Context, product *repository.Product) error {
+	// Validate input
+	if product == nil || product.ID == "" {
+		return invalidArgumentf("product ID is required")
+	}
+	if err := validateProduct(product); err != nil {
+		return err
+	}
+
+	// Update product in repository
+	return repoError(s.repo.UpdateProduct(ctx, product))
+}
+
+// DeleteProduct deletes a product and its inventory
+func (s *inventoryService) DeleteProduct(ctx context.Context, productID string) error {
+	// Validate input
+	if productID == "" {
+		return invalidArgumentf("product ID is required")
+	}
+
+	// Delete product in repository
+	return repoError(s.repo.DeleteProduct(ctx, productID))
+}
+
+// GetProductWithStock gets a product together with its stock levels
+func (s *inventoryService) GetProductWithStock(ctx context.Context, productID string) (*repository.ProductWithStock, error) {
+	// Validate input
+	if productID == "" {
+		return nil, invalidArgumentf("product ID is required")
+	}
+
+	// Get product from repository
+	product, err := s.repo.GetProductWithStock(ctx, productID)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	return product, nil
+}
+
+// validateProduct validates the catalog fields of a product
+func validateProduct(product *repository.Product) error {
+	if product.Name == "" {
+		return invalidArgumentf("product name is required")
+	}
+	if product.Price <= 0 {
+		return invalidArgumentf("price must be positive")
+	}
+	return nil
+}
+
+// encodePageToken encodes the last product ID of a page into an opaque token
+func encodePageToken(lastID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastID))
+}
+
+// decodePageToken decodes a page token back into the last product ID of the previous page
+func decodePageToken(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	lastID, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", invalidArgumentf("invalid page token")
+	}
+	return string(lastID), nil
+}
@@ -0,0 +1,166 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+	"github.com/fardannozami/golang-microservice/inventory-service/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetProduct_Success(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	product := &repository.Product{ID: "prod-001", Name: "Laptop Gaming", Price: 15000000}
+	repo.On("GetProduct", mock.Anything, "prod-001").Return(product, nil)
+
+	result, err := inventoryService.GetProduct(context.Background(), "prod-001")
+
+	assert.NoError(t, err)
+	assert.Equal(t, product, result)
+	repo.AssertExpectations(t)
+}
+
+func TestBatchGetProducts_Success(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	products := []*repository.Product{
+		{ID: "prod-001", Name: "Laptop Gaming", Price: 15000000},
+		{ID: "prod-002", Name: "Smartphone", Price: 8000000},
+	}
+	repo.On("GetProducts", mock.Anything, []string{"prod-002", "prod-001"}).Return(products, nil)
+
+	result, err := inventoryService.BatchGetProducts(context.Background(), []string{"prod-002", "prod-001"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, products, result)
+	repo.AssertExpectations(t)
+}
+
+func TestBatchGetProducts_MissingProduct(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	repo.On("GetProducts", mock.Anything, []string{"prod-001", "prod-999"}).Return([]*repository.Product{
+		{ID: "prod-001", Name: "Laptop Gaming", Price: 15000000},
+	}, nil)
+
+	_, err := inventoryService.BatchGetProducts(context.Background(), []string{"prod-001", "prod-999"})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "product not found: prod-999")
+	repo.AssertExpectations(t)
+}
+
+func TestListProducts_Paginates(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	repo.On("ListProducts", mock.Anything, repository.ProductFilter{NamePrefix: "smart", Limit: 3}).Return([]*repository.Product{
+		{ID: "prod-002", Name: "Smartphone"},
+		{ID: "prod-004", Name: "Smart Watch"},
+		{ID: "prod-006", Name: "Smart TV"},
+	}, nil)
+
+	page, err := inventoryService.ListProducts(context.Background(), 2, "", "smart")
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Products, 2)
+	assert.NotEmpty(t, page.NextPageToken)
+
+	// The token continues after the last product of the page
+	repo.On("ListProducts", mock.Anything, repository.ProductFilter{NamePrefix: "smart", AfterID: "prod-004", Limit: 3}).Return([]*repository.Product{
+		{ID: "prod-006", Name: "Smart TV"},
+	}, nil)
+
+	page, err = inventoryService.ListProducts(context.Background(), 2, page.NextPageToken, "smart")
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Products, 1)
+	assert.Empty(t, page.NextPageToken)
+	repo.AssertExpectations(t)
+}
+
+func TestListProducts_InvalidPageToken(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	_, err := inventoryService.ListProducts(context.Background(), 10, "not a token!", "")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid page token")
+	repo.AssertNotCalled(t, "ListProducts", mock.Anything, mock.Anything)
+}
+
+func TestCreateProduct_Success(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	product := &repository.Product{ID: "prod-006", Name: "Smart TV", Price: 7000000}
+	repo.On("CreateProductWithStock", mock.Anything, product, 5).Return(nil)
+
+	err := inventoryService.CreateProduct(context.Background(), product, 5)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestCreateProduct_InvalidPrice(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	err := inventoryService.CreateProduct(context.Background(), &repository.Product{ID: "prod-006", Name: "Smart TV"}, 5)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "price must be positive")
+	repo.AssertNotCalled(t, "CreateProductWithStock", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateProduct_Error(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	product := &repository.Product{ID: "prod-999", Name: "Unknown", Price: 1}
+	repo.On("UpdateProduct", mock.Anything, product).Return(errors.New("product not found: prod-999"))
+
+	err := inventoryService.UpdateProduct(context.Background(), product)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "product not found")
+	repo.AssertExpectations(t)
+}
+
+func TestDeleteProduct_Success(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	repo.On("DeleteProduct", mock.Anything, "prod-001").Return(nil)
+
+	err := inventoryService.DeleteProduct(context.Background(), "prod-001")
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestGetProductWithStock_Success(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	result := &repository.ProductWithStock{
+		Product:  repository.Product{ID: "prod-001", Name: "Laptop Gaming", Price: 15000000},
+		Quantity: 10,
+		Reserved: 3,
+	}
+	repo.On("GetProductWithStock", mock.Anything, "prod-001").Return(result, nil)
+
+	got, err := inventoryService.GetProductWithStock(context.Background(), "prod-001")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, got.Available())
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+)
+
+// adjustmentReasons lists the reason codes accepted for manual stock changes
+var adjustmentReasons = map[repository.MovementReason]bool{
+	repository.ReasonRestock:    true,
+	repository.ReasonDamage:     true,
+	repository.ReasonShrinkage:  true,
+	repository.ReasonCorrection: true,
+}
+
+// AdjustStock adds delta to the on-hand quantity of a product in a warehouse,
+// e.g. when goods arrive (restock) or are written off (damage, shrinkage). An
+// empty warehouseID adjusts the default warehouse.
+func (s *inventoryService) AdjustStock(ctx context.Context, productID, warehouseID string, delta int, reason, idempotencyKey string) (*repository.Inventory, error) {
+	// Validate input
+	if productID == "" {
+		return nil, invalidArgumentf("product ID is required")
+	}
+	if delta == 0 {
+		return nil, invalidArgumentf("delta must not be zero")
+	}
+	movementReason, err := parseAdjustmentReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	switch movementReason {
+	case repository.ReasonRestock:
+		if delta < 0 {
+			return nil, invalidArgumentf("restock delta must be positive")
+		}
+	case repository.ReasonDamage, repository.ReasonShrinkage:
+		if delta > 0 {
+			return nil, invalidArgumentf("%s delta must be negative", movementReason)
+		}
+	}
+
+	// Adjust stock in repository
+	inventory, err := s.repo.AdjustStock(ctx, productID, warehouseOrDefault(warehouseID), delta, movementReason, idempotencyKey)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	s.stockChanged(ctx, productID)
+	return inventory, nil
+}
+
+// SetStock sets the on-hand quantity of a product in a warehouse to the counted
+// value. An empty warehouseID sets the default warehouse.
+func (s *inventoryService) SetStock(ctx context.Context, productID, warehouseID string, quantity int, reason, idempotencyKey string) (*repository.Inventory, error) {
+	// Validate input
+	if productID == "" {
+		return nil, invalidArgumentf("product ID is required")
+	}
+	if quantity < 0 {
+		return nil, invalidArgumentf("quantity must not be negative")
+	}
+	if reason == "" {
+		reason = string(repository.ReasonCorrection)
+	}
+	movementReason, err := parseAdjustmentReason(reason)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set stock in repository
+	inventory, err := s.repo.SetStock(ctx, productID, warehouseOrDefault(warehouseID), quantity, movementReason, idempotencyKey)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	s.stockChanged(ctx, productID)
+	return inventory, nil
+}
+
+// parseAdjustmentReason validates a reason code for a manual stock change
+func parseAdjustmentReason(reason string) (repository.MovementReason, error) {
+	movementReason := repository.MovementReason(reason)
+	if !adjustmentReasons[movementReason] {
+		return "", invalidArgumentf("invalid reason %q: must be one of restock, damage, shrinkage, correction", reason)
+	}
+	return movementReason, nil
+}
@@ -0,0 +1,86 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+	"github.com/fardannozami/golang-microservice/inventory-service/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAdjustStock_Restock(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	inventory := &repository.Inventory{ProductID: "prod-001", Quantity: 15, Reserved: 2}
+	repo.On("AdjustStock", mock.Anything, "prod-001", "default", 5, repository.ReasonRestock, "delivery-42").Return(inventory, nil)
+
+	result, err := inventoryService.AdjustStock(context.Background(), "prod-001", "", 5, "restock", "delivery-42")
+
+	assert.NoError(t, err)
+	assert.Equal(t, inventory, result)
+	repo.AssertExpectations(t)
+}
+
+func TestAdjustStock_InvalidReason(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	_, err := inventoryService.AdjustStock(context.Background(), "prod-001", "", 5, "sale", "")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid reason")
+	repo.AssertNotCalled(t, "AdjustStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAdjustStock_DamageMustBeNegative(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	_, err := inventoryService.AdjustStock(context.Background(), "prod-001", "", 3, "damage", "")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "damage delta must be negative")
+	repo.AssertNotCalled(t, "AdjustStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAdjustStock_BelowReserved(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	repo.On("AdjustStock", mock.Anything, "prod-001", "default", -10, repository.ReasonShrinkage, "").Return(nil, errors.New("quantity 0 would drop below reserved 2"))
+
+	_, err := inventoryService.AdjustStock(context.Background(), "prod-001", "", -10, "shrinkage", "")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "below reserved")
+	repo.AssertExpectations(t)
+}
+
+func TestSetStock_DefaultsToCorrection(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	inventory := &repository.Inventory{ProductID: "prod-001", Quantity: 8, Reserved: 2}
+	repo.On("SetStock", mock.Anything, "prod-001", "default", 8, repository.ReasonCorrection, "count-2025-01").Return(inventory, nil)
+
+	result, err := inventoryService.SetStock(context.Background(), "prod-001", "", 8, "", "count-2025-01")
+
+	assert.NoError(t, err)
+	assert.Equal(t, inventory, result)
+	repo.AssertExpectations(t)
+}
+
+func TestSetStock_NegativeQuantity(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	_, err := inventoryService.SetStock(context.Background(), "prod-001", "", -1, "correction", "")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "must not be negative")
+	repo.AssertNotCalled(t, "SetStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+)
+
+// maxWatchedProducts limits how many products a single watch may follow
+const maxWatchedProducts = 100
+
+// StockUpdate is the available quantity of a product at a point in time
+type StockUpdate struct {
+	ProductID string
+	Available int
+	UpdatedAt time.Time
+}
+
+// WatchStock sends the current availability of each product and then every
+// change to it until the context is cancelled or send fails
+func (s *inventoryService) WatchStock(ctx context.Context, productIDs []string, send func(StockUpdate) error) error {
+	// Validate input
+	if len(productIDs) == 0 {
+		return invalidArgumentf("at least one product ID is required")
+	}
+	if len(productIDs) > maxWatchedProducts {
+		return invalidArgumentf("at most %d product IDs can be watched", maxWatchedProducts)
+	}
+	ids := make([]string, 0, len(productIDs))
+	seen := make(map[string]bool, len(productIDs))
+	for i, productID := range productIDs {
+		if productID == "" {
+			return invalidArgumentf("product ID is required for item %d", i)
+		}
+		if !seen[productID] {
+			seen[productID] = true
+			ids = append(ids, productID)
+		}
+	}
+
+	// Subscribe before reading the current stock so no change is missed
+	subscription := s.watchers.subscribe(ids)
+	defer s.watchers.unsubscribe(subscription)
+
+	levels, err := s.repo.GetStockLevels(ctx, ids)
+	if err != nil {
+		return repoError(err)
+	}
+	found := make(map[string]bool, len(levels))
+	for _, level := range levels {
+		found[level.ProductID] = true
+	}
+	for _, productID := range ids {
+		if !found[productID] {
+			return notFoundf("product not found: %s", productID)
+		}
+	}
+
+	// Send the current availability
+	last := make(map[string]int, len(levels))
+	for _, update := range toStockUpdates(levels) {
+		last[update.ProductID] = update.Available
+		if err := send(update); err != nil {
+			return err
+		}
+	}
+
+	// Send changes as they are published, skipping ones that leave availability unchanged
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-subscription.ready:
+		}
+
+		for _, update := range subscription.take() {
+			if available, ok := last[update.ProductID]; ok && available == update.Available {
+				continue
+			}
+			last[update.ProductID] = update.Available
+			if err := send(update); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// PublishStockChanges sends the current stock of the given products to their
+// watchers; without product IDs every watched product is refreshed. The stock
+// is read in the background so that stock changes never wait for it, and
+// changes queued while a read runs are combined into the next one.
+func (s *inventoryService) PublishStockChanges(ctx context.Context, productIDs ...string) {
+	if len(s.watchers.watched(productIDs)) == 0 {
+		return
+	}
+	if s.changes.add(productIDs) {
+		go s.drainStockChanges()
+	}
+}
+
+// drainStockChanges publishes queued stock changes until none are left. Only
+// one drain runs at a time, so a product's updates are broadcast in the order
+// they were read.
+func (s *inventoryService) drainStockChanges() {
+	ctx := context.Background()
+	for {
+		productIDs, ok := s.changes.take()
+		if !ok {
+			return
+		}
+
+		ids := s.watchers.watched(productIDs)
+		if len(ids) == 0 {
+			continue
+		}
+		levels, err := s.repo.GetStockLevels(ctx, ids)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to publish stock changes", "error", err)
+			continue
+		}
+		s.watchers.publish(toStockUpdates(levels))
+	}
+}
+
+// stockChanged publishes local stock changes unless a shared notification feed delivers them
+func (s *inventoryService) stockChanged(ctx context.Context, productIDs ...string) {
+	if s.sharedStockNotifications || len(productIDs) == 0 {
+		return
+	}
+	s.PublishStockChanges(ctx, productIDs...)
+}
+
+// toStockUpdates converts inventory rows into stock updates
+func toStockUpdates(levels []*repository.Inventory) []StockUpdate {
+	updates := make([]StockUpdate, len(levels))
+	for i, level := range levels {
+		updates[i] = StockUpdate{
+			ProductID: level.ProductID,
+			Available: level.Quantity - level.Reserved,
+			UpdatedAt: level.UpdatedAt,
+		}
+	}
+	return updates
+}
+
+// stockChanges collects the products whose stock changed until
+// drainStockChanges reads them
+type stockChanges struct {
+	mu       sync.Mutex
+	products map[string]bool
+	all      bool
+	draining bool
+}
+
+// add queues changed products, or every watched product without IDs, and
+// reports whether a drain has to be started
+func (c *stockChanges) add(productIDs []string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(productIDs) == 0 {
+		c.all = true
+	}
+	for _, productID := range productIDs {
+		if c.products == nil {
+			c.products = make(map[string]bool)
+		}
+		c.products[productID] = true
+	}
+
+	if c.draining {
+		return false
+	}
+	c.draining = true
+	return true
+}
+
+// take returns and clears the queued products, where nil means every watched
+// product. Once nothing is queued it ends the drain and reports false.
+func (c *stockChanges) take() ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.all && len(c.products) == 0 {
+		c.draining = false
+		return nil, false
+	}
+
+	var productIDs []string
+	if !c.all {
+		productIDs = make([]string, 0, len(c.products))
+		for productID := range c.products {
+			productIDs = append(productIDs, productID)
+		}
+	}
+	c.products = nil
+	c.all = false
+	return productIDs, true
+}
+
+// stockBroadcaster fans stock updates out to the watchers of each product
+type stockBroadcaster struct {
+	mu            sync.Mutex
+	subscriptions map[*stockSubscription]struct{}
+}
+
+// stockSubscription holds the latest pending update per watched product, so a
+// slow watcher skips intermediate values instead of blocking publishers
+type stockSubscription struct {
+	productIDs map[string]bool
+	ready      chan struct{}
+
+	mu      sync.Mutex
+	pending map[string]StockUpdate
+}
+
+// newStockBroadcaster creates a new stock broadcaster
+func newStockBroadcaster() *stockBroadcaster {
+	return &stockBroadcaster{subscriptions: make(map[*stockSubscription]struct{})}
+}
+
+// subscribe registers a watcher for the given products
+func (b *stockBroadcaster) subscribe(productIDs []string) *stockSubscription {
+	subscription := &stockSubscription{
+		productIDs: make(map[string]bool, len(productIDs)),
+		ready:      make(chan struct{}, 1),
+		pending:    make(map[string]StockUpdate),
+	}
+	for _, productID := range productIDs {
+		subscription.productIDs[productID] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscriptions[subscription] = struct{}{}
+	return subscription
+}
+
+// unsubscribe removes a watcher
+func (b *stockBroadcaster) unsubscribe(subscription *stockSubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscriptions, subscription)
+}
+
+// watched returns the given products that have at least one watcher; without
+// product IDs it returns every watched product
+func (b *stockBroadcaster) watched(productIDs []string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	watched := make(map[string]bool)
+	for subscription := range b.subscriptions {
+		if len(productIDs) == 0 {
+			for productID := range subscription.productIDs {
+				watched[productID] = true
+			}
+			continue
+		}
+		for _, productID := range productIDs {
+			if subscription.productIDs[productID] {
+				watched[productID] = true
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(watched))
+	for productID := range watched {
+		ids = append(ids, productID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// publish hands each update to the watchers of its product
+func (b *stockBroadcaster) publish(updates []StockUpdate) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for subscription := range b.subscriptions {
+		subscription.offer(updates)
+	}
+}
+
+// offer queues the updates for watched products and wakes the watcher
+func (s *stockSubscription) offer(updates []StockUpdate) {
+	s.mu.Lock()
+	queued := false
+	for _, update := range updates {
+		if s.productIDs[update.ProductID] {
+			s.pending[update.ProductID] = update
+			queued = true
+		}
+	}
+	s.mu.Unlock()
+
+	if queued {
+		select {
+		case s.ready <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// take returns and clears the pending updates ordered by product ID
+func (s *stockSubscription) take() []StockUpdate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	updates := make([]StockUpdate, 0, len(s.pending))
+	for _, update := range s.pending {
+		updates = append(updates, update)
+	}
+	s.pending = make(map[string]StockUpdate)
+
+	sort.Slice(updates, func(i, j int) bool {
+		return updates[i].ProductID < updates[j].ProductID
+	})
+	return updates
+}
@@ -0,0 +1,198 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+	"github.com/fardannozami/golang-microservice/inventory-service/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// watchStock runs WatchStock in the background and returns its updates and result
+func watchStock(ctx context.Context, inventoryService service.InventoryService, productIDs ...string) (<-chan service.StockUpdate, <-chan error) {
+	updates := make(chan service.StockUpdate, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- inventoryService.WatchStock(ctx, productIDs, func(update service.StockUpdate) error {
+			updates <- update
+			return nil
+		})
+	}()
+	return updates, done
+}
+
+// nextUpdate waits for the next stock update
+func nextUpdate(t *testing.T, updates <-chan service.StockUpdate) service.StockUpdate {
+	t.Helper()
+	select {
+	case update := <-updates:
+		return update
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for stock update")
+		return service.StockUpdate{}
+	}
+}
+
+func TestWatchStock_SendsCurrentStockAndChanges(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	repo.On("GetStockLevels", mock.Anything, []string{"prod-001"}).Return([]*repository.Inventory{
+		{ProductID: "prod-001", Quantity: 10, Reserved: 0},
+	}, nil).Once()
+	repo.On("GetStockLevels", mock.Anything, []string{"prod-001"}).Return([]*repository.Inventory{
+		{ProductID: "prod-001", Quantity: 10, Reserved: 3},
+	}, nil).Once()
+	repo.On("ReserveStock", mock.Anything, "prod-001", 3, "order-123", time.Time{}).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates, done := watchStock(ctx, inventoryService, "prod-001")
+
+	// Current availability comes first
+	update := nextUpdate(t, updates)
+	assert.Equal(t, "prod-001", update.ProductID)
+	assert.Equal(t, 10, update.Available)
+
+	// A reservation pushes the new availability
+	err := inventoryService.ReserveStock(context.Background(), "prod-001", 3, "order-123", 0)
+	require.NoError(t, err)
+	update = nextUpdate(t, updates)
+	assert.Equal(t, 7, update.Available)
+
+	cancel()
+	assert.NoError(t, <-done)
+	repo.AssertExpectations(t)
+}
+
+func TestWatchStock_SkipsUnchangedAvailability(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	read := make(chan struct{}, 2)
+	repo.On("GetStockLevels", mock.Anything, []string{"prod-001"}).Return([]*repository.Inventory{
+		{ProductID: "prod-001", Quantity: 10, Reserved: 2},
+	}, nil).Twice().Run(func(mock.Arguments) { read <- struct{}{} })
+	repo.On("GetStockLevels", mock.Anything, []string{"prod-001"}).Return([]*repository.Inventory{
+		{ProductID: "prod-001", Quantity: 12, Reserved: 2},
+	}, nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates, done := watchStock(ctx, inventoryService, "prod-001")
+	assert.Equal(t, 8, nextUpdate(t, updates).Available)
+	<-read
+
+	// The first publish leaves availability unchanged and is not sent
+	inventoryService.PublishStockChanges(context.Background(), "prod-001")
+	<-read
+	inventoryService.PublishStockChanges(context.Background(), "prod-001")
+	assert.Equal(t, 10, nextUpdate(t, updates).Available)
+
+	cancel()
+	assert.NoError(t, <-done)
+	repo.AssertExpectations(t)
+}
+
+func TestWatchStock_ProductNotFound(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	repo.On("GetStockLevels", mock.Anything, []string{"prod-001", "prod-404"}).Return([]*repository.Inventory{
+		{ProductID: "prod-001", Quantity: 10},
+	}, nil)
+
+	err := inventoryService.WatchStock(context.Background(), []string{"prod-001", "prod-404"}, func(service.StockUpdate) error {
+		return nil
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "product not found: prod-404", err.Error())
+}
+
+func TestWatchStock_RequiresProductIDs(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	err := inventoryService.WatchStock(context.Background(), nil, func(service.StockUpdate) error {
+		return nil
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "at least one product ID is required", err.Error())
+}
+
+func TestPublishStockChanges_SkipsUnwatchedProducts(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	repo.On("ReleaseStock", mock.Anything, "prod-001", 2, "order-123").Return(nil)
+
+	err := inventoryService.ReleaseStock(context.Background(), "prod-001", 2, "order-123")
+
+	assert.NoError(t, err)
+	repo.AssertNotCalled(t, "GetStockLevels", mock.Anything, mock.Anything)
+}
+
+func TestPublishStockChanges_DoesNotBlockStockChanges(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	// The lookup after the change blocks until the test ends
+	unblock := make(chan struct{})
+	defer close(unblock)
+	repo.On("GetStockLevels", mock.Anything, []string{"prod-001"}).Return([]*repository.Inventory{
+		{ProductID: "prod-001", Quantity: 10},
+	}, nil).Once()
+	repo.On("GetStockLevels", mock.Anything, []string{"prod-001"}).Return([]*repository.Inventory{
+		{ProductID: "prod-001", Quantity: 10, Reserved: 2},
+	}, nil).Run(func(mock.Arguments) { <-unblock })
+	repo.On("ReserveStock", mock.Anything, "prod-001", 1, mock.AnythingOfType("string"), time.Time{}).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates, _ := watchStock(ctx, inventoryService, "prod-001")
+	nextUpdate(t, updates)
+
+	// Reservations return while the watchers' lookup is still running
+	finished := make(chan error, 1)
+	go func() {
+		for _, orderID := range []string{"order-1", "order-2", "order-3"} {
+			if err := inventoryService.ReserveStock(context.Background(), "prod-001", 1, orderID, 0); err != nil {
+				finished <- err
+				return
+			}
+		}
+		finished <- nil
+	}()
+	select {
+	case err := <-finished:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("stock changes waited for the watchers' lookup")
+	}
+}
+
+func TestWatchStock_SharedNotificationsSkipLocalPublish(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo, service.WithSharedStockNotifications())
+
+	repo.On("GetStockLevels", mock.Anything, []string{"prod-001"}).Return([]*repository.Inventory{
+		{ProductID: "prod-001", Quantity: 10},
+	}, nil).Once()
+	repo.On("ReleaseStock", mock.Anything, "prod-001", 2, "order-123").Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates, done := watchStock(ctx, inventoryService, "prod-001")
+	nextUpdate(t, updates)
+
+	// The change arrives through the shared feed instead
+	err := inventoryService.ReleaseStock(context.Background(), "prod-001", 2, "order-123")
+
+	assert.NoError(t, err)
+	cancel()
+	assert.NoError(t, <-done)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+)
+
+// CreateWarehouse creates a new warehouse
+func (s *inventoryService) CreateWarehouse(ctx context.Context, warehouse *repository.Warehouse) error {
+	// Validate input
+	if warehouse.ID == "" {
+		return invalidArgumentf("warehouse ID is required")
+	}
+	if warehouse.Name == "" {
+		return invalidArgumentf("warehouse name is required")
+	}
+
+	// Create warehouse in repository
+	if err := s.repo.CreateWarehouse(ctx, warehouse); err != nil {
+		return repoError(err)
+	}
+	return nil
+}
+
+// ListWarehouses lists warehouses in allocation priority order
+func (s *inventoryService) ListWarehouses(ctx context.Context) ([]*repository.Warehouse, error) {
+	warehouses, err := s.repo.ListWarehouses(ctx)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	return warehouses, nil
+}
+
+// warehouseOrDefault returns warehouseID, or the default warehouse when it is empty
+func warehouseOrDefault(warehouseID string) string {
+	if warehouseID == "" {
+		return repository.DefaultWarehouseID
+	}
+	return warehouseID
+}
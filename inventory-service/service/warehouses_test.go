@@ -0,0 +1,62 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fardannozami/golang-microservice/inventory-service/repository"
+	"github.com/fardannozami/golang-microservice/inventory-service/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateWarehouse_Success(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	warehouse := &repository.Warehouse{ID: "jkt-1", Name: "Jakarta 1", Priority: 1}
+	repo.On("CreateWarehouse", mock.Anything, warehouse).Return(nil)
+
+	err := inventoryService.CreateWarehouse(context.Background(), warehouse)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestCreateWarehouse_MissingName(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	err := inventoryService.CreateWarehouse(context.Background(), &repository.Warehouse{ID: "jkt-1"})
+
+	assert.ErrorIs(t, err, service.ErrInvalidArgument)
+	repo.AssertNotCalled(t, "CreateWarehouse", mock.Anything, mock.Anything)
+}
+
+func TestAdjustStock_Warehouse(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	inventory := &repository.Inventory{ProductID: "prod-001", WarehouseID: "jkt-1", Quantity: 5}
+	repo.On("AdjustStock", mock.Anything, "prod-001", "jkt-1", 5, repository.ReasonRestock, "").Return(inventory, nil)
+
+	result, err := inventoryService.AdjustStock(context.Background(), "prod-001", "jkt-1", 5, "restock", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, inventory, result)
+	repo.AssertExpectations(t)
+}
+
+func TestCheckStock_Warehouse(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	inventoryService := service.NewInventoryService(repo)
+
+	repo.On("CheckStock", mock.Anything, "prod-001", "jkt-1", 2).Return(false, repository.ErrNotFound)
+
+	available, err := inventoryService.CheckStock(context.Background(), "prod-001", "jkt-1", 2)
+
+	assert.False(t, available)
+	assert.True(t, errors.Is(err, service.ErrNotFound))
+	repo.AssertExpectations(t)
+}
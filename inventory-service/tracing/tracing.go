@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// ServiceName identifies the inventory service in traces
+const ServiceName = "inventory-service"
+
+// Config selects where spans are exported and how many traces are recorded
+type Config struct {
+	// Exporter is otlp, stdout or none
+	Exporter string
+	// Endpoint is the host:port of the OTLP/HTTP collector
+	Endpoint string
+	// SampleRatio is the fraction of new traces that are recorded
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider and the W3C trace context and
+// baggage propagators, and returns a function that flushes and stops the
+// provider. With the none exporter no spans are recorded.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	// Create exporter
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx, otlptracehttp.WithEndpoint(cfg.Endpoint), otlptracehttp.WithInsecure())
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	provider := NewTracerProvider(sdktrace.NewBatchSpanProcessor(exporter), cfg.SampleRatio)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// NewTracerProvider creates a tracer provider for the inventory service that hands
+// spans to processor. It records the given ratio of new traces and follows the
+// caller's decision for traces continued from a gRPC call. Tests can pass a
+// simple span processor over tracetest.NewInMemoryExporter.
+func NewTracerProvider(processor sdktrace.SpanProcessor, sampleRatio float64) *sdktrace.TracerProvider {
+	// A schemaless resource cannot conflict with the default one
+	res, _ := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(ServiceName)))
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(res),
+	)
+}
@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthPingTimeout bounds a single database ping
+const healthPingTimeout = 2 * time.Second
+
+// Pinger is a dependency whose reachability decides the health status, e.g. *sql.DB
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// HealthReporter keeps the grpc.health.v1 status of the served services in
+// line with the database: SERVING while it answers pings, NOT_SERVING otherwise
+type HealthReporter struct {
+	db       Pinger
+	health   *health.Server
+	services []string
+	interval time.Duration
+}
+
+// NewHealthReporter creates a health reporter for services, which should
+// include "" for the server as a whole. They are NOT_SERVING until the first
+// ping succeeds.
+func NewHealthReporter(db Pinger, health *health.Server, interval time.Duration, services ...string) *HealthReporter {
+	for _, service := range services {
+		health.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	return &HealthReporter{
+		db:       db,
+		health:   health,
+		services: services,
+		interval: interval,
+	}
+}
+
+// Run pings the database every interval until the context is cancelled.
+// A non-positive interval pings it once.
+func (r *HealthReporter) Run(ctx context.Context) {
+	serving := false
+	if r.interval <= 0 {
+		r.check(ctx, &serving)
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.check(ctx, &serving)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check pings the database and updates the status when it changed
+func (r *HealthReporter) check(ctx context.Context, serving *bool) {
+	pingCtx, cancel := context.WithTimeout(ctx, healthPingTimeout)
+	defer cancel()
+	err := r.db.PingContext(pingCtx)
+	if ctx.Err() != nil || (err == nil) == *serving {
+		return
+	}
+
+	*serving = err == nil
+	status := healthpb.HealthCheckResponse_SERVING
+	if err != nil {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+		slog.WarnContext(ctx, "Database ping failed, reporting NOT_SERVING", "error", err)
+	} else {
+		slog.InfoContext(ctx, "Database reachable, reporting SERVING")
+	}
+	for _, service := range r.services {
+		r.health.SetServingStatus(service, status)
+	}
+}
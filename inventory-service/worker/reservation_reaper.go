@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/fardannozami/golang-microservice/inventory-service/service"
+)
+
+// ReservationReaper periodically releases expired reservations
+type ReservationReaper struct {
+	service  service.InventoryService
+	interval time.Duration
+}
+
+// NewReservationReaper creates a new reservation reaper
+func NewReservationReaper(service service.InventoryService, interval time.Duration) *ReservationReaper {
+	return &ReservationReaper{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Run releases expired reservations every interval until the context is cancelled.
+// A non-positive interval disables the reaper.
+func (r *ReservationReaper) Run(ctx context.Context) {
+	if r.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		// Release everything that has expired so far
+		r.reap(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reap runs a single pass over expired reservations
+func (r *ReservationReaper) reap(ctx context.Context) {
+	released, err := r.service.ReleaseExpiredReservations(ctx, time.Now())
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Reservation reaper failed", "error", err)
+		}
+		return
+	}
+	if released > 0 {
+		slog.InfoContext(ctx, "Reservation reaper released expired reservations", "reservations", released)
+	}
+}
@@ -3,7 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,9 +12,14 @@ import (
 
 	"github.com/fardannozami/golang-microservice/order-service/config"
 	"github.com/fardannozami/golang-microservice/order-service/docs"
+	"github.com/fardannozami/golang-microservice/order-service/events"
 	"github.com/fardannozami/golang-microservice/order-service/handler"
+	"github.com/fardannozami/golang-microservice/order-service/logging"
+	"github.com/fardannozami/golang-microservice/order-service/metrics"
 	"github.com/fardannozami/golang-microservice/order-service/repository"
 	"github.com/fardannozami/golang-microservice/order-service/service"
+	"github.com/fardannozami/golang-microservice/order-service/tracing"
+	"github.com/fardannozami/golang-microservice/order-service/worker"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -24,10 +29,27 @@ func main() {
 	// Load configuration
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		fatal("Failed to load configuration", err)
 	}
-	fmt.Println(cfg)
+
+	// Log structured records carrying the request and order IDs
+	logger, err := logging.New(os.Stdout, logging.Config{Level: cfg.LogLevel, Format: cfg.LogFormat})
+	if err != nil {
+		fatal("Failed to initialize logging", err)
+	}
+	slog.SetDefault(logger.With("service", tracing.ServiceName))
+	slog.Info("Loaded configuration", "config", cfg)
 	
+	// Initialize tracing before anything that records spans
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.TracingExporter,
+		Endpoint:    cfg.TracingEndpoint,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		fatal("Failed to initialize tracing", err)
+	}
+
 	// Swagger configuration
 	docs.SwaggerInfo.Host = fmt.Sprintf("localhost:%d", cfg.ServerPort)
 	docs.SwaggerInfo.BasePath = "/api/v1"
@@ -35,41 +57,102 @@ func main() {
 	// Initialize database connection
 	db, err := repository.NewPostgresConnection(cfg.DatabaseURL)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal("Failed to connect to database", err)
 	}
 	defer db.Close()
 
+	// Initialize metrics
+	appMetrics := metrics.New()
+	appMetrics.RegisterDB(db, "orders")
+
 	// Initialize repositories
-	orderRepo := repository.NewOrderRepository(db)
+	orderRepo := repository.NewOrderRepository(db, repository.WithEventEncoder(events.NewEncoder()))
+	outboxRepo := repository.NewOutboxRepository(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
 
 	// Initialize inventory client
 	inventoryClient, err := service.NewInventoryClient(cfg.InventoryServiceURL)
 	if err != nil {
-		log.Fatalf("Failed to create inventory client: %v", err)
+		fatal("Failed to create inventory client", err)
 	}
 	defer inventoryClient.Close()
 
 	// Initialize services
-	orderService := service.NewOrderService(orderRepo, inventoryClient)
+	orderService := service.NewOrderService(orderRepo, inventoryClient, service.WithPricePolicy(service.PricePolicy(cfg.PriceMismatchPolicy)), service.WithMetrics(appMetrics))
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, cfg.IdempotencyKeyTTL)
+
+	// Resume or compensate unfinished order sagas in the background
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	sagaRecovery := worker.NewSagaRecovery(orderService, cfg.SagaRecoveryInterval, cfg.SagaStaleAfter)
+	go sagaRecovery.Run(repository.WithActor(workerCtx, "saga-recovery"))
+
+	// Remove expired idempotency keys in the background
+	idempotencyCleanup := worker.NewIdempotencyCleanup(idempotencyService, cfg.IdempotencyCleanupInterval)
+	go idempotencyCleanup.Run(workerCtx)
+
+	// Publish outbox events in the background
+	var publisher events.Publisher
+	switch cfg.EventPublisher {
+	case "file":
+		filePublisher, err := events.NewFilePublisher(cfg.EventFile)
+		if err != nil {
+			fatal("Failed to create event publisher", err)
+		}
+		defer filePublisher.Close()
+		publisher = filePublisher
+	case "memory":
+		slog.Warn("Order events are only kept in memory and are lost on restart", "limit", events.MemoryEventLimit)
+		publisher = events.NewMemoryPublisher()
+	}
+	if publisher != nil {
+		outboxRelay := worker.NewOutboxRelay(events.NewRelay(outboxRepo, publisher), cfg.OutboxRelayInterval)
+		go outboxRelay.Run(workerCtx)
+	}
 
 	// Initialize handlers
 	orderHandler := handler.NewOrderHandler(orderService)
+	healthHandler := handler.NewHealthHandler(
+		handler.ReadinessCheck{Name: "postgres", Check: db.PingContext},
+		handler.ReadinessCheck{Name: "inventory", Check: inventoryClient.Ready},
+	)
 
 	// Initialize router
-	router := gin.Default()
+	router := gin.New()
 
 	// Register middleware
 	router.Use(gin.Recovery())
-	router.Use(gin.Logger())
+	router.Use(handler.Tracing())
+	router.Use(handler.RequestID())
+	router.Use(handler.Logger())
+	router.Use(handler.Metrics(appMetrics))
+	router.Use(handler.ErrorHandler())
+
+	// Register probes
+	router.GET("/healthz", healthHandler.Healthz)
+	router.GET("/readyz", healthHandler.Readyz)
+
+	// Prometheus metrics route
+	router.GET("/metrics", gin.WrapH(appMetrics.Handler()))
 
 	// Register routes
 	v1 := router.Group("/api/v1")
 	{
-		orders := v1.Group("/orders")
+		orders := v1.Group("/orders", handler.RecordActor(handler.ActorCustomer))
+		{
+			orders.POST("", handler.Idempotent(idempotencyService), orderHandler.CreateOrder)
+			orders.GET("", handler.RequireAdminToken(cfg.AdminToken), orderHandler.ListOrders)
+			orders.GET("/:id", handler.RequireOrderOwner(cfg.AdminToken, orderService), orderHandler.GetOrder)
+			orders.POST("/:id/fulfill", handler.RequireAdminToken(cfg.AdminToken), handler.RecordActor(handler.ActorAdmin), handler.IfMatch(), orderHandler.FulfillOrder)
+			orders.POST("/:id/cancel", handler.RequireOrderOwner(cfg.AdminToken, orderService), handler.IfMatch(), orderHandler.CancelOrder)
+			orders.GET("/:id/timeline", handler.RequireOrderOwner(cfg.AdminToken, orderService), orderHandler.GetOrderTimeline)
+			orders.PATCH("/:id/items", handler.RequireOrderOwner(cfg.AdminToken, orderService), handler.IfMatch(), orderHandler.UpdateOrderItems)
+			orders.PATCH("/:id/status", handler.RequireAdminToken(cfg.AdminToken), handler.RecordActor(handler.ActorAdmin), handler.IfMatch(), orderHandler.UpdateOrderStatus)
+		}
+
+		users := v1.Group("/users/:user_id", handler.RequireUser(cfg.AdminToken))
 		{
-			orders.POST("", orderHandler.CreateOrder)
-			orders.GET("", orderHandler.ListOrders)
-			orders.GET("/:id", orderHandler.GetOrder)
+			users.GET("/orders", orderHandler.ListUserOrders)
 		}
 	}
 	
@@ -84,9 +167,9 @@ func main() {
 
 	// Run server in a goroutine
 	go func() {
-		log.Printf("Starting order service on port %d", cfg.ServerPort)
+		slog.Info("Starting order service", "port", cfg.ServerPort)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+			fatal("Failed to start server", err)
 		}
 	}()
 
@@ -94,7 +177,9 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Shutting down server...")
+	slog.Info("Shutting down server")
+	healthHandler.Drain()
+	stopWorkers()
 
 	// Create a deadline for server shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -102,8 +187,19 @@ func main() {
 
 	// Attempt graceful shutdown
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		fatal("Server forced to shutdown", err)
 	}
 
-	log.Println("Server exited properly")
+	// Flush the spans of the last requests
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+
+	slog.Info("Server exited properly")
+}
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
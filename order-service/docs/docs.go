@@ -17,7 +17,12 @@ const docTemplate = `{
     "paths": {
         "/orders": {
             "get": {
-                "description": "Get a list of all orders",
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "List orders page by page, newest first unless sorted otherwise. Follow next (or pass next_page_token as page_token) with the same filters to get the following page. Requires the admin token.",
                 "consumes": [
                     "application/json"
                 ],
@@ -27,21 +32,504 @@ const docTemplate = `{
                 "tags": [
                     "orders"
                 ],
-                "summary": "List all orders",
+                "summary": "List orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only orders of this user",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only orders in this status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only orders created at or after this time (RFC 3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only orders created before this time (RFC 3339)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at_desc",
+                            "created_at_asc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Orders per page (default 20, max 100)",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token of the page to return, from next_page_token",
+                        "name": "page_token",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/handler.OrderResponse"
-                            }
+                            "$ref": "#/definitions/handler.ListOrdersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request; errors names the fields",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin endpoints are disabled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Create a new order with the provided items",
+                "description": "Create a new order with the provided items. Retries that repeat the Idempotency-Key of an earlier request return its response instead of creating another order.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Create a new order",
+                "parameters": [
+                    {
+                        "description": "Order details",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateOrderRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key that makes retries of the request return the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handler.OrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or unknown product; errors names the fields",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Idempotency key reused for a different request, or still in progress",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Insufficient stock",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Inventory service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Get an order by its ID. The ETag header carries the order's version; send it back as If-Match to change the order only if nobody changed it in between. Users may only access their own orders; admins may access anyone's.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Get an order by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authenticated user, set by the API gateway",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.OrderResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Order version, for If-Match on changes"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "No authenticated user",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Order of another user",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Release the reserved stock of a pending or reserved order and mark it cancelled. Cancelling a cancelled order returns it unchanged. Users may only change their own orders; admins may change anyone's.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Cancel an order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Cancellation reason",
+                        "name": "cancellation",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CancelOrderRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the order; the change only applies to that version",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authenticated user, set by the API gateway",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.OrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request; errors names the fields",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "No authenticated user",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Order of another user",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Order cannot be cancelled in its current status",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Order no longer has the If-Match version",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Inventory service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/fulfill": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Commit the reserved stock of a reserved or paid order and mark it fulfilled. Requires the admin token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Fulfill an order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the order; the change only applies to that version",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.OrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request; errors names the fields",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Order cannot be fulfilled in its current status, or its reservation no longer exists",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Order no longer has the If-Match version",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Inventory service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/items": {
+            "patch": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Add, remove or change lines of a reserved or paid order. Each entry sets the quantity of one product: 0 removes the line, a product the order does not have is added at its catalog price, and lines that are not mentioned stay as they are. The stock reservation moves with the lines; either all changes are applied or none are. Users may only change their own orders; admins may change anyone's.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Change the items of an order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Item changes",
+                        "name": "items",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.UpdateOrderItemsRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the order; the change only applies to that version",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authenticated user, set by the API gateway",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.OrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or unknown product; errors names the fields",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "No authenticated user",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Order of another user",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Order is not reserved or paid",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Order no longer has the If-Match version",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Insufficient stock",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Inventory service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/status": {
+            "patch": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Move an order to any status its lifecycle allows from the current one, reserving, releasing or committing stock as the transition requires. Requires the admin token.",
                 "consumes": [
                     "application/json"
                 ],
@@ -51,38 +539,85 @@ const docTemplate = `{
                 "tags": [
                     "orders"
                 ],
-                "summary": "Create a new order",
+                "summary": "Change the status of an order",
                 "parameters": [
                     {
-                        "description": "Order details",
-                        "name": "order",
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New status",
+                        "name": "status",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handler.CreateOrderRequest"
+                            "$ref": "#/definitions/handler.UpdateOrderStatusRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the order; the change only applies to that version",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/handler.OrderResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid request; errors names the fields",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Transition not allowed from the current status",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Order no longer has the If-Match version",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Inventory service unavailable",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     }
                 }
             }
         },
-        "/orders/{id}": {
+        "/orders/{id}/timeline": {
             "get": {
-                "description": "Get an order by its ID",
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "List every status change of an order, oldest first, with the reason, the actor and the inventory error that caused it if any. Users may only access their own orders; admins may access anyone's.",
                 "consumes": [
                     "application/json"
                 ],
@@ -92,7 +627,7 @@ const docTemplate = `{
                 "tags": [
                     "orders"
                 ],
-                "summary": "Get an order by ID",
+                "summary": "Get the status timeline of an order",
                 "parameters": [
                     {
                         "type": "string",
@@ -100,13 +635,141 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authenticated user, set by the API gateway",
+                        "name": "X-User-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.OrderResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handler.StatusChangeResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "No authenticated user",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Order of another user",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/orders": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "List the orders of a user page by page, newest first unless sorted otherwise, with totals over all of the user's orders. Total spent covers paid, fulfilled, shipped and delivered orders. Users may only list their own orders; admins may list anyone's.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "List the orders of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authenticated user, set by the API gateway",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only orders in this status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only orders created at or after this time (RFC 3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only orders created before this time (RFC 3339)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at_desc",
+                            "created_at_asc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Orders per page (default 20, max 100)",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token of the page to return, from next_page_token",
+                        "name": "page_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.UserOrdersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request; errors names the fields",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "No authenticated user",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Orders of another user",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     }
                 }
@@ -114,17 +777,26 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "handler.CancelOrderRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "customer changed their mind"
+                }
+            }
+        },
         "handler.CreateOrderItemRequest": {
             "type": "object",
             "required": [
-                "price",
                 "product_id",
                 "quantity"
             ],
             "properties": {
                 "price": {
                     "type": "number",
-                    "example": 10.99
+                    "example": 15000000
                 },
                 "product_id": {
                     "type": "string",
@@ -155,6 +827,23 @@ const docTemplate = `{
                 }
             }
         },
+        "handler.ListOrdersResponse": {
+            "type": "object",
+            "properties": {
+                "next": {
+                    "type": "string"
+                },
+                "next_page_token": {
+                    "type": "string"
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.OrderResponse"
+                    }
+                }
+            }
+        },
         "handler.OrderItemResponse": {
             "type": "object",
             "properties": {
@@ -175,6 +864,9 @@ const docTemplate = `{
         "handler.OrderResponse": {
             "type": "object",
             "properties": {
+                "cancel_reason": {
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
@@ -190,14 +882,218 @@ const docTemplate = `{
                 "status": {
                     "type": "string"
                 },
+                "total": {
+                    "type": "number",
+                    "example": 30
+                },
                 "updated_at": {
                     "type": "string"
                 },
                 "user_id": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "handler.Problem": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "invalid order: items[0].quantity must be positive"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.ProblemField"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/v1/orders"
+                },
+                "product_id": {
+                    "type": "string",
+                    "example": "prod-001"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "3f6c2a9e-5d1b-4c8e-9a47-0b2d6e8f1c35"
+                },
+                "requested": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Bad Request"
+                },
+                "trace_id": {
+                    "type": "string",
+                    "example": "4bf92f3577b34da6a3ce929d0e0e4736"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "about:blank"
+                }
+            }
+        },
+        "handler.ProblemField": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "items[0].quantity"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "must be positive"
+                }
+            }
+        },
+        "handler.StatusChangeResponse": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "from_status": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "to_status": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.UpdateOrderItemRequest": {
+            "type": "object",
+            "required": [
+                "product_id",
+                "quantity"
+            ],
+            "properties": {
+                "product_id": {
+                    "type": "string",
+                    "example": "prod-001"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 3
+                }
+            }
+        },
+        "handler.UpdateOrderItemsRequest": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/handler.UpdateOrderItemRequest"
+                    }
+                }
+            }
+        },
+        "handler.UpdateOrderStatusRequest": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "customer changed their mind"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "reserved",
+                        "rejected",
+                        "paid",
+                        "fulfilled",
+                        "shipped",
+                        "delivered",
+                        "cancelled",
+                        "refunded",
+                        "expired"
+                    ],
+                    "example": "shipped"
+                }
+            }
+        },
+        "handler.UserOrderSummaryResponse": {
+            "type": "object",
+            "properties": {
+                "orders_by_status": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "total_orders": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "total_spent": {
+                    "type": "number",
+                    "example": 39.3
+                }
+            }
+        },
+        "handler.UserOrdersResponse": {
+            "type": "object",
+            "properties": {
+                "next": {
+                    "type": "string"
+                },
+                "next_page_token": {
+                    "type": "string"
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.OrderResponse"
+                    }
+                },
+                "summary": {
+                    "$ref": "#/definitions/handler.UserOrderSummaryResponse"
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "AdminToken": {
+            "description": "Bearer token configured through ADMIN_TOKEN",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
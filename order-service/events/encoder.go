@@ -0,0 +1,113 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	orderpb "github.com/fardannozami/golang-microservice/order-service/proto"
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+	"github.com/fardannozami/golang-microservice/order-service/service"
+)
+
+// Event types, named after the payload of the OrderEvent envelope
+const (
+	TypeOrderCreated      = "OrderCreated"
+	TypeOrderConfirmed    = "OrderConfirmed"
+	TypeOrderRejected     = "OrderRejected"
+	TypeOrderCancelled    = "OrderCancelled"
+	TypeOrderItemsChanged = "OrderItemsChanged"
+)
+
+// Encoder encodes order changes as OrderEvent protobuf messages for the outbox
+type Encoder struct{}
+
+// NewEncoder creates a new event encoder
+func NewEncoder() *Encoder {
+	return &Encoder{}
+}
+
+// OrderCreated encodes an OrderCreated event for a new order
+func (e *Encoder) OrderCreated(order *repository.Order) (*repository.OutboxEvent, error) {
+	return encode(order.ID, TypeOrderCreated, order.CreatedAt, &orderpb.OrderEvent{
+		Payload: &orderpb.OrderEvent_Created{Created: &orderpb.OrderCreated{
+			UserId: order.UserID,
+			Items:  encodeItems(order.Items),
+		}},
+	})
+}
+
+// ItemsChanged encodes an OrderItemsChanged event carrying every line the order now has
+func (e *Encoder) ItemsChanged(order *repository.Order) (*repository.OutboxEvent, error) {
+	return encode(order.ID, TypeOrderItemsChanged, order.UpdatedAt, &orderpb.OrderEvent{
+		Payload: &orderpb.OrderEvent_ItemsChanged{ItemsChanged: &orderpb.OrderItemsChanged{
+			UserId: order.UserID,
+			Items:  encodeItems(order.Items),
+		}},
+	})
+}
+
+// StatusChanged encodes the event for an order's new status: OrderConfirmed
+// once its stock is reserved, OrderRejected and OrderCancelled. Other status
+// changes publish no event.
+func (e *Encoder) StatusChanged(order *repository.Order, change repository.StatusChange) (*repository.OutboxEvent, error) {
+	switch service.OrderStatus(order.Status) {
+	case service.OrderStatusReserved:
+		return encode(order.ID, TypeOrderConfirmed, change.CreatedAt, &orderpb.OrderEvent{
+			Payload: &orderpb.OrderEvent_Confirmed{Confirmed: &orderpb.OrderConfirmed{
+				UserId: order.UserID,
+			}},
+		})
+	case service.OrderStatusRejected:
+		return encode(order.ID, TypeOrderRejected, change.CreatedAt, &orderpb.OrderEvent{
+			Payload: &orderpb.OrderEvent_Rejected{Rejected: &orderpb.OrderRejected{
+				UserId: order.UserID,
+				Reason: change.Reason,
+				Error:  change.Error,
+			}},
+		})
+	case service.OrderStatusCancelled:
+		return encode(order.ID, TypeOrderCancelled, change.CreatedAt, &orderpb.OrderEvent{
+			Payload: &orderpb.OrderEvent_Cancelled{Cancelled: &orderpb.OrderCancelled{
+				UserId: order.UserID,
+				Reason: change.Reason,
+			}},
+		})
+	}
+	return nil, nil
+}
+
+// encodeItems converts order items to their event representation
+func encodeItems(items []repository.OrderItem) []*orderpb.OrderItem {
+	encoded := make([]*orderpb.OrderItem, len(items))
+	for i, item := range items {
+		encoded[i] = &orderpb.OrderItem{
+			ProductId: item.ProductID,
+			Quantity:  int32(item.Quantity),
+			Price:     item.Price,
+		}
+	}
+	return encoded
+}
+
+// encode fills in the envelope of an event and serializes it
+func encode(orderID, eventType string, occurredAt time.Time, event *orderpb.OrderEvent) (*repository.OutboxEvent, error) {
+	event.EventId = uuid.New().String()
+	event.OrderId = orderID
+	event.OccurredAt = timestamppb.New(occurredAt)
+
+	payload, err := proto.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository.OutboxEvent{
+		EventID:   event.EventId,
+		OrderID:   orderID,
+		EventType: eventType,
+		Payload:   payload,
+		CreatedAt: occurredAt,
+	}, nil
+}
@@ -0,0 +1,101 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/fardannozami/golang-microservice/order-service/events"
+	orderpb "github.com/fardannozami/golang-microservice/order-service/proto"
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+)
+
+func TestEncoder_OrderCreated(t *testing.T) {
+	// Create order
+	createdAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	order := &repository.Order{
+		ID:        "order123",
+		UserID:    "user123",
+		Status:    "pending",
+		CreatedAt: createdAt,
+		Items: []repository.OrderItem{
+			{ProductID: "product123", Quantity: 2, Price: 10},
+		},
+	}
+
+	// Encode event
+	event, err := events.NewEncoder().OrderCreated(order)
+	require.NoError(t, err)
+
+	// Assert envelope
+	assert.Equal(t, events.TypeOrderCreated, event.EventType)
+	assert.Equal(t, "order123", event.OrderID)
+	assert.NotEmpty(t, event.EventID)
+	assert.Equal(t, createdAt, event.CreatedAt)
+
+	// Assert payload
+	message := &orderpb.OrderEvent{}
+	require.NoError(t, proto.Unmarshal(event.Payload, message))
+	assert.Equal(t, event.EventID, message.EventId)
+	assert.Equal(t, "user123", message.GetCreated().GetUserId())
+	assert.Equal(t, "product123", message.GetCreated().GetItems()[0].GetProductId())
+	assert.Equal(t, int32(2), message.GetCreated().GetItems()[0].GetQuantity())
+}
+
+func TestEncoder_ItemsChanged(t *testing.T) {
+	// Create order
+	updatedAt := time.Date(2024, 1, 1, 12, 5, 0, 0, time.UTC)
+	order := &repository.Order{
+		ID:        "order123",
+		UserID:    "user123",
+		Status:    "reserved",
+		UpdatedAt: updatedAt,
+		Items: []repository.OrderItem{
+			{ProductID: "product123", Quantity: 3, Price: 10},
+			{ProductID: "product456", Quantity: 1, Price: 25},
+		},
+	}
+
+	// Encode event
+	event, err := events.NewEncoder().ItemsChanged(order)
+	require.NoError(t, err)
+
+	// Assert envelope
+	assert.Equal(t, events.TypeOrderItemsChanged, event.EventType)
+	assert.Equal(t, updatedAt, event.CreatedAt)
+
+	// Assert payload
+	message := &orderpb.OrderEvent{}
+	require.NoError(t, proto.Unmarshal(event.Payload, message))
+	assert.Equal(t, "user123", message.GetItemsChanged().GetUserId())
+	assert.Len(t, message.GetItemsChanged().GetItems(), 2)
+	assert.Equal(t, int32(3), message.GetItemsChanged().GetItems()[0].GetQuantity())
+}
+
+func TestEncoder_StatusChanged(t *testing.T) {
+	encoder := events.NewEncoder()
+
+	// Rejection carries the reason and inventory error
+	event, err := encoder.StatusChanged(
+		&repository.Order{ID: "order123", UserID: "user123", Status: "rejected"},
+		repository.StatusChange{FromStatus: "pending", Reason: "stock reservation failed", Error: "insufficient stock"},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, events.TypeOrderRejected, event.EventType)
+	message := &orderpb.OrderEvent{}
+	require.NoError(t, proto.Unmarshal(event.Payload, message))
+	assert.Equal(t, "insufficient stock", message.GetRejected().GetError())
+
+	// Reservation confirms the order
+	event, err = encoder.StatusChanged(&repository.Order{ID: "order123", Status: "reserved"}, repository.StatusChange{FromStatus: "pending"})
+	require.NoError(t, err)
+	assert.Equal(t, events.TypeOrderConfirmed, event.EventType)
+
+	// Other changes publish nothing
+	event, err = encoder.StatusChanged(&repository.Order{ID: "order123", Status: "shipped"}, repository.StatusChange{FromStatus: "fulfilled"})
+	require.NoError(t, err)
+	assert.Nil(t, event)
+}
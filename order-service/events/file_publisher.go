@@ -0,0 +1,79 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	orderpb "github.com/fardannozami/golang-microservice/order-service/proto"
+)
+
+// FilePublisher appends events to a file as JSON lines, for local use
+type FilePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// fileEvent is the JSON line written for an event
+type fileEvent struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	OrderID    string          `json:"order_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Event      json.RawMessage `json:"event"`
+}
+
+// NewFilePublisher creates a publisher that appends to path, creating it if needed
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+	return &FilePublisher{file: file}, nil
+}
+
+// Publish implements Publisher
+func (p *FilePublisher) Publish(ctx context.Context, event Event) error {
+	// Decode the payload so the file stays readable
+	message := &orderpb.OrderEvent{}
+	if err := proto.Unmarshal(event.Payload, message); err != nil {
+		return fmt.Errorf("failed to decode event %s: %w", event.ID, err)
+	}
+	payload, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", event.ID, err)
+	}
+
+	line, err := json.Marshal(fileEvent{
+		ID:         event.ID,
+		Type:       event.Type,
+		OrderID:    event.OrderID,
+		OccurredAt: event.OccurredAt,
+		Event:      payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", event.ID, err)
+	}
+
+	// Write and flush the line
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write event %s: %w", event.ID, err)
+	}
+	if err := p.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync event file: %w", err)
+	}
+	return nil
+}
+
+// Close closes the event file
+func (p *FilePublisher) Close() error {
+	return p.file.Close()
+}
@@ -0,0 +1,57 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Event is a domain event handed to a publisher
+type Event struct {
+	ID         string // dedupe ID; the same event may be published more than once
+	Type       string
+	OrderID    string
+	OccurredAt time.Time
+	Payload    []byte // serialized OrderEvent protobuf message
+}
+
+// Publisher delivers events to subscribers. Publish must not return nil
+// before the event has been handed over durably, since the relay then marks
+// it as published.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// MemoryEventLimit is how many events a MemoryPublisher keeps; older events
+// are dropped so that a long-running service does not grow without bound
+const MemoryEventLimit = 1000
+
+// MemoryPublisher keeps the most recent published events in memory, for tests
+// and local runs
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewMemoryPublisher creates a new in-memory publisher
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// Publish implements Publisher
+func (p *MemoryPublisher) Publish(ctx context.Context, event Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.events) == MemoryEventLimit {
+		p.events = append(p.events[:0], p.events[1:]...)
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events returns the most recent events published so far, oldest first
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Event(nil), p.events...)
+}
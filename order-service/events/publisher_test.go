@@ -0,0 +1,28 @@
+package events_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fardannozami/golang-microservice/order-service/events"
+)
+
+func TestMemoryPublisher_KeepsMostRecentEvents(t *testing.T) {
+	// Create publisher
+	publisher := events.NewMemoryPublisher()
+
+	// Publish more events than are kept
+	for i := 0; i < events.MemoryEventLimit+2; i++ {
+		require.NoError(t, publisher.Publish(context.Background(), events.Event{ID: "event-" + strconv.Itoa(i)}))
+	}
+
+	// Assert the oldest events were dropped
+	published := publisher.Events()
+	assert.Len(t, published, events.MemoryEventLimit)
+	assert.Equal(t, "event-2", published[0].ID)
+	assert.Equal(t, "event-"+strconv.Itoa(events.MemoryEventLimit+1), published[len(published)-1].ID)
+}
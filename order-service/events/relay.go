@@ -0,0 +1,64 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+)
+
+// relayBatchSize limits how many events are published per pass
+const relayBatchSize = 100
+
+// Relay publishes the events waiting in the outbox. Events are marked as
+// published only after the publisher accepted them, so every event is
+// delivered at least once and a crash in between delivers it again with the
+// same ID.
+type Relay struct {
+	outbox    repository.OutboxRepository
+	publisher Publisher
+}
+
+// NewRelay creates a new outbox relay
+func NewRelay(outbox repository.OutboxRepository, publisher Publisher) *Relay {
+	return &Relay{
+		outbox:    outbox,
+		publisher: publisher,
+	}
+}
+
+// RelayEvents publishes unpublished events in the order they were written and
+// returns how many were published. It stops at the first event that cannot be
+// published, so events are never delivered out of order.
+func (r *Relay) RelayEvents(ctx context.Context) (int, error) {
+	// Find unpublished events
+	pending, err := r.outbox.ListUnpublished(ctx, relayBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// Publish events in order
+	var published []int64
+	var publishErr error
+	for _, event := range pending {
+		err := r.publisher.Publish(ctx, Event{
+			ID:         event.EventID,
+			Type:       event.EventType,
+			OrderID:    event.OrderID,
+			OccurredAt: event.CreatedAt,
+			Payload:    event.Payload,
+		})
+		if err != nil {
+			publishErr = fmt.Errorf("failed to publish event %s: %w", event.EventID, err)
+			break
+		}
+		published = append(published, event.ID)
+	}
+
+	// Remember what was published, even if a later event failed
+	if err := r.outbox.MarkPublished(ctx, published); err != nil {
+		return 0, err
+	}
+
+	return len(published), publishErr
+}
@@ -0,0 +1,94 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/fardannozami/golang-microservice/order-service/events"
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+)
+
+// MockOutboxRepository is a mock implementation of OutboxRepository
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepository) ListUnpublished(ctx context.Context, limit int) ([]*repository.OutboxEvent, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.OutboxEvent), args.Error(1)
+}
+
+func (m *MockOutboxRepository) MarkPublished(ctx context.Context, ids []int64) error {
+	args := m.Called(ctx, ids)
+	return args.Error(0)
+}
+
+// failingPublisher accepts events until it reaches the one with failID
+type failingPublisher struct {
+	events.MemoryPublisher
+	failID string
+}
+
+func (p *failingPublisher) Publish(ctx context.Context, event events.Event) error {
+	if event.ID == p.failID {
+		return errors.New("broker unavailable")
+	}
+	return p.MemoryPublisher.Publish(ctx, event)
+}
+
+func TestRelayEvents_PublishesAndMarks(t *testing.T) {
+	// Create mock and publisher
+	outbox := new(MockOutboxRepository)
+	publisher := events.NewMemoryPublisher()
+
+	// Set up expectations
+	outbox.On("ListUnpublished", mock.Anything, mock.Anything).Return([]*repository.OutboxEvent{
+		{ID: 1, EventID: "event-1", OrderID: "order123", EventType: events.TypeOrderCreated},
+		{ID: 2, EventID: "event-2", OrderID: "order123", EventType: events.TypeOrderConfirmed},
+	}, nil)
+	outbox.On("MarkPublished", mock.Anything, []int64{1, 2}).Return(nil)
+
+	// Relay events
+	published, err := events.NewRelay(outbox, publisher).RelayEvents(context.Background())
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.Len(t, publisher.Events(), 2)
+	assert.Equal(t, "event-1", publisher.Events()[0].ID)
+
+	// Verify mocks
+	outbox.AssertExpectations(t)
+}
+
+func TestRelayEvents_StopsAtFirstFailure(t *testing.T) {
+	// Create mock and publisher
+	outbox := new(MockOutboxRepository)
+	publisher := &failingPublisher{failID: "event-2"}
+
+	// Set up expectations
+	outbox.On("ListUnpublished", mock.Anything, mock.Anything).Return([]*repository.OutboxEvent{
+		{ID: 1, EventID: "event-1"},
+		{ID: 2, EventID: "event-2"},
+		{ID: 3, EventID: "event-3"},
+	}, nil)
+	outbox.On("MarkPublished", mock.Anything, []int64{1}).Return(nil)
+
+	// Relay events
+	published, err := events.NewRelay(outbox, publisher).RelayEvents(context.Background())
+
+	// Assert expectations
+	assert.ErrorContains(t, err, "event-2")
+	assert.Equal(t, 1, published)
+	assert.Len(t, publisher.Events(), 1)
+
+	// Verify mocks
+	outbox.AssertExpectations(t)
+}
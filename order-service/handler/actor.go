@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ActorCustomer is recorded for changes made through the public order endpoints
+	ActorCustomer = "customer"
+	// ActorAdmin is recorded for changes made through privileged endpoints
+	ActorAdmin = "admin"
+)
+
+// RecordActor attaches actor to the request context so that order status
+// changes record who caused them
+func RecordActor(actor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(repository.WithActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
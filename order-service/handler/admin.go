@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdminToken only lets through requests that carry token as a bearer
+// token in the Authorization header. An empty token disables the route.
+func RequireAdminToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			abortWithProblem(c, http.StatusForbidden, "admin endpoints are disabled")
+			return
+		}
+
+		if !hasAdminToken(c, token) {
+			abortWithProblem(c, http.StatusUnauthorized, "missing or invalid admin token")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// hasAdminToken reports whether the request carries token as a bearer token;
+// an empty token never matches
+func hasAdminToken(c *gin.Context, token string) bool {
+	if token == "" {
+		return false
+	}
+	provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
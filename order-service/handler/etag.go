@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+	"github.com/fardannozami/golang-microservice/order-service/service"
+	"github.com/gin-gonic/gin"
+)
+
+// IfMatch makes order changes conditional on the versions named by the
+// request's If-Match header, a list of entity tags from the ETag header. Tags
+// are compared strongly as RFC 9110 requires, so weak tags never match; a list
+// without any tag that can match fails with 412. A missing header or "*"
+// leaves the change unconditional.
+func IfMatch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := strings.TrimSpace(c.GetHeader("If-Match"))
+		if header == "" || header == "*" {
+			c.Next()
+			return
+		}
+
+		tags, ok := parseETagList(header)
+		if !ok {
+			abortWithError(c, invalidRequest("If-Match", "must be \"*\" or a list of entity tags"))
+			return
+		}
+
+		// Collect the versions named by strong tags
+		var versions []int64
+		for _, tag := range tags {
+			if version, ok := parseETag(tag); ok {
+				versions = append(versions, version)
+			}
+		}
+		if len(versions) == 0 {
+			abortWithError(c, fmt.Errorf("%w: If-Match names no version of the order", service.ErrVersionMismatch))
+			return
+		}
+
+		c.Request = c.Request.WithContext(service.WithExpectedVersion(c.Request.Context(), versions...))
+		c.Next()
+	}
+}
+
+// setETag exposes the version of order as the response's entity tag
+func setETag(c *gin.Context, order *repository.Order) {
+	c.Header("ETag", strconv.Quote(strconv.FormatInt(order.Version, 10)))
+}
+
+// parseETagList splits a comma-separated list of entity tags such as
+// "3", W/"4". It fails if any element is not an entity tag.
+func parseETagList(header string) ([]string, bool) {
+	var tags []string
+	rest := header
+	for {
+		rest = strings.TrimLeft(rest, " \t,")
+		if rest == "" {
+			return tags, len(tags) > 0
+		}
+
+		// Read one tag, keeping its W/ prefix; opaque tags may contain commas
+		weak := strings.HasPrefix(rest, "W/")
+		opaque := strings.TrimPrefix(rest, "W/")
+		if !strings.HasPrefix(opaque, `"`) {
+			return nil, false
+		}
+		end := strings.IndexByte(opaque[1:], '"')
+		if end < 0 {
+			return nil, false
+		}
+		tag := opaque[:end+2]
+		if weak {
+			tag = "W/" + tag
+		}
+		tags = append(tags, tag)
+		rest = opaque[end+2:]
+
+		// Tags are separated by commas
+		rest = strings.TrimLeft(rest, " \t")
+		if rest != "" && rest[0] != ',' {
+			return nil, false
+		}
+	}
+}
+
+// parseETag reads the order version from a strong entity tag such as "3".
+// Weak tags and tags that were not issued by setETag name no version.
+func parseETag(tag string) (int64, bool) {
+	unquoted, ok := strings.CutPrefix(tag, `"`)
+	if !ok {
+		return 0, false
+	}
+	unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	if !ok {
+		return 0, false
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
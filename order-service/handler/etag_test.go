@@ -0,0 +1,48 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fardannozami/golang-microservice/order-service/handler"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfMatch(t *testing.T) {
+	// Create router
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/orders/:id/cancel", handler.IfMatch(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		want    int
+	}{
+		{"no header", "", http.StatusOK},
+		{"any version", "*", http.StatusOK},
+		{"strong tag", `"3"`, http.StatusOK},
+		{"list of tags", `"3", W/"4", "5"`, http.StatusOK},
+		{"opaque tag with a comma next to a version", `"a,b", "3"`, http.StatusOK},
+		{"weak tag only", `W/"3"`, http.StatusPreconditionFailed},
+		{"foreign tag only", `"xyzzy"`, http.StatusPreconditionFailed},
+		{"unquoted tag", `3`, http.StatusBadRequest},
+		{"unterminated tag", `"3`, http.StatusBadRequest},
+		{"missing comma", `"3" "4"`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/orders/order123/cancel", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
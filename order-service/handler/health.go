@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds all readiness checks of a single probe
+const readinessTimeout = 2 * time.Second
+
+// ReadinessCheck reports whether a dependency the service needs can be used
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HealthHandler serves the liveness and readiness probes
+type HealthHandler struct {
+	checks   []ReadinessCheck
+	draining atomic.Bool
+}
+
+// NewHealthHandler creates a health handler that is ready while every check passes
+func NewHealthHandler(checks ...ReadinessCheck) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// HealthResponse represents the result of a probe; Checks maps every
+// dependency to "ok" or to the reason it cannot be used
+type HealthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Drain makes the service report not ready from now on, so that it stops
+// receiving traffic while it shuts down
+func (h *HealthHandler) Drain() {
+	h.draining.Store(true)
+}
+
+// Healthz answers the liveness probe. It does not check dependencies, so an
+// outage of the database or the inventory service does not get the process
+// restarted.
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// Readyz answers the readiness probe with 200 while PostgreSQL and the
+// inventory service can be used and with 503 otherwise or while shutting down
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	if h.draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, HealthResponse{Status: "shutting down"})
+		return
+	}
+
+	// Run the checks concurrently
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+	results := make([]error, len(h.checks))
+	done := make(chan struct{})
+	for i, check := range h.checks {
+		go func() {
+			results[i] = check.Check(ctx)
+			done <- struct{}{}
+		}()
+	}
+	for range h.checks {
+		<-done
+	}
+
+	// Report every check
+	resp := HealthResponse{Status: "ready", Checks: make(map[string]string, len(h.checks))}
+	status := http.StatusOK
+	for i, check := range h.checks {
+		resp.Checks[check.Name] = "ok"
+		if results[i] != nil {
+			resp.Checks[check.Name] = results[i].Error()
+			resp.Status = "not ready"
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	c.JSON(status, resp)
+}
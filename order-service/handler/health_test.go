@@ -0,0 +1,61 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fardannozami/golang-microservice/order-service/handler"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// probe serves GET /readyz from h and returns the status and response
+func probe(t *testing.T, h *handler.HealthHandler) (int, handler.HealthResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/readyz", h.Readyz)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var resp handler.HealthResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+// check returns a readiness check named name that fails with err
+func check(name string, err error) handler.ReadinessCheck {
+	return handler.ReadinessCheck{Name: name, Check: func(ctx context.Context) error { return err }}
+}
+
+func TestReadyz_Ready(t *testing.T) {
+	status, resp := probe(t, handler.NewHealthHandler(check("postgres", nil), check("inventory", nil)))
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ready", resp.Status)
+	assert.Equal(t, map[string]string{"postgres": "ok", "inventory": "ok"}, resp.Checks)
+}
+
+func TestReadyz_FailingCheck(t *testing.T) {
+	status, resp := probe(t, handler.NewHealthHandler(check("postgres", nil), check("inventory", errors.New("connection refused"))))
+
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "not ready", resp.Status)
+	assert.Equal(t, map[string]string{"postgres": "ok", "inventory": "connection refused"}, resp.Checks)
+}
+
+func TestReadyz_Draining(t *testing.T) {
+	h := handler.NewHealthHandler(check("postgres", nil))
+	h.Drain()
+
+	status, resp := probe(t, h)
+
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "shutting down", resp.Status)
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/fardannozami/golang-microservice/order-service/service"
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader is the request header that makes retries of a request safe
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength is the longest idempotency key that is accepted
+const maxIdempotencyKeyLength = 255
+
+// responseRecorder keeps a copy of the response body written by a handler
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write implements io.Writer
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString implements io.StringWriter
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotent makes requests that carry an Idempotency-Key header safe to retry.
+// The first request with a key is processed and its response stored; repeating
+// it returns the stored response, while reusing the key for a different request
+// is rejected with 409. Server errors are not stored, so they can be retried.
+// Keys are scoped to the user named in the X-User-ID header.
+func Idempotent(idempotency service.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			abortWithError(c, invalidRequest(IdempotencyKeyHeader, "must not be longer than 255 characters"))
+			return
+		}
+
+		// Read the body, leaving it in place for the handler
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortWithError(c, bindingError(err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Claim the key, replaying the stored response of a completed request
+		request := append([]byte(c.Request.Method+" "+c.Request.URL.Path+"\n"), body...)
+		scope := c.GetHeader(UserIDHeader)
+		stored, err := idempotency.Begin(c.Request.Context(), scope, key, request)
+		switch {
+		case err != nil:
+			abortWithError(c, err)
+			return
+		case stored != nil:
+			c.Header("Idempotent-Replayed", "true")
+			if stored.ETag != "" {
+				c.Header("ETag", stored.ETag)
+			}
+			c.Data(stored.StatusCode, storedContentType(stored.StatusCode), stored.Body)
+			c.Abort()
+			return
+		}
+
+		// Store the response even if the client has gone away, since that is
+		// exactly when it is going to retry
+		ctx := context.WithoutCancel(c.Request.Context())
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		completed := false
+		defer func() {
+			if !completed {
+				if err := idempotency.Abandon(ctx, scope, key); err != nil {
+					slog.ErrorContext(ctx, "Failed to release idempotency key", "idempotency_key", key, "error", err)
+				}
+			}
+		}()
+
+		c.Next()
+
+		// Write the response for an error the handler left to ErrorHandler now,
+		// so that it is stored
+		respondError(c)
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+		response := service.IdempotentResponse{
+			StatusCode: recorder.Status(),
+			Body:       recorder.body.Bytes(),
+			ETag:       recorder.Header().Get("ETag"),
+		}
+		if err := idempotency.Complete(ctx, scope, key, response); err != nil {
+			slog.ErrorContext(ctx, "Failed to store idempotent response", "idempotency_key", key, "error", err)
+			return
+		}
+		completed = true
+	}
+}
+
+// storedContentType returns the content type of a stored response; handlers
+// answer with JSON and report errors as problem details
+func storedContentType(status int) string {
+	if status >= http.StatusBadRequest {
+		return problemContentType
+	}
+	return "application/json; charset=utf-8"
+}
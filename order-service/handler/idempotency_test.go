@@ -0,0 +1,170 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fardannozami/golang-microservice/order-service/handler"
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+	"github.com/fardannozami/golang-microservice/order-service/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryIdempotencyRepository keeps idempotency keys in memory
+type memoryIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[[2]string]*repository.IdempotencyRecord
+}
+
+func newMemoryIdempotencyRepository() *memoryIdempotencyRepository {
+	return &memoryIdempotencyRepository{records: make(map[[2]string]*repository.IdempotencyRecord)}
+}
+
+func (r *memoryIdempotencyRepository) Claim(ctx context.Context, scope, key, requestHash string, lockedUntil, expiresAt time.Time) (*repository.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if record, ok := r.records[[2]string{scope, key}]; ok {
+		if now.Before(record.ExpiresAt) && (record.Completed() || now.Before(record.LockedUntil)) {
+			claimed := *record
+			return &claimed, nil
+		}
+	}
+	r.records[[2]string{scope, key}] = &repository.IdempotencyRecord{Scope: scope, Key: key, RequestHash: requestHash, LockedUntil: lockedUntil, ExpiresAt: expiresAt}
+	return nil, nil
+}
+
+func (r *memoryIdempotencyRepository) Complete(ctx context.Context, scope, key string, statusCode int, body []byte, etag string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record := r.records[[2]string{scope, key}]
+	record.StatusCode = statusCode
+	record.ResponseBody = append([]byte(nil), body...)
+	record.ETag = etag
+	record.LockedUntil = time.Time{}
+	return nil
+}
+
+func (r *memoryIdempotencyRepository) Release(ctx context.Context, scope, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.records, [2]string{scope, key})
+	return nil
+}
+
+func (r *memoryIdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+// orderCreator counts the orders it creates and answers like CreateOrder
+type orderCreator struct {
+	created int
+	status  int
+}
+
+func (h *orderCreator) create(c *gin.Context) {
+	h.created++
+	if h.status != 0 {
+		c.Status(h.status)
+		return
+	}
+	c.Header("ETag", `"1"`)
+	c.JSON(http.StatusCreated, gin.H{"id": "order123"})
+}
+
+// newIdempotentRouter serves POST /orders through Idempotent
+func newIdempotentRouter(creator *orderCreator) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(handler.ErrorHandler())
+	idempotency := service.NewIdempotencyService(newMemoryIdempotencyRepository(), time.Hour)
+	router.POST("/orders", handler.Idempotent(idempotency), creator.create)
+	return router
+}
+
+// postOrder sends a create order request with key on behalf of userID
+func postOrder(router *gin.Engine, key, userID, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set(handler.IdempotencyKeyHeader, key)
+	req.Header.Set(handler.UserIDHeader, userID)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotent_ReplaysResponse(t *testing.T) {
+	creator := &orderCreator{}
+	router := newIdempotentRouter(creator)
+
+	first := postOrder(router, "key-1", "user123", `{"user_id":"user123"}`)
+	replay := postOrder(router, "key-1", "user123", `{"user_id":"user123"}`)
+
+	// Assert the order was created once and the response replayed with its ETag
+	assert.Equal(t, 1, creator.created)
+	assert.Equal(t, http.StatusCreated, replay.Code)
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+	assert.Equal(t, "true", replay.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, `"1"`, replay.Header().Get("ETag"))
+}
+
+func TestIdempotent_RejectsReusedKey(t *testing.T) {
+	creator := &orderCreator{}
+	router := newIdempotentRouter(creator)
+
+	postOrder(router, "key-1", "user123", `{"user_id":"user123"}`)
+	reused := postOrder(router, "key-1", "user123", `{"user_id":"user456"}`)
+
+	assert.Equal(t, 1, creator.created)
+	assert.Equal(t, http.StatusConflict, reused.Code)
+}
+
+func TestIdempotent_ScopesKeysPerUser(t *testing.T) {
+	creator := &orderCreator{}
+	router := newIdempotentRouter(creator)
+
+	postOrder(router, "key-1", "user123", `{"user_id":"user123"}`)
+	other := postOrder(router, "key-1", "user456", `{"user_id":"user123"}`)
+
+	// Assert the other user's request was processed, not replayed
+	assert.Equal(t, 2, creator.created)
+	assert.Equal(t, http.StatusCreated, other.Code)
+	assert.Empty(t, other.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotent_RetriesServerErrors(t *testing.T) {
+	creator := &orderCreator{status: http.StatusServiceUnavailable}
+	router := newIdempotentRouter(creator)
+
+	failed := postOrder(router, "key-1", "user123", `{"user_id":"user123"}`)
+	creator.status = 0
+	retried := postOrder(router, "key-1", "user123", `{"user_id":"user123"}`)
+
+	// Assert the failure was not stored, so the retry was processed
+	assert.Equal(t, http.StatusServiceUnavailable, failed.Code)
+	assert.Equal(t, http.StatusCreated, retried.Code)
+	assert.Equal(t, 2, creator.created)
+}
+
+func TestIdempotent_TakesOverCrashedRequest(t *testing.T) {
+	creator := &orderCreator{}
+	repo := newMemoryIdempotencyRepository()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(handler.ErrorHandler())
+	router.POST("/orders", handler.Idempotent(service.NewIdempotencyService(repo, time.Hour)), creator.create)
+
+	// Leave a request in progress whose lock has passed, as if it crashed
+	repo.Claim(context.Background(), "user123", "key-1", "crashed", time.Now().Add(-time.Second), time.Now().Add(time.Hour))
+
+	retried := postOrder(router, "key-1", "user123", `{"user_id":"user123"}`)
+
+	// Assert the retry took the key over rather than getting 409
+	assert.Equal(t, http.StatusCreated, retried.Code)
+	assert.Equal(t, 1, creator.created)
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logger logs every request with its route pattern, status and duration.
+// Server errors are logged at error level, client errors at warn level and
+// probes and metrics scrapes at debug level. Register it after RequestID so
+// that the records carry the request ID, and before ErrorHandler so that the
+// status of error responses is known.
+func Logger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		case probePaths[c.Request.URL.Path]:
+			level = slog.LevelDebug
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		slog.Log(c.Request.Context(), level, "Request handled",
+			"method", c.Request.Method,
+			"route", route,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"duration", time.Since(start),
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels requests that did not match any route, so that
+// arbitrary paths cannot create new metric series
+const unmatchedRoute = "unmatched"
+
+// RequestMetrics records handled HTTP requests, e.g. as Prometheus metrics
+type RequestMetrics interface {
+	ObserveRequest(method, route string, status int, duration time.Duration)
+}
+
+// Metrics records the status and duration of every request by its route
+// pattern, e.g. /api/v1/orders/:id. Register it before ErrorHandler so that
+// the status of error responses is known.
+func Metrics(metrics RequestMetrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		metrics.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
@@ -1,8 +1,13 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"net/url"
+	"time"
 
+	"github.com/fardannozami/golang-microservice/order-service/repository"
 	"github.com/fardannozami/golang-microservice/order-service/service"
 	"github.com/gin-gonic/gin"
 )
@@ -12,6 +17,10 @@ import (
 // @description API for managing orders in the microservice architecture
 // @host localhost:8080
 // @BasePath /api/v1
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+// @description Bearer token configured through ADMIN_TOKEN
 
 // OrderHandler handles HTTP requests for orders
 type OrderHandler struct {
@@ -29,21 +38,93 @@ type CreateOrderRequest struct {
 	Items  []CreateOrderItemRequest `json:"items" binding:"required,dive"`
 }
 
-// CreateOrderItemRequest represents a request to create an order item
+// CreateOrderItemRequest represents a request to create an order item.
+// Price is optional; the catalog price is always charged.
 type CreateOrderItemRequest struct {
 	ProductID string  `json:"product_id" binding:"required" example:"prod-001"`
 	Quantity  int     `json:"quantity" binding:"required,gt=0" example:"2"`
-	Price     float64 `json:"price" binding:"required,gt=0" example:"10.99"`
+	Price     float64 `json:"price,omitempty" binding:"omitempty,gt=0" example:"15000000"`
+}
+
+// UpdateOrderStatusRequest represents a request to move an order to another status
+type UpdateOrderStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=pending reserved rejected paid fulfilled shipped delivered cancelled refunded expired" example:"shipped"`
+	Reason string `json:"reason" binding:"max=500" example:"customer changed their mind"`
+}
+
+// UpdateOrderItemsRequest represents a request to change the lines of an order
+type UpdateOrderItemsRequest struct {
+	Items []UpdateOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// UpdateOrderItemRequest sets the quantity of one product on an order; 0
+// removes the line and a product the order does not have adds one
+type UpdateOrderItemRequest struct {
+	ProductID string `json:"product_id" binding:"required" example:"prod-001"`
+	Quantity  *int   `json:"quantity" binding:"required,min=0" example:"3"`
+}
+
+// CancelOrderRequest represents a request to cancel an order
+type CancelOrderRequest struct {
+	Reason string `json:"reason" binding:"max=500" example:"customer changed their mind"`
+}
+
+// ListOrdersQuery represents the query parameters of an order listing
+type ListOrdersQuery struct {
+	UserID      string    `form:"user_id"`
+	Status      string    `form:"status"`
+	CreatedFrom time.Time `form:"created_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo   time.Time `form:"created_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Sort        string    `form:"sort"`
+	PageSize    int       `form:"page_size"`
+	PageToken   string    `form:"page_token"`
+}
+
+// ListOrdersResponse represents one page of orders. Next is the URL of the
+// following page and is empty on the last page.
+type ListOrdersResponse struct {
+	Orders        []OrderResponse `json:"orders"`
+	NextPageToken string          `json:"next_page_token,omitempty"`
+	Next          string          `json:"next,omitempty"`
+}
+
+// UserOrdersResponse represents one page of a user's orders together with
+// totals over all of the user's orders
+type UserOrdersResponse struct {
+	Orders        []OrderResponse          `json:"orders"`
+	NextPageToken string                   `json:"next_page_token,omitempty"`
+	Next          string                   `json:"next,omitempty"`
+	Summary       UserOrderSummaryResponse `json:"summary"`
+}
+
+// UserOrderSummaryResponse represents the order totals of a user
+type UserOrderSummaryResponse struct {
+	TotalOrders    int            `json:"total_orders" example:"7"`
+	TotalSpent     float64        `json:"total_spent" example:"39.3"`
+	OrdersByStatus map[string]int `json:"orders_by_status"`
 }
 
 // OrderResponse represents an order response
 type OrderResponse struct {
-	ID        string              `json:"id"`
-	UserID    string              `json:"user_id"`
-	Status    string              `json:"status"`
-	Items     []OrderItemResponse `json:"items"`
-	CreatedAt string              `json:"created_at"`
-	UpdatedAt string              `json:"updated_at"`
+	ID           string              `json:"id"`
+	UserID       string              `json:"user_id"`
+	Status       string              `json:"status"`
+	CancelReason string              `json:"cancel_reason,omitempty"`
+	Items        []OrderItemResponse `json:"items"`
+	Total        float64             `json:"total" example:"30"`
+	Version      int64               `json:"version" example:"2"`
+	CreatedAt    string              `json:"created_at"`
+	UpdatedAt    string              `json:"updated_at"`
+}
+
+// StatusChangeResponse represents one entry in an order's timeline
+type StatusChangeResponse struct {
+	FromStatus string `json:"from_status,omitempty"`
+	ToStatus   string `json:"to_status"`
+	Reason     string `json:"reason,omitempty"`
+	Actor      string `json:"actor"`
+	Error      string `json:"error,omitempty"`
+	CreatedAt  string `json:"created_at"`
 }
 
 // OrderItemResponse represents an order item response
@@ -56,19 +137,23 @@ type OrderItemResponse struct {
 
 // CreateOrder godoc
 // @Summary Create a new order
-// @Description Create a new order with the provided items
+// @Description Create a new order with the provided items. Retries that repeat the Idempotency-Key of an earlier request return its response instead of creating another order.
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param order body CreateOrderRequest true "Order details"
+// @Param Idempotency-Key header string false "Key that makes retries of the request return the original response"
 // @Success 201 {object} OrderResponse
-// @Failure 400 {object} map[string]interface{}
+// @Failure 400 {object} Problem "Invalid request or unknown product; errors names the fields"
+// @Failure 409 {object} Problem "Idempotency key reused for a different request, or still in progress"
+// @Failure 422 {object} Problem "Insufficient stock"
+// @Failure 503 {object} Problem "Inventory service unavailable"
 // @Router /orders [post]
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	// Parse request
 	var req CreateOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(bindingError(err))
 		return
 	}
 
@@ -90,118 +175,446 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	// Create order
 	order, err := h.orderService.CreateOrder(c.Request.Context(), serviceReq)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	// Convert order to response
-	resp := OrderResponse{
-		ID:        order.ID,
-		UserID:    order.UserID,
-		Status:    order.Status,
-		Items:     make([]OrderItemResponse, len(order.Items)),
-		CreatedAt: order.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: order.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
-
-	// Convert order items
-	for i, item := range order.Items {
-		resp.Items[i] = OrderItemResponse{
-			ID:        item.ID,
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			Price:     item.Price,
-		}
-	}
+	resp := newOrderResponse(order)
 
+	setETag(c, order)
 	c.JSON(http.StatusCreated, resp)
 }
 
 // GetOrder godoc
 // @Summary Get an order by ID
-// @Description Get an order by its ID
+// @Description Get an order by its ID. The ETag header carries the order's version; send it back as If-Match to change the order only if nobody changed it in between. Users may only access their own orders; admins may access anyone's.
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param id path string true "Order ID"
+// @Param X-User-ID header string false "Authenticated user, set by the API gateway"
 // @Success 200 {object} OrderResponse
+// @Header 200 {string} ETag "Order version, for If-Match on changes"
+// @Failure 401 {object} Problem "No authenticated user"
+// @Failure 403 {object} Problem "Order of another user"
+// @Failure 404 {object} Problem "Order not found"
+// @Security AdminToken
 // @Router /orders/{id} [get]
 func (h *OrderHandler) GetOrder(c *gin.Context) {
 	// Get order ID from path
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "order ID is required"})
+		c.Error(invalidRequest("id", "is required"))
 		return
 	}
 
 	// Get order
 	order, err := h.orderService.GetOrder(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	// Convert order to response
-	resp := OrderResponse{
-		ID:        order.ID,
-		UserID:    order.UserID,
-		Status:    order.Status,
-		Items:     make([]OrderItemResponse, len(order.Items)),
-		CreatedAt: order.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: order.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
-
-	// Convert order items
-	for i, item := range order.Items {
-		resp.Items[i] = OrderItemResponse{
-			ID:        item.ID,
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			Price:     item.Price,
-		}
-	}
+	resp := newOrderResponse(order)
 
+	setETag(c, order)
 	c.JSON(http.StatusOK, resp)
 }
 
 // ListOrders godoc
-// @Summary List all orders
-// @Description Get a list of all orders
+// @Summary List orders
+// @Description List orders page by page, newest first unless sorted otherwise. Follow next (or pass next_page_token as page_token) with the same filters to get the following page. Requires the admin token.
 // @Tags orders
 // @Accept json
 // @Produce json
-// @Success 200 {array} OrderResponse
+// @Param user_id query string false "Only orders of this user"
+// @Param status query string false "Only orders in this status"
+// @Param created_from query string false "Only orders created at or after this time (RFC 3339)"
+// @Param created_to query string false "Only orders created before this time (RFC 3339)"
+// @Param sort query string false "Sort order" Enums(created_at_desc, created_at_asc)
+// @Param page_size query int false "Orders per page (default 20, max 100)"
+// @Param page_token query string false "Token of the page to return, from next_page_token"
+// @Success 200 {object} ListOrdersResponse
+// @Failure 400 {object} Problem "Invalid request; errors names the fields"
+// @Failure 401 {object} Problem "Missing or invalid admin token"
+// @Failure 403 {object} Problem "Admin endpoints are disabled"
+// @Security AdminToken
 // @Router /orders [get]
 func (h *OrderHandler) ListOrders(c *gin.Context) {
+	// Bind query
+	var query ListOrdersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(bindingError(err))
+		return
+	}
+
 	// List orders
-	orders, err := h.orderService.ListOrders(c.Request.Context())
+	page, err := h.orderService.ListOrders(c.Request.Context(), &service.ListOrdersRequest{
+		UserID:      query.UserID,
+		Status:      query.Status,
+		CreatedFrom: query.CreatedFrom,
+		CreatedTo:   query.CreatedTo,
+		Sort:        query.Sort,
+		PageSize:    query.PageSize,
+		PageToken:   query.PageToken,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	// Convert orders to response
-	resp := make([]OrderResponse, len(orders))
-	for i, order := range orders {
-		resp[i] = OrderResponse{
-			ID:        order.ID,
-			UserID:    order.UserID,
-			Status:    order.Status,
-			Items:     make([]OrderItemResponse, len(order.Items)),
-			CreatedAt: order.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt: order.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	resp := ListOrdersResponse{
+		Orders:        make([]OrderResponse, len(page.Orders)),
+		NextPageToken: page.NextPageToken,
+	}
+	for i, order := range page.Orders {
+		resp.Orders[i] = newOrderResponse(order)
+	}
+	if page.NextPageToken != "" {
+		resp.Next = nextPageURL(c.Request.URL, page.NextPageToken)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListUserOrders godoc
+// @Summary List the orders of a user
+// @Description List the orders of a user page by page, newest first unless sorted otherwise, with totals over all of the user's orders. Total spent covers paid, fulfilled, shipped and delivered orders. Users may only list their own orders; admins may list anyone's.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Param X-User-ID header string false "Authenticated user, set by the API gateway"
+// @Param status query string false "Only orders in this status"
+// @Param created_from query string false "Only orders created at or after this time (RFC 3339)"
+// @Param created_to query string false "Only orders created before this time (RFC 3339)"
+// @Param sort query string false "Sort order" Enums(created_at_desc, created_at_asc)
+// @Param page_size query int false "Orders per page (default 20, max 100)"
+// @Param page_token query string false "Token of the page to return, from next_page_token"
+// @Success 200 {object} UserOrdersResponse
+// @Failure 400 {object} Problem "Invalid request; errors names the fields"
+// @Failure 401 {object} Problem "No authenticated user"
+// @Failure 403 {object} Problem "Orders of another user"
+// @Security AdminToken
+// @Router /users/{user_id}/orders [get]
+func (h *OrderHandler) ListUserOrders(c *gin.Context) {
+	// Bind query
+	var query ListOrdersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(bindingError(err))
+		return
+	}
+	userID := c.Param("user_id")
+
+	// List orders
+	page, err := h.orderService.ListOrders(c.Request.Context(), &service.ListOrdersRequest{
+		UserID:      userID,
+		Status:      query.Status,
+		CreatedFrom: query.CreatedFrom,
+		CreatedTo:   query.CreatedTo,
+		Sort:        query.Sort,
+		PageSize:    query.PageSize,
+		PageToken:   query.PageToken,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Summarize orders
+	summary, err := h.orderService.SummarizeUserOrders(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Convert orders to response
+	resp := UserOrdersResponse{
+		Orders:        make([]OrderResponse, len(page.Orders)),
+		NextPageToken: page.NextPageToken,
+		Summary: UserOrderSummaryResponse{
+			TotalOrders:    summary.TotalOrders,
+			TotalSpent:     summary.TotalSpent,
+			OrdersByStatus: make(map[string]int, len(summary.OrdersByStatus)),
+		},
+	}
+	for i, order := range page.Orders {
+		resp.Orders[i] = newOrderResponse(order)
+	}
+	for status, count := range summary.OrdersByStatus {
+		resp.Summary.OrdersByStatus[string(status)] = count
+	}
+	if page.NextPageToken != "" {
+		resp.Next = nextPageURL(c.Request.URL, page.NextPageToken)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// FulfillOrder godoc
+// @Summary Fulfill an order
+// @Description Commit the reserved stock of a reserved or paid order and mark it fulfilled. Requires the admin token.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param id path string true "Order ID"
+// @Param If-Match header string false "ETag of the order; the change only applies to that version"
+// @Success 200 {object} OrderResponse
+// @Failure 400 {object} Problem "Invalid request; errors names the fields"
+// @Failure 401 {object} Problem "Missing or invalid admin token"
+// @Failure 404 {object} Problem "Order not found"
+// @Failure 409 {object} Problem "Order cannot be fulfilled in its current status, or its reservation no longer exists"
+// @Failure 412 {object} Problem "Order no longer has the If-Match version"
+// @Failure 503 {object} Problem "Inventory service unavailable"
+// @Router /orders/{id}/fulfill [post]
+func (h *OrderHandler) FulfillOrder(c *gin.Context) {
+	// Get order ID from path
+	id := c.Param("id")
+	if id == "" {
+		c.Error(invalidRequest("id", "is required"))
+		return
+	}
+
+	// Fulfill order
+	order, err := h.orderService.FulfillOrder(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setETag(c, order)
+	c.JSON(http.StatusOK, newOrderResponse(order))
+}
+
+// CancelOrder godoc
+// @Summary Cancel an order
+// @Description Release the reserved stock of a pending or reserved order and mark it cancelled. Cancelling a cancelled order returns it unchanged. Users may only change their own orders; admins may change anyone's.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param cancellation body CancelOrderRequest false "Cancellation reason"
+// @Param If-Match header string false "ETag of the order; the change only applies to that version"
+// @Param X-User-ID header string false "Authenticated user, set by the API gateway"
+// @Success 200 {object} OrderResponse
+// @Failure 400 {object} Problem "Invalid request; errors names the fields"
+// @Failure 401 {object} Problem "No authenticated user"
+// @Failure 403 {object} Problem "Order of another user"
+// @Failure 404 {object} Problem "Order not found"
+// @Failure 409 {object} Problem "Order cannot be cancelled in its current status"
+// @Failure 412 {object} Problem "Order no longer has the If-Match version"
+// @Failure 503 {object} Problem "Inventory service unavailable"
+// @Security AdminToken
+// @Router /orders/{id}/cancel [post]
+func (h *OrderHandler) CancelOrder(c *gin.Context) {
+	// Get order ID from path
+	id := c.Param("id")
+	if id == "" {
+		c.Error(invalidRequest("id", "is required"))
+		return
+	}
+
+	// Parse request; the body is optional
+	var req CancelOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.Error(bindingError(err))
+		return
+	}
+
+	// Cancel order
+	order, err := h.orderService.CancelOrder(c.Request.Context(), id, req.Reason)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setETag(c, order)
+	c.JSON(http.StatusOK, newOrderResponse(order))
+}
+
+// UpdateOrderStatus godoc
+// @Summary Change the status of an order
+// @Description Move an order to any status its lifecycle allows from the current one, reserving, releasing or committing stock as the transition requires. Requires the admin token.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param id path string true "Order ID"
+// @Param status body UpdateOrderStatusRequest true "New status"
+// @Param If-Match header string false "ETag of the order; the change only applies to that version"
+// @Success 200 {object} OrderResponse
+// @Failure 400 {object} Problem "Invalid request; errors names the fields"
+// @Failure 401 {object} Problem "Missing or invalid admin token"
+// @Failure 404 {object} Problem "Order not found"
+// @Failure 409 {object} Problem "Transition not allowed from the current status"
+// @Failure 412 {object} Problem "Order no longer has the If-Match version"
+// @Failure 503 {object} Problem "Inventory service unavailable"
+// @Router /orders/{id}/status [patch]
+func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
+	// Get order ID from path
+	id := c.Param("id")
+	if id == "" {
+		c.Error(invalidRequest("id", "is required"))
+		return
+	}
+
+	// Parse request
+	var req UpdateOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindingError(err))
+		return
+	}
+
+	// Change status
+	order, err := h.orderService.UpdateOrderStatus(c.Request.Context(), id, req.Status, req.Reason)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setETag(c, order)
+	c.JSON(http.StatusOK, newOrderResponse(order))
+}
+
+// UpdateOrderItems godoc
+// @Summary Change the items of an order
+// @Description Add, remove or change lines of a reserved or paid order. Each entry sets the quantity of one product: 0 removes the line, a product the order does not have is added at its catalog price, and lines that are not mentioned stay as they are. The stock reservation moves with the lines; either all changes are applied or none are. Users may only change their own orders; admins may change anyone's.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param items body UpdateOrderItemsRequest true "Item changes"
+// @Param If-Match header string false "ETag of the order; the change only applies to that version"
+// @Param X-User-ID header string false "Authenticated user, set by the API gateway"
+// @Success 200 {object} OrderResponse
+// @Failure 400 {object} Problem "Invalid request or unknown product; errors names the fields"
+// @Failure 401 {object} Problem "No authenticated user"
+// @Failure 403 {object} Problem "Order of another user"
+// @Failure 404 {object} Problem "Order not found"
+// @Failure 409 {object} Problem "Order is not reserved or paid"
+// @Failure 412 {object} Problem "Order no longer has the If-Match version"
+// @Failure 422 {object} Problem "Insufficient stock"
+// @Failure 503 {object} Problem "Inventory service unavailable"
+// @Security AdminToken
+// @Router /orders/{id}/items [patch]
+func (h *OrderHandler) UpdateOrderItems(c *gin.Context) {
+	// Get order ID from path
+	id := c.Param("id")
+	if id == "" {
+		c.Error(invalidRequest("id", "is required"))
+		return
+	}
+
+	// Parse request
+	var req UpdateOrderItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindingError(err))
+		return
+	}
+
+	// Convert request to service request
+	serviceReq := &service.UpdateOrderItemsRequest{
+		Items: make([]service.OrderItemChange, len(req.Items)),
+	}
+	for i, item := range req.Items {
+		serviceReq.Items[i] = service.OrderItemChange{
+			ProductID: item.ProductID,
+			Quantity:  *item.Quantity,
 		}
+	}
+
+	// Change items
+	order, err := h.orderService.UpdateOrderItems(c.Request.Context(), id, serviceReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setETag(c, order)
+	c.JSON(http.StatusOK, newOrderResponse(order))
+}
+
+// GetOrderTimeline godoc
+// @Summary Get the status timeline of an order
+// @Description List every status change of an order, oldest first, with the reason, the actor and the inventory error that caused it if any. Users may only access their own orders; admins may access anyone's.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param X-User-ID header string false "Authenticated user, set by the API gateway"
+// @Success 200 {array} StatusChangeResponse
+// @Failure 401 {object} Problem "No authenticated user"
+// @Failure 403 {object} Problem "Order of another user"
+// @Failure 404 {object} Problem "Order not found"
+// @Security AdminToken
+// @Router /orders/{id}/timeline [get]
+func (h *OrderHandler) GetOrderTimeline(c *gin.Context) {
+	// Get order ID from path
+	id := c.Param("id")
+	if id == "" {
+		c.Error(invalidRequest("id", "is required"))
+		return
+	}
+
+	// Get timeline
+	history, err := h.orderService.GetOrderTimeline(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
-		// Convert order items
-		for j, item := range order.Items {
-			resp[i].Items[j] = OrderItemResponse{
-				ID:        item.ID,
-				ProductID: item.ProductID,
-				Quantity:  item.Quantity,
-				Price:     item.Price,
-			}
+	// Convert history to response
+	resp := make([]StatusChangeResponse, len(history))
+	for i, change := range history {
+		resp[i] = StatusChangeResponse{
+			FromStatus: change.FromStatus,
+			ToStatus:   change.ToStatus,
+			Reason:     change.Reason,
+			Actor:      change.Actor,
+			Error:      change.Error,
+			CreatedAt:  change.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		}
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
+
+// newOrderResponse converts an order into its response representation
+func newOrderResponse(order *repository.Order) OrderResponse {
+	resp := OrderResponse{
+		ID:           order.ID,
+		UserID:       order.UserID,
+		Status:       order.Status,
+		CancelReason: order.CancelReason,
+		Items:        make([]OrderItemResponse, len(order.Items)),
+		Total:        order.Total(),
+		Version:      order.Version,
+		CreatedAt:    order.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:    order.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+
+	// Convert order items
+	for i, item := range order.Items {
+		resp.Items[i] = OrderItemResponse{
+			ID:        item.ID,
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
+		}
+	}
+
+	return resp
+}
+
+// nextPageURL returns the request URL with its page token replaced, keeping
+// the filters the token was issued for
+func nextPageURL(current *url.URL, pageToken string) string {
+	query := current.Query()
+	query.Set("page_token", pageToken)
+	next := url.URL{Path: current.Path, RawQuery: query.Encode()}
+	return next.String()
+}
@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/fardannozami/golang-microservice/order-service/service"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// problemContentType is the media type of problem details responses
+const problemContentType = "application/problem+json"
+
+// errInvalidRequest is returned for a request that cannot be bound
+var errInvalidRequest = errors.New("invalid request")
+
+// Problem is an RFC 7807 problem details response. TraceID is set when the
+// request is traced. Errors lists the invalid fields of a rejected request;
+// insufficient stock also reports the product and the quantities involved.
+type Problem struct {
+	Type      string         `json:"type" example:"about:blank"`
+	Title     string         `json:"title" example:"Bad Request"`
+	Status    int            `json:"status" example:"400"`
+	Detail    string         `json:"detail,omitempty" example:"invalid order: items[0].quantity must be positive"`
+	Instance  string         `json:"instance,omitempty" example:"/api/v1/orders"`
+	RequestID string         `json:"request_id" example:"3f6c2a9e-5d1b-4c8e-9a47-0b2d6e8f1c35"`
+	TraceID   string         `json:"trace_id,omitempty" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+	Errors    []ProblemField `json:"errors,omitempty"`
+	ProductID string         `json:"product_id,omitempty" example:"prod-001"`
+	Available *int           `json:"available,omitempty" example:"3"`
+	Requested *int           `json:"requested,omitempty" example:"10"`
+}
+
+// ProblemField describes why one field of a request is invalid
+type ProblemField struct {
+	Field   string `json:"field" example:"items[0].quantity"`
+	Message string `json:"message" example:"must be positive"`
+}
+
+func init() {
+	// Name fields in validation errors the way clients send them
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(requestFieldName)
+	}
+}
+
+// ErrorHandler turns the last error a handler attached with c.Error into a
+// problem details response, unless the handler has responded already
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		respondError(c)
+	}
+}
+
+// respondError writes the problem details of the last error attached to the
+// request, if there is one and no response has been written
+func respondError(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+	writeProblem(c, newProblem(c, c.Errors.Last().Err))
+}
+
+// abortWithError stops the request with the problem details of err
+func abortWithError(c *gin.Context, err error) {
+	c.Abort()
+	writeProblem(c, newProblem(c, err))
+}
+
+// abortWithProblem stops the request with a problem of status explained by detail
+func abortWithProblem(c *gin.Context, status int, detail string) {
+	c.Abort()
+	problem := baseProblem(c, status)
+	problem.Detail = detail
+	writeProblem(c, problem)
+}
+
+// writeProblem writes problem as the response
+func writeProblem(c *gin.Context, problem Problem) {
+	c.Header("Content-Type", problemContentType)
+	c.JSON(problem.Status, problem)
+}
+
+// baseProblem returns a problem of status for the current request
+func baseProblem(c *gin.Context, status int) Problem {
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Instance:  c.Request.URL.Path,
+		RequestID: requestID(c),
+	}
+	if span := trace.SpanContextFromContext(c.Request.Context()); span.IsValid() {
+		problem.TraceID = span.TraceID().String()
+	}
+	return problem
+}
+
+// newProblem describes err as a problem. The details of unexpected errors and
+// of failures of the services and database behind this one are logged rather
+// than sent to the client.
+func newProblem(c *gin.Context, err error) Problem {
+	problem := baseProblem(c, errorStatus(err))
+	switch problem.Status {
+	case http.StatusInternalServerError:
+		slog.ErrorContext(c.Request.Context(), "Request failed", "method", c.Request.Method, "path", c.Request.URL.Path, "error", err)
+		problem.Detail = "internal error; quote the request ID when reporting it"
+		return problem
+	case http.StatusServiceUnavailable:
+		slog.WarnContext(c.Request.Context(), "Request failed", "method", c.Request.Method, "path", c.Request.URL.Path, "error", err)
+		problem.Detail = "a service this request depends on is unavailable; retry later"
+		return problem
+	}
+	problem.Detail = err.Error()
+
+	// Name the invalid fields
+	var invalid *service.ValidationError
+	if errors.As(err, &invalid) {
+		for _, field := range invalid.Fields {
+			problem.Errors = append(problem.Errors, ProblemField{Field: field.Field, Message: field.Message})
+		}
+	}
+
+	// Report the stock that was missing
+	var insufficient *service.InsufficientStockError
+	if errors.As(err, &insufficient) {
+		problem.ProductID = insufficient.ProductID
+		problem.Available = &insufficient.Available
+		problem.Requested = &insufficient.Requested
+	}
+
+	return problem
+}
+
+// errorStatus returns the HTTP status for the kind of err
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, service.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInsufficientStock):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// invalidRequest returns a validation error for one field of a request
+func invalidRequest(field, message string) error {
+	return &service.ValidationError{
+		Err:    errInvalidRequest,
+		Fields: []service.FieldError{{Field: field, Message: message}},
+	}
+}
+
+// bindingError turns a request that could not be bound into a validation
+// error, naming the invalid fields where they are known
+func bindingError(err error) error {
+	var invalid validator.ValidationErrors
+	if errors.As(err, &invalid) {
+		fields := make([]service.FieldError, len(invalid))
+		for i, field := range invalid {
+			fields[i] = service.FieldError{Field: fieldPath(field), Message: fieldMessage(field)}
+		}
+		return &service.ValidationError{Err: errInvalidRequest, Fields: fields}
+	}
+
+	var mistyped *json.UnmarshalTypeError
+	if errors.As(err, &mistyped) && mistyped.Field != "" {
+		return invalidRequest(jsonFieldPath(mistyped.Field), "must be "+jsonType(mistyped.Type))
+	}
+
+	return &service.ValidationError{Err: fmt.Errorf("%w: %v", errInvalidRequest, err)}
+}
+
+// requestFieldName names a struct field by its JSON or form tag
+func requestFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// fieldPath returns the path of an invalid field without the name of the
+// request struct, e.g. items[0].quantity
+func fieldPath(field validator.FieldError) string {
+	_, path, ok := strings.Cut(field.Namespace(), ".")
+	if !ok {
+		return field.Field()
+	}
+	return path
+}
+
+// jsonFieldPath writes the dotted path of a JSON decoding error, e.g.
+// items.0.quantity, the way validation errors name fields
+func jsonFieldPath(path string) string {
+	var b strings.Builder
+	for i, part := range strings.Split(path, ".") {
+		if _, err := strconv.Atoi(part); err == nil {
+			b.WriteString("[" + part + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// fieldMessage explains the validation rule an invalid field breaks
+func fieldMessage(field validator.FieldError) string {
+	switch field.Tag() {
+	case "required":
+		return "is required"
+	case "gt":
+		return "must be greater than " + field.Param()
+	case "min":
+		if field.Kind() == reflect.Slice {
+			return "must contain at least " + field.Param() + " item(s)"
+		}
+		return "must be at least " + field.Param()
+	case "max":
+		if field.Kind() == reflect.String {
+			return "must be at most " + field.Param() + " characters long"
+		}
+		return "must be at most " + field.Param()
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(field.Param(), " ", ", ")
+	}
+	return "must satisfy " + field.Tag()
+}
+
+// jsonType names the JSON type a Go type is decoded from
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return "of type " + t.String()
+}
@@ -0,0 +1,131 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fardannozami/golang-microservice/order-service/handler"
+	"github.com/fardannozami/golang-microservice/order-service/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// failWith serves GET /fail through ErrorHandler, failing with err
+func failWith(err error) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(handler.RequestID())
+	router.Use(handler.ErrorHandler())
+	router.GET("/fail", func(c *gin.Context) {
+		c.Error(err)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set(handler.RequestIDHeader, "req-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// decodeProblem decodes the problem details of a response
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) handler.Problem {
+	t.Helper()
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	var problem handler.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	return problem
+}
+
+func TestErrorHandler_MapsErrorKinds(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"validation", &service.ValidationError{Err: errors.New("invalid order")}, http.StatusBadRequest},
+		{"not found", fmt.Errorf("%w: order order123", service.ErrNotFound), http.StatusNotFound},
+		{"conflict", service.ErrInvalidOrderStatus, http.StatusConflict},
+		{"version mismatch", fmt.Errorf("%w: %w", service.ErrVersionMismatch, service.ErrConflict), http.StatusPreconditionFailed},
+		{"insufficient stock", &service.InsufficientStockError{ProductID: "prod-001"}, http.StatusUnprocessableEntity},
+		{"unavailable", fmt.Errorf("%w: inventory", service.ErrUnavailable), http.StatusServiceUnavailable},
+		{"unexpected", errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := failWith(tt.err)
+
+			assert.Equal(t, tt.want, w.Code)
+			problem := decodeProblem(t, w)
+			assert.Equal(t, tt.want, problem.Status)
+			assert.Equal(t, http.StatusText(tt.want), problem.Title)
+			assert.Equal(t, "/fail", problem.Instance)
+			assert.Equal(t, "req-123", problem.RequestID)
+		})
+	}
+}
+
+func TestErrorHandler_HidesUnexpectedErrors(t *testing.T) {
+	w := failWith(errors.New("pq: password authentication failed"))
+
+	problem := decodeProblem(t, w)
+	assert.NotContains(t, problem.Detail, "password")
+	assert.Contains(t, problem.Detail, "request ID")
+}
+
+func TestErrorHandler_ReportsFieldsAndStock(t *testing.T) {
+	// Validation errors name the invalid fields
+	w := failWith(&service.ValidationError{
+		Err:    errors.New("invalid order"),
+		Fields: []service.FieldError{{Field: "items[0].quantity", Message: "must be positive"}},
+	})
+	problem := decodeProblem(t, w)
+	assert.Equal(t, []handler.ProblemField{{Field: "items[0].quantity", Message: "must be positive"}}, problem.Errors)
+
+	// Insufficient stock reports the quantities
+	w = failWith(fmt.Errorf("failed to reserve inventory: %w", &service.InsufficientStockError{ProductID: "prod-001", Available: 1, Requested: 2}))
+	problem = decodeProblem(t, w)
+	assert.Equal(t, "prod-001", problem.ProductID)
+	require.NotNil(t, problem.Available)
+	require.NotNil(t, problem.Requested)
+	assert.Equal(t, 1, *problem.Available)
+	assert.Equal(t, 2, *problem.Requested)
+}
+
+func TestErrorHandler_HidesUpstreamErrors(t *testing.T) {
+	w := failWith(fmt.Errorf("%w: rpc error: code = Unavailable desc = connection refused to 10.0.3.7:50051", service.ErrUnavailable))
+
+	problem := decodeProblem(t, w)
+	assert.Equal(t, http.StatusServiceUnavailable, problem.Status)
+	assert.NotContains(t, problem.Detail, "10.0.3.7")
+	assert.Contains(t, problem.Detail, "retry")
+}
+
+func TestErrorHandler_ReportsTraceID(t *testing.T) {
+	// Untraced requests have no trace ID
+	problem := decodeProblem(t, failWith(errors.New("boom")))
+	assert.Empty(t, problem.TraceID)
+
+	// Traced requests report the trace they belong to
+	traceID := trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	span := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{1}, TraceFlags: trace.FlagsSampled})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(trace.ContextWithSpanContext(c.Request.Context(), span))
+	})
+	router.Use(handler.ErrorHandler())
+	router.GET("/fail", func(c *gin.Context) {
+		c.Error(errors.New("boom"))
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	problem = decodeProblem(t, w)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", problem.TraceID)
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"github.com/fardannozami/golang-microservice/order-service/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID that ties a request to its log lines and to
+// the request_id of its error responses
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the longest request ID taken over from a caller
+const maxRequestIDLength = 128
+
+// requestIDKey is the gin context key of the request ID
+const requestIDKey = "request_id"
+
+// RequestID gives every request an ID, reusing the one sent by the caller,
+// e.g. the API gateway, and returns it in the X-Request-ID response header.
+// The ID is stored in the request context, so that it is logged with every
+// record logged with that context and passed on to the inventory service.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = uuid.New().String()
+		}
+
+		c.Set(requestIDKey, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// requestID returns the ID given to the request by RequestID
+func requestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
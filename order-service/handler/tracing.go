@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/fardannozami/golang-microservice/order-service/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// probePaths are polled by infrastructure and would only add noise to traces
+// and logs
+var probePaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// Tracing starts a server span for every request, continuing the trace of the
+// caller if the request carries a W3C traceparent header. The span is named
+// after the route pattern, e.g. GET /api/v1/orders/:id. Probes and metrics
+// scrapes are not traced.
+func Tracing() gin.HandlerFunc {
+	return otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(func(r *http.Request) bool {
+		return !probePaths[r.URL.Path]
+	}))
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/fardannozami/golang-microservice/order-service/service"
+	"github.com/gin-gonic/gin"
+)
+
+// UserIDHeader carries the ID of the authenticated user. The API gateway sets
+// it after authenticating the caller and strips it from client requests.
+const UserIDHeader = "X-User-ID"
+
+// RequireUser only lets through requests made by the user named in the
+// user_id path parameter, or by an admin carrying adminToken
+func RequireUser(adminToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if hasAdminToken(c, adminToken) {
+			c.Next()
+			return
+		}
+
+		userID := c.GetHeader(UserIDHeader)
+		if userID == "" {
+			abortWithProblem(c, http.StatusUnauthorized, "missing "+UserIDHeader+" header")
+			return
+		}
+		if userID != c.Param("user_id") {
+			abortWithProblem(c, http.StatusForbidden, "orders of other users are not accessible")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireOrderOwner only lets through requests made by the user who placed
+// the order named in the id path parameter, or by an admin carrying adminToken
+func RequireOrderOwner(adminToken string, orders service.OrderService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if hasAdminToken(c, adminToken) {
+			c.Next()
+			return
+		}
+
+		userID := c.GetHeader(UserIDHeader)
+		if userID == "" {
+			abortWithProblem(c, http.StatusUnauthorized, "missing "+UserIDHeader+" header")
+			return
+		}
+		order, err := orders.GetOrder(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if userID != order.UserID {
+			abortWithProblem(c, http.StatusForbidden, "orders of other users are not accessible")
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,140 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fardannozami/golang-microservice/order-service/handler"
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+	"github.com/fardannozami/golang-microservice/order-service/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubOrderService serves GetOrder from a fixed set of orders
+type stubOrderService struct {
+	service.OrderService
+	orders map[string]*repository.Order
+}
+
+func (s stubOrderService) GetOrder(ctx context.Context, id string) (*repository.Order, error) {
+	order, ok := s.orders[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: order %s", service.ErrNotFound, id)
+	}
+	return order, nil
+}
+
+// serve sends a GET request with headers to router and returns the response
+func serve(router *gin.Engine, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequireUser(t *testing.T) {
+	// Create router
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/users/:user_id/orders", handler.RequireUser("admin-token"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"own orders", map[string]string{handler.UserIDHeader: "user123"}, http.StatusOK},
+		{"other user's orders", map[string]string{handler.UserIDHeader: "user456"}, http.StatusForbidden},
+		{"no user", nil, http.StatusUnauthorized},
+		{"admin", map[string]string{"Authorization": "Bearer admin-token"}, http.StatusOK},
+		{"wrong admin token", map[string]string{"Authorization": "Bearer guess"}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, "/users/user123/orders", tt.headers)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestRequireOrderOwner(t *testing.T) {
+	// Create router
+	gin.SetMode(gin.TestMode)
+	orders := stubOrderService{orders: map[string]*repository.Order{
+		"order123": {ID: "order123", UserID: "user123"},
+	}}
+	router := gin.New()
+	router.GET("/orders/:id", handler.RequireOrderOwner("admin-token", orders), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		want    int
+	}{
+		{"own order", "/orders/order123", map[string]string{handler.UserIDHeader: "user123"}, http.StatusOK},
+		{"other user's order", "/orders/order123", map[string]string{handler.UserIDHeader: "user456"}, http.StatusForbidden},
+		{"no user", "/orders/order123", nil, http.StatusUnauthorized},
+		{"unknown order", "/orders/order999", map[string]string{handler.UserIDHeader: "user123"}, http.StatusNotFound},
+		{"admin", "/orders/order123", map[string]string{"Authorization": "Bearer admin-token"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, tt.path, tt.headers)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestRequireOrderOwner_GuardsChanges(t *testing.T) {
+	// Create router with the order change routes
+	gin.SetMode(gin.TestMode)
+	orders := stubOrderService{orders: map[string]*repository.Order{
+		"order123": {ID: "order123", UserID: "user123"},
+	}}
+	changed := 0
+	change := func(c *gin.Context) {
+		changed++
+		c.Status(http.StatusOK)
+	}
+	router := gin.New()
+	router.POST("/orders/:id/cancel", handler.RequireOrderOwner("admin-token", orders), handler.IfMatch(), change)
+	router.PATCH("/orders/:id/items", handler.RequireOrderOwner("admin-token", orders), handler.IfMatch(), change)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		userID string
+		want   int
+	}{
+		{"cancel another user's order", http.MethodPost, "/orders/order123/cancel", "user456", http.StatusForbidden},
+		{"cancel an unknown order", http.MethodPost, "/orders/order999/cancel", "user456", http.StatusNotFound},
+		{"change another user's items", http.MethodPatch, "/orders/order123/items", "user456", http.StatusForbidden},
+		{"change the items of an unknown order", http.MethodPatch, "/orders/order999/items", "user456", http.StatusNotFound},
+		{"cancel own order", http.MethodPost, "/orders/order123/cancel", "user123", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(handler.UserIDHeader, tt.userID)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+
+	// Assert only the owner's request got through
+	assert.Equal(t, 1, changed)
+}
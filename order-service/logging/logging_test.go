@@ -0,0 +1,123 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	inventorylogging "github.com/fardannozami/golang-microservice/inventory-service/logging"
+	pb "github.com/fardannozami/golang-microservice/inventory-service/proto"
+	"github.com/fardannozami/golang-microservice/inventory-service/server"
+	"github.com/fardannozami/golang-microservice/order-service/handler"
+	"github.com/fardannozami/golang-microservice/order-service/logging"
+	"github.com/fardannozami/golang-microservice/order-service/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+func TestNew_AddsCorrelationIDs(t *testing.T) {
+	// Create logger
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.Config{Level: "info", Format: "json"})
+	require.NoError(t, err)
+
+	// Log with a request ID and a span in the context
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := logging.WithRequestID(context.Background(), "req-123")
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+	logger.With("service", "order-service").InfoContext(ctx, "Order status changed", "order_id", "order-1")
+
+	// Assert the record carries the IDs
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "Order status changed", record["msg"])
+	assert.Equal(t, "order-service", record["service"])
+	assert.Equal(t, "order-1", record["order_id"])
+	assert.Equal(t, "req-123", record["request_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", record["span_id"])
+}
+
+func TestNew_FiltersByLevel(t *testing.T) {
+	// Create logger
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.Config{Level: "warn", Format: "text"})
+	require.NoError(t, err)
+
+	// Log below and at the level
+	logger.Info("Reserving stock")
+	logger.Warn("Request handled", "status", 404)
+
+	// Assert only the warning was written
+	assert.NotContains(t, buf.String(), "Reserving stock")
+	assert.Contains(t, buf.String(), `level=WARN msg="Request handled" status=404`)
+}
+
+func TestNew_ValidatesConfig(t *testing.T) {
+	for _, level := range []string{"debug", "info", "warn", "error"} {
+		_, err := logging.New(&bytes.Buffer{}, logging.Config{Level: level, Format: "json"})
+		assert.NoError(t, err, level)
+	}
+
+	_, err := logging.New(&bytes.Buffer{}, logging.Config{Level: "verbose", Format: "json"})
+	assert.Error(t, err)
+	_, err = logging.New(&bytes.Buffer{}, logging.Config{Level: "info", Format: "xml"})
+	assert.Error(t, err)
+}
+
+// productServer records the request ID of the BatchGetProducts call it serves
+type productServer struct {
+	pb.UnimplementedInventoryServiceServer
+	requestID chan string
+}
+
+func (s productServer) BatchGetProducts(ctx context.Context, req *pb.BatchGetProductsRequest) (*pb.BatchGetProductsResponse, error) {
+	s.requestID <- inventorylogging.RequestID(ctx)
+	return &pb.BatchGetProductsResponse{Success: true}, nil
+}
+
+func TestRequestID_PropagatesToInventory(t *testing.T) {
+	// Start inventory server
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	products := productServer{requestID: make(chan string, 1)}
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(server.UnaryRequestIDInterceptor))
+	pb.RegisterInventoryServiceServer(grpcServer, products)
+	go func() { _ = grpcServer.Serve(lis) }()
+	defer grpcServer.Stop()
+
+	// Create inventory client
+	inventoryClient, err := service.NewInventoryClient(lis.Addr().String())
+	require.NoError(t, err)
+	defer inventoryClient.Close()
+
+	// Create router
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(handler.RequestID())
+	router.GET("/prices/:id", func(c *gin.Context) {
+		if _, err := inventoryClient.GetPrices(c.Request.Context(), []string{c.Param("id")}); err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	// Send a request with the caller's ID
+	req := httptest.NewRequest(http.MethodGet, "/prices/prod-001", nil)
+	req.Header.Set(handler.RequestIDHeader, "req-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert the inventory server saw the same ID
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "req-123", <-products.requestID)
+}
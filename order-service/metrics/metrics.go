@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds the Prometheus metrics of the order service in a registry of
+// its own, together with the Go runtime and process metrics
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests        *prometheus.CounterVec
+	httpRequestDuration *prometheus.HistogramVec
+	ordersCreated       prometheus.Counter
+	ordersConfirmed     prometheus.Counter
+	ordersRejected      prometheus.Counter
+	reservationFailures *prometheus.CounterVec
+}
+
+// New creates and registers the order service metrics
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests handled, by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time taken to handle HTTP requests, by method and route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		ordersCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "orders_created_total",
+			Help: "Orders stored as pending.",
+		}),
+		ordersConfirmed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "orders_confirmed_total",
+			Help: "Orders whose stock was reserved.",
+		}),
+		ordersRejected: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "orders_rejected_total",
+			Help: "Orders rejected because their stock could not be reserved.",
+		}),
+		reservationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "order_reservation_failures_total",
+			Help: "Failed inventory reservation calls, by reason.",
+		}, []string{"reason"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpRequestDuration,
+		m.ordersCreated,
+		m.ordersConfirmed,
+		m.ordersRejected,
+		m.reservationFailures,
+	)
+	return m
+}
+
+// RegisterDB exposes the connection pool statistics of db, labelled with name
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveRequest records a handled HTTP request
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpRequestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// OrderCreated counts an order stored as pending
+func (m *Metrics) OrderCreated() {
+	m.ordersCreated.Inc()
+}
+
+// OrderConfirmed counts an order whose stock was reserved
+func (m *Metrics) OrderConfirmed() {
+	m.ordersConfirmed.Inc()
+}
+
+// OrderRejected counts an order rejected for lack of stock
+func (m *Metrics) OrderRejected() {
+	m.ordersRejected.Inc()
+}
+
+// ReservationFailed counts a failed reservation call
+func (m *Metrics) ReservationFailed(reason string) {
+	m.reservationFailures.WithLabelValues(reason).Inc()
+}
@@ -0,0 +1,615 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v6.32.0
+// source: proto/order_events.proto
+
+package orderpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Envelope of every event published by the order service
+type OrderEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Unique per event; an event can be delivered more than once, so consumers
+	// should drop events whose ID they have already seen
+	EventId    string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	OrderId    string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	OccurredAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*OrderEvent_Created
+	//	*OrderEvent_Confirmed
+	//	*OrderEvent_Rejected
+	//	*OrderEvent_Cancelled
+	//	*OrderEvent_ItemsChanged
+	Payload       isOrderEvent_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderEvent) Reset() {
+	*x = OrderEvent{}
+	mi := &file_proto_order_events_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderEvent) ProtoMessage() {}
+
+func (x *OrderEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_events_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderEvent.ProtoReflect.Descriptor instead.
+func (*OrderEvent) Descriptor() ([]byte, []int) {
+	return file_proto_order_events_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *OrderEvent) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *OrderEvent) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *OrderEvent) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+func (x *OrderEvent) GetPayload() isOrderEvent_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *OrderEvent) GetCreated() *OrderCreated {
+	if x != nil {
+		if x, ok := x.Payload.(*OrderEvent_Created); ok {
+			return x.Created
+		}
+	}
+	return nil
+}
+
+func (x *OrderEvent) GetConfirmed() *OrderConfirmed {
+	if x != nil {
+		if x, ok := x.Payload.(*OrderEvent_Confirmed); ok {
+			return x.Confirmed
+		}
+	}
+	return nil
+}
+
+func (x *OrderEvent) GetRejected() *OrderRejected {
+	if x != nil {
+		if x, ok := x.Payload.(*OrderEvent_Rejected); ok {
+			return x.Rejected
+		}
+	}
+	return nil
+}
+
+func (x *OrderEvent) GetCancelled() *OrderCancelled {
+	if x != nil {
+		if x, ok := x.Payload.(*OrderEvent_Cancelled); ok {
+			return x.Cancelled
+		}
+	}
+	return nil
+}
+
+func (x *OrderEvent) GetItemsChanged() *OrderItemsChanged {
+	if x != nil {
+		if x, ok := x.Payload.(*OrderEvent_ItemsChanged); ok {
+			return x.ItemsChanged
+		}
+	}
+	return nil
+}
+
+type isOrderEvent_Payload interface {
+	isOrderEvent_Payload()
+}
+
+type OrderEvent_Created struct {
+	Created *OrderCreated `protobuf:"bytes,10,opt,name=created,proto3,oneof"`
+}
+
+type OrderEvent_Confirmed struct {
+	Confirmed *OrderConfirmed `protobuf:"bytes,11,opt,name=confirmed,proto3,oneof"`
+}
+
+type OrderEvent_Rejected struct {
+	Rejected *OrderRejected `protobuf:"bytes,12,opt,name=rejected,proto3,oneof"`
+}
+
+type OrderEvent_Cancelled struct {
+	Cancelled *OrderCancelled `protobuf:"bytes,13,opt,name=cancelled,proto3,oneof"`
+}
+
+type OrderEvent_ItemsChanged struct {
+	ItemsChanged *OrderItemsChanged `protobuf:"bytes,14,opt,name=items_changed,json=itemsChanged,proto3,oneof"`
+}
+
+func (*OrderEvent_Created) isOrderEvent_Payload() {}
+
+func (*OrderEvent_Confirmed) isOrderEvent_Payload() {}
+
+func (*OrderEvent_Rejected) isOrderEvent_Payload() {}
+
+func (*OrderEvent_Cancelled) isOrderEvent_Payload() {}
+
+func (*OrderEvent_ItemsChanged) isOrderEvent_Payload() {}
+
+type OrderItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Quantity      int32                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Price         float64                `protobuf:"fixed64,3,opt,name=price,proto3" json:"price,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderItem) Reset() {
+	*x = OrderItem{}
+	mi := &file_proto_order_events_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderItem) ProtoMessage() {}
+
+func (x *OrderItem) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_events_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderItem.ProtoReflect.Descriptor instead.
+func (*OrderItem) Descriptor() ([]byte, []int) {
+	return file_proto_order_events_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *OrderItem) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *OrderItem) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *OrderItem) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+// An order was placed; its stock is not reserved yet
+type OrderCreated struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Items         []*OrderItem           `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderCreated) Reset() {
+	*x = OrderCreated{}
+	mi := &file_proto_order_events_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderCreated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderCreated) ProtoMessage() {}
+
+func (x *OrderCreated) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_events_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderCreated.ProtoReflect.Descriptor instead.
+func (*OrderCreated) Descriptor() ([]byte, []int) {
+	return file_proto_order_events_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *OrderCreated) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *OrderCreated) GetItems() []*OrderItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// The stock of an order was reserved
+type OrderConfirmed struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderConfirmed) Reset() {
+	*x = OrderConfirmed{}
+	mi := &file_proto_order_events_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderConfirmed) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderConfirmed) ProtoMessage() {}
+
+func (x *OrderConfirmed) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_events_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderConfirmed.ProtoReflect.Descriptor instead.
+func (*OrderConfirmed) Descriptor() ([]byte, []int) {
+	return file_proto_order_events_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *OrderConfirmed) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+// An order failed because its stock could not be reserved
+type OrderRejected struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Reason string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Inventory error that caused the rejection
+	Error         string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderRejected) Reset() {
+	*x = OrderRejected{}
+	mi := &file_proto_order_events_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderRejected) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderRejected) ProtoMessage() {}
+
+func (x *OrderRejected) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_events_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderRejected.ProtoReflect.Descriptor instead.
+func (*OrderRejected) Descriptor() ([]byte, []int) {
+	return file_proto_order_events_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *OrderRejected) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *OrderRejected) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *OrderRejected) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// An order was cancelled and its reserved stock released
+type OrderCancelled struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderCancelled) Reset() {
+	*x = OrderCancelled{}
+	mi := &file_proto_order_events_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderCancelled) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderCancelled) ProtoMessage() {}
+
+func (x *OrderCancelled) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_events_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderCancelled.ProtoReflect.Descriptor instead.
+func (*OrderCancelled) Descriptor() ([]byte, []int) {
+	return file_proto_order_events_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *OrderCancelled) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *OrderCancelled) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// The lines of an order were changed; items lists every line the order now has
+type OrderItemsChanged struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Items         []*OrderItem           `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderItemsChanged) Reset() {
+	*x = OrderItemsChanged{}
+	mi := &file_proto_order_events_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderItemsChanged) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderItemsChanged) ProtoMessage() {}
+
+func (x *OrderItemsChanged) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_events_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderItemsChanged.ProtoReflect.Descriptor instead.
+func (*OrderItemsChanged) Descriptor() ([]byte, []int) {
+	return file_proto_order_events_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *OrderItemsChanged) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *OrderItemsChanged) GetItems() []*OrderItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+var File_proto_order_events_proto protoreflect.FileDescriptor
+
+const file_proto_order_events_proto_rawDesc = "" +
+	"\n" +
+	"\x18proto/order_events.proto\x12\vorderevents\x1a\x1fgoogle/protobuf/timestamp.proto\"\xbc\x03\n" +
+	"\n" +
+	"OrderEvent\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x19\n" +
+	"\border_id\x18\x02 \x01(\tR\aorderId\x12;\n" +
+	"\voccurred_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\x125\n" +
+	"\acreated\x18\n" +
+	" \x01(\v2\x19.orderevents.OrderCreatedH\x00R\acreated\x12;\n" +
+	"\tconfirmed\x18\v \x01(\v2\x1b.orderevents.OrderConfirmedH\x00R\tconfirmed\x128\n" +
+	"\brejected\x18\f \x01(\v2\x1a.orderevents.OrderRejectedH\x00R\brejected\x12;\n" +
+	"\tcancelled\x18\r \x01(\v2\x1b.orderevents.OrderCancelledH\x00R\tcancelled\x12E\n" +
+	"\ritems_changed\x18\x0e \x01(\v2\x1e.orderevents.OrderItemsChangedH\x00R\fitemsChangedB\t\n" +
+	"\apayload\"\\\n" +
+	"\tOrderItem\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1a\n" +
+	"\bquantity\x18\x02 \x01(\x05R\bquantity\x12\x14\n" +
+	"\x05price\x18\x03 \x01(\x01R\x05price\"U\n" +
+	"\fOrderCreated\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12,\n" +
+	"\x05items\x18\x02 \x03(\v2\x16.orderevents.OrderItemR\x05items\")\n" +
+	"\x0eOrderConfirmed\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"V\n" +
+	"\rOrderRejected\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"A\n" +
+	"\x0eOrderCancelled\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"Z\n" +
+	"\x11OrderItemsChanged\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12,\n" +
+	"\x05items\x18\x02 \x03(\v2\x16.orderevents.OrderItemR\x05itemsB\x1eZ\x1c/order-service/proto;orderpbb\x06proto3"
+
+var (
+	file_proto_order_events_proto_rawDescOnce sync.Once
+	file_proto_order_events_proto_rawDescData []byte
+)
+
+func file_proto_order_events_proto_rawDescGZIP() []byte {
+	file_proto_order_events_proto_rawDescOnce.Do(func() {
+		file_proto_order_events_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_order_events_proto_rawDesc), len(file_proto_order_events_proto_rawDesc)))
+	})
+	return file_proto_order_events_proto_rawDescData
+}
+
+var file_proto_order_events_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_proto_order_events_proto_goTypes = []any{
+	(*OrderEvent)(nil),            // 0: orderevents.OrderEvent
+	(*OrderItem)(nil),             // 1: orderevents.OrderItem
+	(*OrderCreated)(nil),          // 2: orderevents.OrderCreated
+	(*OrderConfirmed)(nil),        // 3: orderevents.OrderConfirmed
+	(*OrderRejected)(nil),         // 4: orderevents.OrderRejected
+	(*OrderCancelled)(nil),        // 5: orderevents.OrderCancelled
+	(*OrderItemsChanged)(nil),     // 6: orderevents.OrderItemsChanged
+	(*timestamppb.Timestamp)(nil), // 7: google.protobuf.Timestamp
+}
+var file_proto_order_events_proto_depIdxs = []int32{
+	7, // 0: orderevents.OrderEvent.occurred_at:type_name -> google.protobuf.Timestamp
+	2, // 1: orderevents.OrderEvent.created:type_name -> orderevents.OrderCreated
+	3, // 2: orderevents.OrderEvent.confirmed:type_name -> orderevents.OrderConfirmed
+	4, // 3: orderevents.OrderEvent.rejected:type_name -> orderevents.OrderRejected
+	5, // 4: orderevents.OrderEvent.cancelled:type_name -> orderevents.OrderCancelled
+	6, // 5: orderevents.OrderEvent.items_changed:type_name -> orderevents.OrderItemsChanged
+	1, // 6: orderevents.OrderCreated.items:type_name -> orderevents.OrderItem
+	1, // 7: orderevents.OrderItemsChanged.items:type_name -> orderevents.OrderItem
+	8, // [8:8] is the sub-list for method output_type
+	8, // [8:8] is the sub-list for method input_type
+	8, // [8:8] is the sub-list for extension type_name
+	8, // [8:8] is the sub-list for extension extendee
+	0, // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_proto_order_events_proto_init() }
+func file_proto_order_events_proto_init() {
+	if File_proto_order_events_proto != nil {
+		return
+	}
+	file_proto_order_events_proto_msgTypes[0].OneofWrappers = []any{
+		(*OrderEvent_Created)(nil),
+		(*OrderEvent_Confirmed)(nil),
+		(*OrderEvent_Rejected)(nil),
+		(*OrderEvent_Cancelled)(nil),
+		(*OrderEvent_ItemsChanged)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_order_events_proto_rawDesc), len(file_proto_order_events_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_order_events_proto_goTypes,
+		DependencyIndexes: file_proto_order_events_proto_depIdxs,
+		MessageInfos:      file_proto_order_events_proto_msgTypes,
+	}.Build()
+	File_proto_order_events_proto = out.File
+	file_proto_order_events_proto_goTypes = nil
+	file_proto_order_events_proto_depIdxs = nil
+}
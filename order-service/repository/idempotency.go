@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// maxClaimAttempts limits how often Claim retries a key that was released
+// between claiming and reading it
+const maxClaimAttempts = 3
+
+// IdempotencyRecord represents a request made with an idempotency key and,
+// once it has completed, the response that was sent for it. Keys are scoped,
+// e.g. to the user making the request, so the same key can be used by
+// different scopes.
+type IdempotencyRecord struct {
+	Scope        string
+	Key          string
+	RequestHash  string
+	StatusCode   int // zero while the request is still in progress
+	ResponseBody []byte
+	ETag         string
+	CreatedAt    time.Time
+	LockedUntil  time.Time // zero once the request has completed
+	ExpiresAt    time.Time
+}
+
+// Completed reports whether the response to the request has been stored
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
+
+// IdempotencyRepository defines the interface for idempotency key operations
+type IdempotencyRepository interface {
+	Claim(ctx context.Context, scope, key, requestHash string, lockedUntil, expiresAt time.Time) (*IdempotencyRecord, error)
+	Complete(ctx context.Context, scope, key string, statusCode int, body []byte, etag string) error
+	Release(ctx context.Context, scope, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// idempotencyRepository implements IdempotencyRepository interface
+type idempotencyRepository struct {
+	db *sql.DB
+}
+
+// NewIdempotencyRepository creates a new idempotency key repository
+func NewIdempotencyRepository(db *sql.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Claim records key in scope for a new request with the given hash, locking
+// it for that request until lockedUntil. It returns nil if the key was free,
+// or the existing record if the key is already in use. An expired key, and
+// one whose request never completed and whose lock has passed, e.g. because
+// the instance handling it crashed, is taken over as if it were free.
+func (r *idempotencyRepository) Claim(ctx context.Context, scope, key, requestHash string, lockedUntil, expiresAt time.Time) (*IdempotencyRecord, error) {
+	for attempt := 1; ; attempt++ {
+		record, err := r.claim(ctx, scope, key, requestHash, lockedUntil, expiresAt)
+		if err != sql.ErrNoRows {
+			return record, err
+		}
+
+		// The key was released between claiming and reading it, so it is
+		// free again
+		if attempt == maxClaimAttempts {
+			return nil, fmt.Errorf("failed to claim idempotency key: released %d times while claiming", attempt)
+		}
+	}
+}
+
+// claim makes a single attempt at claiming key. It returns sql.ErrNoRows if
+// the key was in use but disappeared before its record could be read.
+func (r *idempotencyRepository) claim(ctx context.Context, scope, key, requestHash string, lockedUntil, expiresAt time.Time) (*IdempotencyRecord, error) {
+	now := time.Now()
+
+	// Claim the key unless it is in use, has not expired and is either
+	// completed or still locked by its request
+	result, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO idempotency_keys (scope, key, request_hash, created_at, locked_until, expires_at) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (scope, key) DO UPDATE SET request_hash = EXCLUDED.request_hash, status_code = NULL, response_body = NULL, etag = NULL, created_at = EXCLUDED.created_at, locked_until = EXCLUDED.locked_until, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+		   OR (idempotency_keys.status_code IS NULL AND idempotency_keys.locked_until <= EXCLUDED.created_at)`,
+		scope, key, requestHash, now, lockedUntil, expiresAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert idempotency key: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if affected == 1 {
+		return nil, nil
+	}
+
+	// Load the existing record
+	record := &IdempotencyRecord{}
+	var statusCode sql.NullInt64
+	var etag sql.NullString
+	var locked sql.NullTime
+	err = r.db.QueryRowContext(
+		ctx,
+		"SELECT scope, key, request_hash, status_code, response_body, etag, created_at, locked_until, expires_at FROM idempotency_keys WHERE scope = $1 AND key = $2",
+		scope, key,
+	).Scan(&record.Scope, &record.Key, &record.RequestHash, &statusCode, &record.ResponseBody, &etag, &record.CreatedAt, &locked, &record.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan idempotency key: %w", err)
+	}
+	record.StatusCode = int(statusCode.Int64)
+	record.ETag = etag.String
+	record.LockedUntil = locked.Time
+
+	return record, nil
+}
+
+// Complete stores the response sent for the request made with key in scope
+func (r *idempotencyRepository) Complete(ctx context.Context, scope, key string, statusCode int, body []byte, etag string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE idempotency_keys SET status_code = $1, response_body = $2, etag = $3, locked_until = NULL WHERE scope = $4 AND key = $5",
+		statusCode, body, sql.NullString{String: etag, Valid: etag != ""}, scope, key,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Release forgets key in scope so that the request can be retried with it
+func (r *idempotencyRepository) Release(ctx context.Context, scope, key string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2", scope, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes the keys that expired by now and returns how many
+// were removed
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= $1", now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	return deleted, nil
+}
@@ -3,20 +3,58 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// ErrOrderNotFound is returned when no order has the requested ID
+var ErrOrderNotFound = errors.New("order not found")
+
+// ErrOrderConflict is returned when an order was changed by another request since it was read
+var ErrOrderConflict = errors.New("order changed concurrently")
+
+// VersionConflictError reports the version a write expected an order to still
+// have. It matches ErrOrderConflict.
+type VersionConflictError struct {
+	OrderID string
+	Version int64
+}
+
+// Error implements error
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("order %s changed concurrently: version %d is stale", e.OrderID, e.Version)
+}
+
+// Is reports whether target is ErrOrderConflict
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrOrderConflict
+}
+
 // Order represents an order entity
 type Order struct {
-	ID        string
-	UserID    string
-	Status    string
-	Items     []OrderItem
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID           string
+	UserID       string
+	Status       string
+	CancelReason string // set when the order is cancelled
+	Items        []OrderItem
+	Version      int64 // incremented on every write
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Total returns what the items of the order add up to
+func (o *Order) Total() float64 {
+	total := 0.0
+	for _, item := range o.Items {
+		total += float64(item.Quantity) * item.Price
+	}
+	return math.Round(total*100) / 100
 }
 
 // OrderItem represents an order item entity
@@ -30,24 +68,48 @@ type OrderItem struct {
 
 // OrderRepository defines the interface for order repository operations
 type OrderRepository interface {
-	Create(ctx context.Context, order *Order) error
+	Create(ctx context.Context, order *Order, saga *Saga) error
 	GetByID(ctx context.Context, id string) (*Order, error)
-	List(ctx context.Context) ([]*Order, error)
-	Update(ctx context.Context, order *Order) error
+	List(ctx context.Context, filter OrderFilter) ([]*Order, error)
+	SummarizeByUser(ctx context.Context, userID string) ([]StatusTotal, error)
+	Claim(ctx context.Context, order *Order, until time.Time) error
+	ReleaseClaim(ctx context.Context, order *Order) error
+	UpdateStatus(ctx context.Context, order *Order, change StatusChange) error
+	ReplaceItems(ctx context.Context, order *Order) error
+	ListStatusHistory(ctx context.Context, orderID string) ([]StatusChange, error)
+	UpdateSaga(ctx context.Context, saga *Saga) error
+	ListUnfinishedSagas(ctx context.Context, updatedBefore time.Time, limit int) ([]*Saga, error)
 }
 
 // orderRepository implements OrderRepository interface
 type orderRepository struct {
-	db *sql.DB
+	db     *sql.DB
+	events EventEncoder
 }
 
-// NewOrderRepository creates a new order repository
-func NewOrderRepository(db *sql.DB) OrderRepository {
-	return &orderRepository{db: db}
+// Option configures an order repository
+type Option func(*orderRepository)
+
+// WithEventEncoder writes the events encoded for every order change to the
+// outbox, in the same transaction as the change
+func WithEventEncoder(encoder EventEncoder) Option {
+	return func(r *orderRepository) {
+		r.events = encoder
+	}
 }
 
-// Create creates a new order
-func (r *orderRepository) Create(ctx context.Context, order *Order) error {
+// NewOrderRepository creates a new order repository. Without an event encoder
+// no events are written to the outbox.
+func NewOrderRepository(db *sql.DB, opts ...Option) OrderRepository {
+	r := &orderRepository{db: db}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Create creates a new order and starts its creation saga in the same transaction
+func (r *orderRepository) Create(ctx context.Context, order *Order, saga *Saga) error {
 	// Start a transaction
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -64,35 +126,44 @@ func (r *orderRepository) Create(ctx context.Context, order *Order) error {
 	now := time.Now()
 	order.CreatedAt = now
 	order.UpdatedAt = now
+	order.Version = 1
 
 	// Insert order
 	_, err = tx.ExecContext(
 		ctx,
-		"INSERT INTO orders (id, user_id, status, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)",
-		order.ID, order.UserID, order.Status, order.CreatedAt, order.UpdatedAt,
+		"INSERT INTO orders (id, user_id, status, version, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		order.ID, order.UserID, order.Status, order.Version, order.CreatedAt, order.UpdatedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert order: %w", err)
 	}
 
 	// Insert order items
-	for i := range order.Items {
-		// Generate a new UUID for the item if not provided
-		if order.Items[i].ID == "" {
-			order.Items[i].ID = uuid.New().String()
-		}
+	if err := insertItems(ctx, tx, order); err != nil {
+		return err
+	}
 
-		// Set order ID
-		order.Items[i].OrderID = order.ID
+	// Record the initial status
+	if err := recordStatusChange(ctx, tx, order, StatusChange{CreatedAt: now}); err != nil {
+		return err
+	}
 
-		// Insert order item
-		_, err = tx.ExecContext(
-			ctx,
-			"INSERT INTO order_items (id, order_id, product_id, quantity, price) VALUES ($1, $2, $3, $4, $5)",
-			order.Items[i].ID, order.Items[i].OrderID, order.Items[i].ProductID, order.Items[i].Quantity, order.Items[i].Price,
-		)
+	// Start the saga
+	saga.OrderID = order.ID
+	saga.CreatedAt = now
+	saga.UpdatedAt = now
+	if err := insertSaga(ctx, tx, saga); err != nil {
+		return err
+	}
+
+	// Publish the new order through the outbox
+	if r.events != nil {
+		event, err := r.events.OrderCreated(order)
 		if err != nil {
-			return fmt.Errorf("failed to insert order item: %w", err)
+			return fmt.Errorf("failed to encode order event: %w", err)
+		}
+		if err := insertOutboxEvent(ctx, tx, event); err != nil {
+			return err
 		}
 	}
 
@@ -106,22 +177,32 @@ func (r *orderRepository) Create(ctx context.Context, order *Order) error {
 
 // GetByID gets an order by ID
 func (r *orderRepository) GetByID(ctx context.Context, id string) (*Order, error) {
+	// Order IDs are UUIDs, so no order has any other ID; PostgreSQL would
+	// reject it as invalid input instead of finding nothing
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, id)
+	}
+	id = parsed.String()
+
 	// Query order
 	row := r.db.QueryRowContext(
 		ctx,
-		"SELECT id, user_id, status, created_at, updated_at FROM orders WHERE id = $1",
+		"SELECT id, user_id, status, cancel_reason, version, created_at, updated_at FROM orders WHERE id = $1",
 		id,
 	)
 
 	// Scan order
 	order := &Order{}
-	err := row.Scan(&order.ID, &order.UserID, &order.Status, &order.CreatedAt, &order.UpdatedAt)
+	var cancelReason sql.NullString
+	err = row.Scan(&order.ID, &order.UserID, &order.Status, &cancelReason, &order.Version, &order.CreatedAt, &order.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("order not found: %s", id)
+			return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to scan order: %w", err)
 	}
+	order.CancelReason = cancelReason.String
 
 	// Query order items
 	rows, err := r.db.QueryContext(
@@ -147,12 +228,75 @@ func (r *orderRepository) GetByID(ctx context.Context, id string) (*Order, error
 	return order, nil
 }
 
-// List lists all orders
-func (r *orderRepository) List(ctx context.Context) ([]*Order, error) {
+// OrderSort is the order in which orders are listed
+type OrderSort string
+
+const (
+	// SortNewestFirst lists the most recently created orders first
+	SortNewestFirst OrderSort = "created_at_desc"
+	// SortOldestFirst lists the least recently created orders first
+	SortOldestFirst OrderSort = "created_at_asc"
+)
+
+// OrderCursor is the position of the last order of a page; the next page
+// continues after it
+type OrderCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// OrderFilter narrows, sorts and pages an order listing
+type OrderFilter struct {
+	UserID      string
+	Status      string
+	CreatedFrom time.Time // inclusive; zero means unbounded
+	CreatedTo   time.Time // exclusive; zero means unbounded
+	Sort        OrderSort
+	After       *OrderCursor // nil starts at the first order
+	Limit       int
+}
+
+// List lists the orders matching filter together with their items
+func (r *orderRepository) List(ctx context.Context, filter OrderFilter) ([]*Order, error) {
+	// Build filter conditions
+	conditions := []string{"TRUE"}
+	args := []interface{}{}
+	if filter.UserID != "" {
+		args = append(args, filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if !filter.CreatedFrom.IsZero() {
+		args = append(args, filter.CreatedFrom)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if !filter.CreatedTo.IsZero() {
+		args = append(args, filter.CreatedTo)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+
+	// Continue after the cursor; ID breaks ties between equal timestamps
+	direction, comparison := "DESC", "<"
+	if filter.Sort == SortOldestFirst {
+		direction, comparison = "ASC", ">"
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.CreatedAt, filter.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) %s ($%d, $%d)", comparison, len(args)-1, len(args)))
+	}
+	args = append(args, filter.Limit)
+
 	// Query orders
 	rows, err := r.db.QueryContext(
 		ctx,
-		"SELECT id, user_id, status, created_at, updated_at FROM orders ORDER BY created_at DESC",
+		fmt.Sprintf(
+			"SELECT id, user_id, status, cancel_reason, version, created_at, updated_at FROM orders WHERE %s ORDER BY created_at %s, id %s LIMIT $%d",
+			strings.Join(conditions, " AND "), direction, direction, len(args),
+		),
+		args...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query orders: %w", err)
@@ -163,42 +307,74 @@ func (r *orderRepository) List(ctx context.Context) ([]*Order, error) {
 	orders := []*Order{}
 	for rows.Next() {
 		order := &Order{}
-		err := rows.Scan(&order.ID, &order.UserID, &order.Status, &order.CreatedAt, &order.UpdatedAt)
+		var cancelReason sql.NullString
+		err := rows.Scan(&order.ID, &order.UserID, &order.Status, &cancelReason, &order.Version, &order.CreatedAt, &order.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan order: %w", err)
 		}
+		order.CancelReason = cancelReason.String
 		orders = append(orders, order)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read orders: %w", err)
+	}
 
-	// Query order items for each order
-	for _, order := range orders {
-		// Query order items
-		rows, err := r.db.QueryContext(
-			ctx,
-			"SELECT id, order_id, product_id, quantity, price FROM order_items WHERE order_id = $1",
-			order.ID,
-		)
+	// Load the items of all orders at once
+	if err := r.loadItems(ctx, orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// loadItems fills in the items of orders with a single query
+func (r *orderRepository) loadItems(ctx context.Context, orders []*Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	// Index orders by ID
+	ids := make([]string, len(orders))
+	byID := make(map[string]*Order, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID
+		byID[order.ID] = order
+	}
+
+	// Query order items
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT id, order_id, product_id, quantity, price FROM order_items WHERE order_id = ANY($1::uuid[])",
+		pq.Array(ids),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query order items: %w", err)
+	}
+	defer rows.Close()
+
+	// Scan order items
+	for rows.Next() {
+		item := OrderItem{}
+		err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.Quantity, &item.Price)
 		if err != nil {
-			return nil, fmt.Errorf("failed to query order items: %w", err)
+			return fmt.Errorf("failed to scan order item: %w", err)
 		}
-		defer rows.Close()
-
-		// Scan order items
-		for rows.Next() {
-			item := OrderItem{}
-			err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.Quantity, &item.Price)
-			if err != nil {
-				return nil, fmt.Errorf("failed to scan order item: %w", err)
-			}
+		if order, ok := byID[item.OrderID]; ok {
 			order.Items = append(order.Items, item)
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read order items: %w", err)
+	}
 
-	return orders, nil
+	return nil
 }
 
-// Update updates an order
-func (r *orderRepository) Update(ctx context.Context, order *Order) error {
+// UpdateStatus stores the status and cancellation reason of an order, provided
+// it still has the version it was read with, and records the change in the
+// order's status history. It returns a VersionConflictError when another
+// request changed the order first.
+func (r *orderRepository) UpdateStatus(ctx context.Context, order *Order, change StatusChange) error {
 	// Start a transaction
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -207,22 +383,178 @@ func (r *orderRepository) Update(ctx context.Context, order *Order) error {
 	defer tx.Rollback()
 
 	// Set updated timestamp
-	order.UpdatedAt = time.Now()
+	updatedAt := time.Now()
 
-	// Update order
-	_, err = tx.ExecContext(
+	// Update order if it is unchanged
+	result, err := tx.ExecContext(
 		ctx,
-		"UPDATE orders SET user_id = $1, status = $2, updated_at = $3 WHERE id = $4",
-		order.UserID, order.Status, order.UpdatedAt, order.ID,
+		"UPDATE orders SET status = $1, cancel_reason = $2, updated_at = $3, version = version + 1, locked_until = NULL WHERE id = $4 AND version = $5",
+		order.Status, nullString(order.CancelReason), updatedAt, order.ID, order.Version,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update order: %w", err)
 	}
+	if err := checkVersion(result, order); err != nil {
+		return err
+	}
+
+	// Record the change
+	change.CreatedAt = updatedAt
+	if err := recordStatusChange(ctx, tx, order, change); err != nil {
+		return err
+	}
+
+	// Publish the change through the outbox
+	if r.events != nil {
+		event, err := r.events.StatusChanged(order, change)
+		if err != nil {
+			return fmt.Errorf("failed to encode order event: %w", err)
+		}
+		if err := insertOutboxEvent(ctx, tx, event); err != nil {
+			return err
+		}
+	}
 
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	order.UpdatedAt = updatedAt
+	order.Version++
+	return nil
+}
+
+// Claim reserves order for a change whose stock effect has to run before the
+// change is stored, provided it still has the version it was read with and no
+// other change holds it. The claim bumps the version, so requests that read the
+// order earlier fail to store theirs, and lasts until the change is stored, the
+// claim is released or until passes, e.g. because the process died.
+func (r *orderRepository) Claim(ctx context.Context, order *Order, until time.Time) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		"UPDATE orders SET version = version + 1, locked_until = $1 WHERE id = $2 AND version = $3 AND (locked_until IS NULL OR locked_until <= $4)",
+		until, order.ID, order.Version, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to claim order: %w", err)
+	}
+	if err := checkVersion(result, order); err != nil {
+		return err
+	}
+
+	order.Version++
 	return nil
-}
\ No newline at end of file
+}
+
+// ReleaseClaim gives up the claim on order after its change failed, restoring
+// the version the order had before it was claimed
+func (r *orderRepository) ReleaseClaim(ctx context.Context, order *Order) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		"UPDATE orders SET version = version - 1, locked_until = NULL WHERE id = $1 AND version = $2",
+		order.ID, order.Version,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release order: %w", err)
+	}
+	if err := checkVersion(result, order); err != nil {
+		return err
+	}
+
+	order.Version--
+	return nil
+}
+
+// ReplaceItems replaces the items of an order with order.Items, provided it
+// still has the version it was read with
+func (r *orderRepository) ReplaceItems(ctx context.Context, order *Order) error {
+	// Start a transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Set updated timestamp
+	updatedAt := time.Now()
+
+	// Bump the version if the order is unchanged, locking it until commit
+	result, err := tx.ExecContext(
+		ctx,
+		"UPDATE orders SET updated_at = $1, version = version + 1, locked_until = NULL WHERE id = $2 AND version = $3",
+		updatedAt, order.ID, order.Version,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update order: %w", err)
+	}
+	if err := checkVersion(result, order); err != nil {
+		return err
+	}
+
+	// Replace order items; kept lines keep their IDs
+	_, err = tx.ExecContext(ctx, "DELETE FROM order_items WHERE order_id = $1", order.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete order items: %w", err)
+	}
+	if err := insertItems(ctx, tx, order); err != nil {
+		return err
+	}
+
+	// Publish the change through the outbox
+	order.UpdatedAt = updatedAt
+	order.Version++
+	if r.events != nil {
+		event, err := r.events.ItemsChanged(order)
+		if err != nil {
+			return fmt.Errorf("failed to encode order event: %w", err)
+		}
+		if err := insertOutboxEvent(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// checkVersion returns a VersionConflictError when a conditional update of
+// order matched no row because its version changed
+func checkVersion(result sql.Result, order *Order) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return &VersionConflictError{OrderID: order.ID, Version: order.Version}
+	}
+	return nil
+}
+
+// insertItems inserts the items of order within tx, assigning IDs to new items
+func insertItems(ctx context.Context, tx *sql.Tx, order *Order) error {
+	for i := range order.Items {
+		// Generate a new UUID for the item if not provided
+		if order.Items[i].ID == "" {
+			order.Items[i].ID = uuid.New().String()
+		}
+
+		// Set order ID
+		order.Items[i].OrderID = order.ID
+
+		// Insert order item
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO order_items (id, order_id, product_id, quantity, price) VALUES ($1, $2, $3, $4, $5)",
+			order.Items[i].ID, order.Items[i].OrderID, order.Items[i].ProductID, order.Items[i].Quantity, order.Items[i].Price,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert order item: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetByID_InvalidIDNotFound(t *testing.T) {
+	// Open a database that is never reached
+	db, err := sql.Open("postgres", "postgres://localhost:1/unused?sslmode=disable")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Get an order by an ID that is not a UUID
+	order, err := repository.NewOrderRepository(db).GetByID(context.Background(), "not-a-uuid")
+
+	// Assert it is reported as missing rather than as a database error
+	assert.ErrorIs(t, err, repository.ErrOrderNotFound)
+	assert.Nil(t, order)
+}
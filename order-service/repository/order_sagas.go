@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SagaStatus describes how far the creation saga of an order has got
+type SagaStatus string
+
+const (
+	// SagaStatusRunning marks a saga whose forward steps have not finished
+	SagaStatusRunning SagaStatus = "running"
+	// SagaStatusCompensating marks a saga whose compensation has not finished
+	SagaStatusCompensating SagaStatus = "compensating"
+	// SagaStatusCompleted marks a saga whose forward steps all succeeded
+	SagaStatusCompleted SagaStatus = "completed"
+	// SagaStatusCompensated marks a saga that was undone
+	SagaStatusCompensated SagaStatus = "compensated"
+)
+
+// SagaStep names the next step a saga has to run
+type SagaStep string
+
+const (
+	// SagaStepReserveStock reserves the order's stock and marks it reserved
+	SagaStepReserveStock SagaStep = "reserve_stock"
+	// SagaStepRejectOrder releases the order's stock and marks it rejected
+	SagaStepRejectOrder SagaStep = "reject_order"
+	// SagaStepDone is the step of a finished saga
+	SagaStepDone SagaStep = "done"
+)
+
+// Saga represents the persisted state of an order's creation saga
+type Saga struct {
+	OrderID   string
+	Status    SagaStatus
+	Step      SagaStep
+	LastError string // failure that made the saga compensate, if any
+	Attempts  int    // failed compensation attempts so far
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Finished reports whether the saga needs no further steps
+func (s *Saga) Finished() bool {
+	return s.Status == SagaStatusCompleted || s.Status == SagaStatusCompensated
+}
+
+// UpdateSaga stores the status, step, error and attempts of a saga
+func (r *orderRepository) UpdateSaga(ctx context.Context, saga *Saga) error {
+	// Set updated timestamp
+	updatedAt := time.Now()
+
+	// Update saga
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE order_sagas SET status = $1, step = $2, last_error = $3, attempts = $4, updated_at = $5 WHERE order_id = $6",
+		saga.Status, saga.Step, nullString(saga.LastError), saga.Attempts, updatedAt, saga.OrderID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update saga: %w", err)
+	}
+
+	saga.UpdatedAt = updatedAt
+	return nil
+}
+
+// ListUnfinishedSagas lists up to limit running or compensating sagas that
+// have not been updated since updatedBefore, oldest first
+func (r *orderRepository) ListUnfinishedSagas(ctx context.Context, updatedBefore time.Time, limit int) ([]*Saga, error) {
+	// Query sagas
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT order_id, status, step, last_error, attempts, created_at, updated_at FROM order_sagas
+		WHERE status IN ($1, $2) AND updated_at < $3 ORDER BY updated_at LIMIT $4`,
+		SagaStatusRunning, SagaStatusCompensating, updatedBefore, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sagas: %w", err)
+	}
+	defer rows.Close()
+
+	// Scan sagas
+	sagas := []*Saga{}
+	for rows.Next() {
+		saga := &Saga{}
+		var lastError sql.NullString
+		err := rows.Scan(&saga.OrderID, &saga.Status, &saga.Step, &lastError, &saga.Attempts, &saga.CreatedAt, &saga.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saga: %w", err)
+		}
+		saga.LastError = lastError.String
+		sagas = append(sagas, saga)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read sagas: %w", err)
+	}
+
+	return sagas, nil
+}
+
+// insertSaga starts the saga of an order within tx
+func insertSaga(ctx context.Context, tx *sql.Tx, saga *Saga) error {
+	_, err := tx.ExecContext(
+		ctx,
+		"INSERT INTO order_sagas (order_id, status, step, last_error, attempts, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		saga.OrderID, saga.Status, saga.Step, nullString(saga.LastError), saga.Attempts, saga.CreatedAt, saga.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert saga: %w", err)
+	}
+	return nil
+}
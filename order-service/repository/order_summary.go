@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// StatusTotal represents how many orders a user has in one status and what
+// their items add up to
+type StatusTotal struct {
+	Status string
+	Orders int
+	Amount float64
+}
+
+// SummarizeByUser totals the orders of a user per status
+func (r *orderRepository) SummarizeByUser(ctx context.Context, userID string) ([]StatusTotal, error) {
+	// Query totals
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT o.status, COUNT(DISTINCT o.id), COALESCE(SUM(i.quantity * i.price), 0)
+		FROM orders o LEFT JOIN order_items i ON i.order_id = o.id
+		WHERE o.user_id = $1 GROUP BY o.status ORDER BY o.status`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query order totals: %w", err)
+	}
+	defer rows.Close()
+
+	// Scan totals
+	totals := []StatusTotal{}
+	for rows.Next() {
+		var total StatusTotal
+		if err := rows.Scan(&total.Status, &total.Orders, &total.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan order totals: %w", err)
+		}
+		totals = append(totals, total)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read order totals: %w", err)
+	}
+
+	return totals, nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxEvent represents a domain event waiting in the outbox to be published
+type OutboxEvent struct {
+	ID          int64
+	EventID     string // unique per event; consumers use it to drop duplicates
+	OrderID     string
+	EventType   string
+	Payload     []byte
+	CreatedAt   time.Time
+	PublishedAt *time.Time
+}
+
+// EventEncoder turns order changes into outbox events. It returns nil for
+// changes that publish no event.
+type EventEncoder interface {
+	OrderCreated(order *Order) (*OutboxEvent, error)
+	StatusChanged(order *Order, change StatusChange) (*OutboxEvent, error)
+	ItemsChanged(order *Order) (*OutboxEvent, error)
+}
+
+// OutboxRepository defines the interface for outbox operations
+type OutboxRepository interface {
+	ListUnpublished(ctx context.Context, limit int) ([]*OutboxEvent, error)
+	MarkPublished(ctx context.Context, ids []int64) error
+}
+
+// outboxRepository implements OutboxRepository interface
+type outboxRepository struct {
+	db *sql.DB
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(db *sql.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// ListUnpublished lists up to limit events that have not been published yet, oldest first
+func (r *outboxRepository) ListUnpublished(ctx context.Context, limit int) ([]*OutboxEvent, error) {
+	// Query events
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, event_id, order_id, event_type, payload, created_at FROM order_outbox
+		WHERE published_at IS NULL ORDER BY id LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	defer rows.Close()
+
+	// Scan events
+	events := []*OutboxEvent{}
+	for rows.Next() {
+		event := &OutboxEvent{}
+		err := rows.Scan(&event.ID, &event.EventID, &event.OrderID, &event.EventType, &event.Payload, &event.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+
+	return events, nil
+}
+
+// MarkPublished records that the events with the given IDs have been published
+func (r *outboxRepository) MarkPublished(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// Build the ID list
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, time.Now())
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args = append(args, id)
+	}
+
+	// Update events
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE order_outbox SET published_at = $1 WHERE id IN ("+strings.Join(placeholders, ", ")+")",
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox events published: %w", err)
+	}
+	return nil
+}
+
+// insertOutboxEvent adds an event to the outbox within tx; a nil event is ignored
+func insertOutboxEvent(ctx context.Context, tx *sql.Tx, event *OutboxEvent) error {
+	if event == nil {
+		return nil
+	}
+	if event.EventID == "" {
+		event.EventID = uuid.New().String()
+	}
+
+	_, err := tx.ExecContext(
+		ctx,
+		"INSERT INTO order_outbox (event_id, order_id, event_type, payload, created_at) VALUES ($1, $2, $3, $4, $5)",
+		event.EventID, event.OrderID, event.EventType, event.Payload, event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert outbox event: %w", err)
+	}
+	return nil
+}
@@ -1,15 +1,28 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// NewPostgresConnection creates a new PostgreSQL connection
+// NewPostgresConnection creates a new PostgreSQL connection. Statements run
+// within a trace are recorded as spans of that trace.
 func NewPostgresConnection(databaseURL string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", databaseURL)
+	db, err := otelsql.Open("postgres", databaseURL,
+		otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			SpanFilter:           inTrace,
+		}),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
@@ -27,6 +40,12 @@ func NewPostgresConnection(databaseURL string) (*sql.DB, error) {
 	return db, nil
 }
 
+// inTrace only lets statements issued within a trace create spans, so that
+// background workers polling the database do not start a trace on every pass
+func inTrace(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+	return trace.SpanContextFromContext(ctx).IsValid()
+}
+
 // createTables creates the necessary tables if they don't exist
 func createTables(db *sql.DB) error {
 	// Create orders table
@@ -57,5 +76,197 @@ func createTables(db *sql.DB) error {
 		return err
 	}
 
+	// Add cancellation reason for databases created before it existed
+	_, err = db.Exec(`
+		ALTER TABLE orders ADD COLUMN IF NOT EXISTS cancel_reason TEXT
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create order_status_history table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS order_status_history (
+			id BIGSERIAL PRIMARY KEY,
+			order_id UUID NOT NULL REFERENCES orders(id),
+			from_status VARCHAR(50),
+			to_status VARCHAR(50) NOT NULL,
+			reason TEXT,
+			actor VARCHAR(255) NOT NULL,
+			error TEXT,
+			created_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Index history by order for timelines
+	_, err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_order_status_history_order_id ON order_status_history (order_id)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create idempotency_keys table for retried order creation
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS idempotency_keys (
+			scope VARCHAR(255) NOT NULL DEFAULT '',
+			key VARCHAR(255) NOT NULL,
+			request_hash CHAR(64) NOT NULL,
+			status_code INT,
+			response_body BYTEA,
+			etag VARCHAR(255),
+			created_at TIMESTAMP NOT NULL,
+			expires_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Scope idempotency keys to the user sending them; keys created before
+	// scoping belong to the empty scope
+	_, err = db.Exec(`
+		ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS scope VARCHAR(255) NOT NULL DEFAULT ''
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_keys_scope_key ON idempotency_keys (scope, key)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Store the entity tag of the response, so that a replay carries it
+	_, err = db.Exec(`
+		ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS etag VARCHAR(255)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Lock in-progress idempotency keys only for a lease, so that a request
+	// that crashed does not block its retries until the key expires
+	_, err = db.Exec(`
+		ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Index idempotency keys by expiry for cleanup
+	_, err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create order_sagas table for resumable order creation
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS order_sagas (
+			order_id UUID PRIMARY KEY REFERENCES orders(id),
+			status VARCHAR(50) NOT NULL,
+			step VARCHAR(50) NOT NULL,
+			last_error TEXT,
+			attempts INT NOT NULL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Index unfinished sagas for recovery
+	_, err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_order_sagas_unfinished ON order_sagas (updated_at) WHERE status IN ('running', 'compensating')
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create order_outbox table for events waiting to be published
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS order_outbox (
+			id BIGSERIAL PRIMARY KEY,
+			event_id UUID NOT NULL UNIQUE,
+			order_id UUID NOT NULL,
+			event_type VARCHAR(100) NOT NULL,
+			payload BYTEA NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			published_at TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Index unpublished events for the relay
+	_, err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_order_outbox_unpublished ON order_outbox (id) WHERE published_at IS NULL
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Index orders for keyset pagination
+	_, err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_orders_created_at_id ON orders (created_at, id)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Index orders by user for order history pages
+	_, err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_orders_user_id_created_at_id ON orders (user_id, created_at, id)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Index order items by order so items are loaded without a table scan
+	_, err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items (order_id)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add version column for optimistic concurrency control
+	_, err = db.Exec(`
+		ALTER TABLE orders ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add lease column for changes that move stock before they are stored
+	_, err = db.Exec(`
+		ALTER TABLE orders ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Rename the confirmed status, which became reserved in the order lifecycle
+	_, err = db.Exec(`
+		UPDATE orders SET status = 'reserved' WHERE status = 'confirmed'
+	`)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// defaultActor is recorded when no actor is attached to the context
+const defaultActor = "system"
+
+// actorKey is the context key for the actor responsible for a change
+type actorKey struct{}
+
+// StatusChange represents one entry in the status history of an order
+type StatusChange struct {
+	ID         int64
+	OrderID    string
+	FromStatus string // empty for the status an order was created with
+	ToStatus   string
+	Reason     string
+	Actor      string
+	Error      string // failure that caused the change, e.g. a rejected reservation
+	CreatedAt  time.Time
+}
+
+// WithActor returns a context that records actor on every status change
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor attached to the context, or "system"
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return defaultActor
+}
+
+// ListStatusHistory lists the status changes of an order in the order they happened
+func (r *orderRepository) ListStatusHistory(ctx context.Context, orderID string) ([]StatusChange, error) {
+	// Query history
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, order_id, from_status, to_status, reason, actor, error, created_at
+		FROM order_status_history WHERE order_id = $1 ORDER BY id`,
+		orderID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status history: %w", err)
+	}
+	defer rows.Close()
+
+	// Scan history
+	history := []StatusChange{}
+	for rows.Next() {
+		var change StatusChange
+		var fromStatus, reason, changeErr sql.NullString
+		err := rows.Scan(&change.ID, &change.OrderID, &fromStatus, &change.ToStatus, &reason, &change.Actor, &changeErr, &change.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan status change: %w", err)
+		}
+		change.FromStatus = fromStatus.String
+		change.Reason = reason.String
+		change.Error = changeErr.String
+		history = append(history, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read status history: %w", err)
+	}
+
+	return history, nil
+}
+
+// recordStatusChange inserts a status history entry for the current status of
+// order within tx, taking the actor from the context
+func recordStatusChange(ctx context.Context, tx *sql.Tx, order *Order, change StatusChange) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO order_status_history (order_id, from_status, to_status, reason, actor, error, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		order.ID, nullString(change.FromStatus), order.Status, nullString(change.Reason),
+		ActorFromContext(ctx), nullString(change.Error), change.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert status history: %w", err)
+	}
+	return nil
+}
+
+// nullString stores empty strings as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
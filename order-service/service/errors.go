@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+)
+
+// Error kinds returned by OrderService; match them with errors.Is. Every error
+// a caller can act on matches exactly one kind, except ErrVersionMismatch,
+// which reports a failed If-Match precondition and is matched on its own.
+var (
+	// ErrValidation is returned when a request is invalid; see ValidationError
+	ErrValidation = errors.New("validation failed")
+	// ErrNotFound is returned when an order does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when the current state of an order or of a
+	// request does not allow a change
+	ErrConflict = errors.New("conflict")
+	// ErrInsufficientStock is returned when an order asks for more stock than
+	// is available; see InsufficientStockError
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrUnavailable is returned when a service the order service depends on
+	// cannot be reached; the request may succeed when retried
+	ErrUnavailable = errors.New("upstream unavailable")
+)
+
+// FieldError describes why one field of a request is invalid
+type FieldError struct {
+	Field   string // path of the field in the request, e.g. items[1].quantity
+	Message string
+}
+
+// ValidationError reports every invalid field of a request. It matches
+// ErrValidation as well as Err, which names what was invalid.
+type ValidationError struct {
+	Err    error
+	Fields []FieldError
+}
+
+// Error implements error
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Err.Error()
+	}
+	problems := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		problems[i] = field.Field + " " + field.Message
+	}
+	return e.Err.Error() + ": " + strings.Join(problems, "; ")
+}
+
+// Unwrap returns ErrValidation and the error naming what was invalid
+func (e *ValidationError) Unwrap() []error {
+	return []error{ErrValidation, e.Err}
+}
+
+// invalidField returns a validation error for a single field
+func invalidField(err error, field, message string) error {
+	return &ValidationError{Err: err, Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+// kindError attaches an error kind to an error without changing its message
+type kindError struct {
+	kind error
+	err  error
+}
+
+// Error implements error
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the kind and the underlying error
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// newKindError returns a sentinel error with message that matches kind
+func newKindError(kind error, message string) error {
+	return &kindError{kind: kind, err: errors.New(message)}
+}
+
+// repoError attaches the matching error kind to an order repository error
+func repoError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrOrderNotFound):
+		return &kindError{kind: ErrNotFound, err: err}
+	case errors.Is(err, repository.ErrOrderConflict):
+		return &kindError{kind: ErrConflict, err: err}
+	}
+	return err
+}
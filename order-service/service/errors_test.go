@@ -0,0 +1,171 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+	"github.com/fardannozami/golang-microservice/order-service/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorKinds(t *testing.T) {
+	kinds := []error{
+		service.ErrValidation,
+		service.ErrNotFound,
+		service.ErrConflict,
+		service.ErrInsufficientStock,
+		service.ErrUnavailable,
+	}
+	tests := []struct {
+		name string
+		err  error
+		kind error
+	}{
+		{"invalid order status", service.ErrInvalidOrderStatus, service.ErrConflict},
+		{"idempotency key reused", service.ErrIdempotencyKeyReused, service.ErrConflict},
+		{"idempotency key in progress", service.ErrIdempotencyKeyInProgress, service.ErrConflict},
+		{"inventory invalid argument", service.ErrInventoryInvalidArgument, service.ErrValidation},
+		{"inventory precondition", service.ErrInventoryPrecondition, service.ErrConflict},
+		{"inventory unavailable", service.ErrInventoryUnavailable, service.ErrUnavailable},
+		{"insufficient stock", &service.InsufficientStockError{ProductID: "prod-001", Available: 1, Requested: 2}, service.ErrInsufficientStock},
+		{"validation error", &service.ValidationError{Err: service.ErrInvalidOrder}, service.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, kind := range kinds {
+				assert.Equal(t, kind == tt.kind, errors.Is(tt.err, kind), "kind %v", kind)
+			}
+		})
+	}
+}
+
+func TestCreateOrder_ReportsEveryInvalidField(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Create request
+	req := &service.CreateOrderRequest{
+		Items: []service.OrderItemRequest{
+			{ProductID: "prod-001", Quantity: 1},
+			{Quantity: 0, Price: -1},
+		},
+	}
+
+	// Call service
+	order, err := orderService.CreateOrder(context.Background(), req)
+
+	// Assert expectations
+	var invalid *service.ValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.ErrorIs(t, err, service.ErrInvalidOrder)
+	assert.Nil(t, order)
+	assert.Equal(t, []service.FieldError{
+		{Field: "user_id", Message: "is required"},
+		{Field: "items[1].product_id", Message: "is required"},
+		{Field: "items[1].quantity", Message: "must be positive"},
+		{Field: "items[1].price", Message: "must not be negative"},
+	}, invalid.Fields)
+	assert.Equal(t, "invalid order: user_id is required; items[1].product_id is required; items[1].quantity must be positive; items[1].price must not be negative", err.Error())
+
+	// Verify mocks
+	inventoryClient.AssertNotCalled(t, "GetPrices", mock.Anything, mock.Anything)
+}
+
+func TestCreateOrder_UnknownProduct(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Create request
+	req := &service.CreateOrderRequest{
+		UserID: "user123",
+		Items: []service.OrderItemRequest{
+			{ProductID: "prod-001", Quantity: 1},
+			{ProductID: "prod-404", Quantity: 1},
+		},
+	}
+
+	// Set up expectations
+	inventoryClient.On("GetPrices", mock.Anything, []string{"prod-001", "prod-404"}).Return(map[string]float64{"prod-001": 10.0}, nil)
+
+	// Call service
+	order, err := orderService.CreateOrder(context.Background(), req)
+
+	// Assert expectations
+	var invalid *service.ValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.Nil(t, order)
+	assert.Equal(t, []service.FieldError{
+		{Field: "items[1].product_id", Message: "is not a catalog product: prod-404"},
+	}, invalid.Fields)
+
+	// Verify mocks
+	inventoryClient.AssertExpectations(t)
+	orderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetOrder_DatabaseErrorIsNotNotFound(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(nil, errors.New("failed to get order: connection refused"))
+
+	// Call service
+	result, err := orderService.GetOrder(context.Background(), "order123")
+
+	// Assert expectations
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, service.ErrNotFound)
+	assert.Nil(t, result)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+}
+
+func TestFulfillOrder_ReservationGoneIsConflict(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(&repository.Order{
+		ID:     "order123",
+		Status: string(service.OrderStatusReserved),
+	}, nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("ReleaseClaim", mock.Anything, mock.Anything).Return(nil)
+	inventoryClient.On("CommitStock", mock.Anything, "order123").Return(service.ErrProductNotFound)
+
+	// Call service
+	result, err := orderService.FulfillOrder(context.Background(), "order123")
+
+	// Assert expectations
+	assert.ErrorIs(t, err, service.ErrConflict)
+	assert.ErrorIs(t, err, service.ErrProductNotFound)
+	assert.NotErrorIs(t, err, service.ErrValidation)
+	assert.Nil(t, result)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertExpectations(t)
+}
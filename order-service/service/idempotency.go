@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+)
+
+// Errors returned by IdempotencyService; both match ErrConflict
+var (
+	// ErrIdempotencyKeyReused is returned when an idempotency key is sent again with a different request
+	ErrIdempotencyKeyReused = newKindError(ErrConflict, "idempotency key was used for a different request")
+	// ErrIdempotencyKeyInProgress is returned when the first request made with an idempotency key has not finished yet
+	ErrIdempotencyKeyInProgress = newKindError(ErrConflict, "request with this idempotency key is still in progress")
+)
+
+// idempotencyLease is how long a request holds its idempotency key before a
+// retry may take the key over, on the assumption that the request crashed
+const idempotencyLease = 30 * time.Second
+
+// IdempotentResponse is the response stored for a request made with an idempotency key
+type IdempotentResponse struct {
+	StatusCode int
+	Body       []byte
+	// ETag is the entity tag of the response, if it had one
+	ETag string
+}
+
+// IdempotencyService defines the interface for idempotent request handling.
+// Keys are scoped, e.g. to the user sending them, so that the keys of one
+// scope never collide with those of another.
+type IdempotencyService interface {
+	Begin(ctx context.Context, scope, key string, request []byte) (*IdempotentResponse, error)
+	Complete(ctx context.Context, scope, key string, response IdempotentResponse) error
+	Abandon(ctx context.Context, scope, key string) error
+	PurgeExpired(ctx context.Context) (int, error)
+}
+
+// idempotencyService implements IdempotencyService interface
+type idempotencyService struct {
+	repo repository.IdempotencyRepository
+	ttl  time.Duration
+}
+
+// NewIdempotencyService creates a new idempotency service that remembers keys for ttl
+func NewIdempotencyService(repo repository.IdempotencyRepository, ttl time.Duration) IdempotencyService {
+	return &idempotencyService{repo: repo, ttl: ttl}
+}
+
+// Begin claims key in scope for request. It returns nil if the request should
+// be processed, or the stored response if the same request was already completed.
+// A request that has not completed within idempotencyLease loses the key to
+// the next retry.
+func (s *idempotencyService) Begin(ctx context.Context, scope, key string, request []byte) (*IdempotentResponse, error) {
+	// Claim the key
+	hash := sha256.Sum256(request)
+	requestHash := hex.EncodeToString(hash[:])
+	now := time.Now()
+	record, err := s.repo.Claim(ctx, scope, key, requestHash, now.Add(idempotencyLease), now.Add(s.ttl))
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, nil
+	}
+
+	// Check the key was used for the same request
+	if record.RequestHash != requestHash {
+		return nil, fmt.Errorf("%w: %s", ErrIdempotencyKeyReused, key)
+	}
+	if !record.Completed() {
+		return nil, fmt.Errorf("%w: %s", ErrIdempotencyKeyInProgress, key)
+	}
+
+	return &IdempotentResponse{StatusCode: record.StatusCode, Body: record.ResponseBody, ETag: record.ETag}, nil
+}
+
+// Complete stores the response to replay for later requests made with key in scope
+func (s *idempotencyService) Complete(ctx context.Context, scope, key string, response IdempotentResponse) error {
+	return s.repo.Complete(ctx, scope, key, response.StatusCode, response.Body, response.ETag)
+}
+
+// Abandon frees key in scope after a request failed in a way that is worth retrying
+func (s *idempotencyService) Abandon(ctx context.Context, scope, key string) error {
+	return s.repo.Release(ctx, scope, key)
+}
+
+// PurgeExpired removes the keys whose responses are no longer replayed and
+// returns how many were removed. Claiming an expired key works without it;
+// purging only keeps the table small.
+func (s *idempotencyService) PurgeExpired(ctx context.Context) (int, error) {
+	deleted, err := s.repo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return int(deleted), nil
+}
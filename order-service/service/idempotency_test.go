@@ -0,0 +1,187 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+	"github.com/fardannozami/golang-microservice/order-service/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockIdempotencyRepository is a mock implementation of IdempotencyRepository
+type MockIdempotencyRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyRepository) Claim(ctx context.Context, scope, key, requestHash string, lockedUntil, expiresAt time.Time) (*repository.IdempotencyRecord, error) {
+	args := m.Called(ctx, scope, key, requestHash, lockedUntil, expiresAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.IdempotencyRecord), args.Error(1)
+}
+
+func (m *MockIdempotencyRepository) Complete(ctx context.Context, scope, key string, statusCode int, body []byte, etag string) error {
+	args := m.Called(ctx, scope, key, statusCode, body, etag)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepository) Release(ctx context.Context, scope, key string) error {
+	args := m.Called(ctx, scope, key)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// requestHash hashes a request the way the idempotency service does
+func requestHash(request string) string {
+	hash := sha256.Sum256([]byte(request))
+	return hex.EncodeToString(hash[:])
+}
+
+func TestIdempotencyBegin_NewKey(t *testing.T) {
+	// Create mock
+	repo := new(MockIdempotencyRepository)
+
+	// Create service
+	idempotencyService := service.NewIdempotencyService(repo, time.Hour)
+
+	// Set up expectations
+	repo.On("Claim", mock.Anything, "user-1", "key-1", requestHash("POST /orders"), mock.MatchedBy(func(lockedUntil time.Time) bool {
+		return lockedUntil.After(time.Now()) && lockedUntil.Before(time.Now().Add(time.Minute))
+	}), mock.MatchedBy(func(expiresAt time.Time) bool {
+		return expiresAt.After(time.Now().Add(59 * time.Minute))
+	})).Return(nil, nil)
+
+	// Call service
+	stored, err := idempotencyService.Begin(context.Background(), "user-1", "key-1", []byte("POST /orders"))
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Nil(t, stored)
+
+	// Verify mocks
+	repo.AssertExpectations(t)
+}
+
+func TestIdempotencyBegin_ReplaysCompletedRequest(t *testing.T) {
+	// Create mock
+	repo := new(MockIdempotencyRepository)
+
+	// Create service
+	idempotencyService := service.NewIdempotencyService(repo, time.Hour)
+
+	// Set up expectations
+	repo.On("Claim", mock.Anything, "user-1", "key-1", requestHash("POST /orders"), mock.Anything, mock.Anything).Return(&repository.IdempotencyRecord{
+		Key:          "key-1",
+		RequestHash:  requestHash("POST /orders"),
+		StatusCode:   201,
+		ResponseBody: []byte(`{"id":"order123"}`),
+		ETag:         `"1"`,
+	}, nil)
+
+	// Call service
+	stored, err := idempotencyService.Begin(context.Background(), "user-1", "key-1", []byte("POST /orders"))
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, &service.IdempotentResponse{StatusCode: 201, Body: []byte(`{"id":"order123"}`), ETag: `"1"`}, stored)
+}
+
+func TestIdempotencyBegin_DifferentRequest(t *testing.T) {
+	// Create mock
+	repo := new(MockIdempotencyRepository)
+
+	// Create service
+	idempotencyService := service.NewIdempotencyService(repo, time.Hour)
+
+	// Set up expectations
+	repo.On("Claim", mock.Anything, "user-1", "key-1", mock.Anything, mock.Anything, mock.Anything).Return(&repository.IdempotencyRecord{
+		Key:         "key-1",
+		RequestHash: requestHash("POST /orders first"),
+		StatusCode:  201,
+	}, nil)
+
+	// Call service
+	stored, err := idempotencyService.Begin(context.Background(), "user-1", "key-1", []byte("POST /orders second"))
+
+	// Assert expectations
+	assert.ErrorIs(t, err, service.ErrIdempotencyKeyReused)
+	assert.Nil(t, stored)
+}
+
+func TestIdempotencyBegin_InProgress(t *testing.T) {
+	// Create mock
+	repo := new(MockIdempotencyRepository)
+
+	// Create service
+	idempotencyService := service.NewIdempotencyService(repo, time.Hour)
+
+	// Set up expectations
+	repo.On("Claim", mock.Anything, "user-1", "key-1", mock.Anything, mock.Anything, mock.Anything).Return(&repository.IdempotencyRecord{
+		Key:         "key-1",
+		RequestHash: requestHash("POST /orders"),
+	}, nil)
+
+	// Call service
+	stored, err := idempotencyService.Begin(context.Background(), "user-1", "key-1", []byte("POST /orders"))
+
+	// Assert expectations
+	assert.ErrorIs(t, err, service.ErrIdempotencyKeyInProgress)
+	assert.Nil(t, stored)
+}
+
+func TestIdempotencyComplete_StoresETag(t *testing.T) {
+	// Create mock
+	repo := new(MockIdempotencyRepository)
+
+	// Create service
+	idempotencyService := service.NewIdempotencyService(repo, time.Hour)
+
+	// Set up expectations
+	repo.On("Complete", mock.Anything, "user-1", "key-1", 201, []byte(`{"id":"order123"}`), `"1"`).Return(nil)
+
+	// Call service
+	err := idempotencyService.Complete(context.Background(), "user-1", "key-1", service.IdempotentResponse{
+		StatusCode: 201,
+		Body:       []byte(`{"id":"order123"}`),
+		ETag:       `"1"`,
+	})
+
+	// Assert expectations
+	assert.NoError(t, err)
+
+	// Verify mocks
+	repo.AssertExpectations(t)
+}
+
+func TestIdempotencyPurgeExpired(t *testing.T) {
+	// Create mock
+	repo := new(MockIdempotencyRepository)
+
+	// Create service
+	idempotencyService := service.NewIdempotencyService(repo, time.Hour)
+
+	// Set up expectations
+	repo.On("DeleteExpired", mock.Anything, mock.MatchedBy(func(now time.Time) bool {
+		return time.Since(now) < time.Minute
+	})).Return(int64(3), nil)
+
+	// Call service
+	deleted, err := idempotencyService.PurgeExpired(context.Background())
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+
+	// Verify mocks
+	repo.AssertExpectations(t)
+}
@@ -3,21 +3,54 @@ package service
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/backoff"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 
 	pb "github.com/fardannozami/golang-microservice/inventory-service/proto"
+	"github.com/fardannozami/golang-microservice/order-service/logging"
 )
 
+// inventoryActor identifies the order service in the inventory movement ledger
+const inventoryActor = "order-service"
+
+// inventoryServiceConfig retries inventory calls that failed with UNAVAILABLE.
+// Reservations and commits are keyed by order, so repeating a call is safe.
+const inventoryServiceConfig = `{
+	"methodConfig": [{
+		"name": [{"service": "inventory.InventoryService"}],
+		"retryPolicy": {
+			"maxAttempts": 3,
+			"initialBackoff": "0.1s",
+			"maxBackoff": "1s",
+			"backoffMultiplier": 2,
+			"retryableStatusCodes": ["UNAVAILABLE"]
+		}
+	}]
+}`
+
+// ReservationItem represents a single product line of an order reservation
+type ReservationItem struct {
+	ProductID string
+	Quantity  int
+}
+
 // InventoryClient defines the interface for inventory client operations
 type InventoryClient interface {
 	CheckStock(ctx context.Context, productID string, quantity int) (bool, error)
 	ReserveStock(ctx context.Context, productID string, quantity int, orderID string) error
 	ReleaseStock(ctx context.Context, productID string, quantity int, orderID string) error
+	ReserveItems(ctx context.Context, orderID string, items []ReservationItem) error
+	CommitStock(ctx context.Context, orderID string) error
+	GetPrices(ctx context.Context, productIDs []string) (map[string]float64, error)
+	Ready(ctx context.Context) error
 	Close() error
 }
 
@@ -25,9 +58,12 @@ type InventoryClient interface {
 type inventoryClient struct {
 	conn   *grpc.ClientConn
 	client pb.InventoryServiceClient
+	health healthpb.HealthClient
 }
 
-// NewInventoryClient creates a new inventory client
+// NewInventoryClient creates a new inventory client. Calls carry the trace
+// context and the request ID of ctx to the inventory service and are recorded
+// as client spans.
 func NewInventoryClient(inventoryServiceURL string) (InventoryClient, error) {
 	// Configure connection parameters with retry
 	connParams := grpc.WithConnectParams(grpc.ConnectParams{
@@ -44,6 +80,9 @@ func NewInventoryClient(inventoryServiceURL string) (InventoryClient, error) {
 	conn, err := grpc.Dial(
 		inventoryServiceURL,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(actorInterceptor, requestIDInterceptor),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler(otelgrpc.WithFilter(filters.Not(filters.HealthCheck())))),
+		grpc.WithDefaultServiceConfig(inventoryServiceConfig),
 		connParams,
 	)
 	if err != nil {
@@ -56,6 +95,7 @@ func NewInventoryClient(inventoryServiceURL string) (InventoryClient, error) {
 	return &inventoryClient{
 		conn:   conn,
 		client: client,
+		health: healthpb.NewHealthClient(conn),
 	}, nil
 }
 
@@ -66,41 +106,43 @@ func (c *inventoryClient) CheckStock(ctx context.Context, productID string, quan
 	defer cancel()
 
 	// Call inventory service
-	log.Printf("[order-service] -> gRPC CheckStock product_id=%s qty=%d", productID, quantity)
+	slog.DebugContext(ctx, "Calling inventory CheckStock", "product_id", productID, "quantity", quantity)
 	resp, err := c.client.CheckStock(ctx, &pb.CheckStockRequest{
 		ProductId: productID,
 		Quantity:  int32(quantity),
 	})
 	if err != nil {
-		return false, fmt.Errorf("failed to check stock: %w", err)
+		return false, inventoryError("check stock", err)
 	}
 
-	log.Printf("[order-service] <- gRPC CheckStock available=%v message=%s", resp.Available, resp.Message)
+	slog.DebugContext(ctx, "Inventory CheckStock returned", "product_id", productID, "available", resp.Available, "message", resp.Message)
 	return resp.Available, nil
 }
 
-// ReserveStock reserves stock for an order
+// ReserveStock reserves stock for an order. Orders hold their reservations
+// until they release or commit them, so the reservation never expires.
 func (c *inventoryClient) ReserveStock(ctx context.Context, productID string, quantity int, orderID string) error {
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	// Call inventory service
-	log.Printf("[order-service] -> gRPC ReserveStock product_id=%s qty=%d order_id=%s", productID, quantity, orderID)
+	slog.DebugContext(ctx, "Calling inventory ReserveStock", "order_id", orderID, "product_id", productID, "quantity", quantity)
 	resp, err := c.client.ReserveStock(ctx, &pb.ReserveStockRequest{
 		ProductId: productID,
 		Quantity:  int32(quantity),
 		OrderId:   orderID,
+		NoExpiry:  true,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to reserve stock: %w", err)
+		return inventoryError("reserve stock", err)
 	}
 
 	if !resp.Success {
 		return fmt.Errorf("failed to reserve stock: %s", resp.Message)
 	}
 
-	log.Printf("[order-service] <- gRPC ReserveStock success=%v message=%s", resp.Success, resp.Message)
+	slog.DebugContext(ctx, "Inventory ReserveStock returned", "order_id", orderID, "product_id", productID, "message", resp.Message)
 
 	return nil
 }
@@ -112,25 +154,145 @@ func (c *inventoryClient) ReleaseStock(ctx context.Context, productID string, qu
 	defer cancel()
 
 	// Call inventory service
-	log.Printf("[order-service] -> gRPC ReleaseStock product_id=%s qty=%d order_id=%s", productID, quantity, orderID)
+	slog.DebugContext(ctx, "Calling inventory ReleaseStock", "order_id", orderID, "product_id", productID, "quantity", quantity)
 	resp, err := c.client.ReleaseStock(ctx, &pb.ReleaseStockRequest{
 		ProductId: productID,
 		Quantity:  int32(quantity),
 		OrderId:   orderID,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to release stock: %w", err)
+		return inventoryError("release stock", err)
 	}
 
 	if !resp.Success {
 		return fmt.Errorf("failed to release stock: %s", resp.Message)
 	}
 
-	log.Printf("[order-service] <- gRPC ReleaseStock success=%v message=%s", resp.Success, resp.Message)
+	slog.DebugContext(ctx, "Inventory ReleaseStock returned", "order_id", orderID, "product_id", productID, "message", resp.Message)
+
+	return nil
+}
+
+// ReserveItems reserves stock for all items of an order in a single round trip.
+// Like ReserveStock, the reservations never expire.
+func (c *inventoryClient) ReserveItems(ctx context.Context, orderID string, items []ReservationItem) error {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Convert items
+	reqItems := make([]*pb.ReservationItem, len(items))
+	for i, item := range items {
+		reqItems[i] = &pb.ReservationItem{
+			ProductId: item.ProductID,
+			Quantity:  int32(item.Quantity),
+		}
+	}
+
+	// Call inventory service
+	slog.DebugContext(ctx, "Calling inventory ReserveItems", "order_id", orderID, "items", len(items))
+	resp, err := c.client.ReserveItems(ctx, &pb.ReserveItemsRequest{
+		OrderId:  orderID,
+		Items:    reqItems,
+		NoExpiry: true,
+	})
+	if err != nil {
+		return inventoryError("reserve stock", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("failed to reserve stock: %s", resp.Message)
+	}
+
+	slog.DebugContext(ctx, "Inventory ReserveItems returned", "order_id", orderID, "message", resp.Message)
+
+	return nil
+}
+
+// CommitStock turns the stock reserved for an order into a permanent deduction
+func (c *inventoryClient) CommitStock(ctx context.Context, orderID string) error {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Call inventory service
+	slog.DebugContext(ctx, "Calling inventory CommitStock", "order_id", orderID)
+	resp, err := c.client.CommitStock(ctx, &pb.CommitStockRequest{
+		OrderId: orderID,
+	})
+	if err != nil {
+		return inventoryError("commit stock", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("failed to commit stock: %s", resp.Message)
+	}
+
+	slog.DebugContext(ctx, "Inventory CommitStock returned", "order_id", orderID, "items", len(resp.Items))
+
+	return nil
+}
+
+// GetPrices gets the catalog unit price of each product
+func (c *inventoryClient) GetPrices(ctx context.Context, productIDs []string) (map[string]float64, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Call inventory service
+	slog.DebugContext(ctx, "Calling inventory BatchGetProducts", "product_ids", productIDs)
+	resp, err := c.client.BatchGetProducts(ctx, &pb.BatchGetProductsRequest{
+		Ids: productIDs,
+	})
+	if err != nil {
+		return nil, inventoryError("get products", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("failed to get products: %s", resp.Message)
+	}
 
+	slog.DebugContext(ctx, "Inventory BatchGetProducts returned", "products", len(resp.Products))
+
+	// Index prices by product ID
+	prices := make(map[string]float64, len(resp.Products))
+	for _, product := range resp.Products {
+		prices[product.Id] = product.Price
+	}
+
+	return prices, nil
+}
+
+// Ready reports whether the inventory service can serve requests: it must be
+// reachable and report SERVING through grpc.health.v1
+func (c *inventoryClient) Ready(ctx context.Context) error {
+	resp, err := c.health.Check(ctx, &healthpb.HealthCheckRequest{
+		Service: pb.InventoryService_ServiceDesc.ServiceName,
+	})
+	if err != nil {
+		return fmt.Errorf("inventory health check failed, connection %s: %w", c.conn.GetState(), err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("inventory service is %s", resp.Status)
+	}
 	return nil
 }
 
+// actorInterceptor tags every inventory call with the order service as actor
+func actorInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-actor", inventoryActor)
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// requestIDInterceptor passes the request ID of the context on to the
+// inventory service, so that its log records can be matched with ours
+func requestIDInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if id := logging.RequestID(ctx); id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", id)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
 // Close closes the connection
 func (c *inventoryClient) Close() error {
 	return c.conn.Close()
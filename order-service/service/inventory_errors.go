@@ -0,0 +1,119 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/fardannozami/golang-microservice/inventory-service/proto"
+)
+
+// Errors returned by InventoryClient; match them with errors.Is
+var (
+	// ErrInventoryInvalidArgument is returned when the inventory service rejects
+	// a request as invalid. It matches ErrValidation.
+	ErrInventoryInvalidArgument = newKindError(ErrValidation, "invalid inventory request")
+	// ErrProductNotFound is returned when a product or its reservation does not
+	// exist. It has no kind of its own, since what it means depends on the call.
+	ErrProductNotFound = errors.New("product not found")
+	// ErrInventoryPrecondition is returned when the current stock does not allow
+	// a change. It matches ErrConflict.
+	ErrInventoryPrecondition = newKindError(ErrConflict, "inventory precondition failed")
+	// ErrInventoryUnavailable is returned when the inventory service or its
+	// database cannot be reached; the call may succeed when retried. It
+	// matches ErrUnavailable.
+	ErrInventoryUnavailable = newKindError(ErrUnavailable, "inventory service unavailable")
+)
+
+// InsufficientStockError reports that a product has less available stock than
+// an order requested. It matches ErrInsufficientStock and ErrInventoryPrecondition.
+type InsufficientStockError struct {
+	ProductID string
+	Available int
+	Requested int
+}
+
+// Error implements error
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for product %s: available %d, requested %d", e.ProductID, e.Available, e.Requested)
+}
+
+// Is reports whether target is ErrInsufficientStock or ErrInventoryPrecondition
+func (e *InsufficientStockError) Is(target error) bool {
+	return target == ErrInsufficientStock || target == ErrInventoryPrecondition
+}
+
+// goneError reports ErrProductNotFound as a conflict: a product or reservation
+// that an order already refers to existed when the order was made, so it has
+// been removed since
+func goneError(err error) error {
+	if errors.Is(err, ErrProductNotFound) {
+		return &kindError{kind: ErrConflict, err: err}
+	}
+	return err
+}
+
+// inventoryCallError attaches an error kind to the message of a failed inventory call
+type inventoryCallError struct {
+	kind    error
+	message string
+}
+
+// Error implements error
+func (e *inventoryCallError) Error() string {
+	return e.message
+}
+
+// Unwrap returns the error kind
+func (e *inventoryCallError) Unwrap() error {
+	return e.kind
+}
+
+// inventoryError translates the gRPC status of a failed inventory call into a typed error
+func inventoryError(op string, err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return fmt.Errorf("failed to %s: %w", op, err)
+	}
+
+	var kind error
+	switch st.Code() {
+	case codes.InvalidArgument:
+		kind = ErrInventoryInvalidArgument
+	case codes.NotFound:
+		kind = ErrProductNotFound
+	case codes.FailedPrecondition:
+		if insufficient := insufficientStock(st); insufficient != nil {
+			return fmt.Errorf("failed to %s: %w", op, insufficient)
+		}
+		kind = ErrInventoryPrecondition
+	case codes.Unavailable, codes.DeadlineExceeded:
+		kind = ErrInventoryUnavailable
+	default:
+		return fmt.Errorf("failed to %s: %w", op, err)
+	}
+
+	return fmt.Errorf("failed to %s: %w", op, &inventoryCallError{kind: kind, message: st.Message()})
+}
+
+// insufficientStock extracts the insufficient stock details of a status, if any
+func insufficientStock(st *status.Status) *InsufficientStockError {
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.Domain != pb.ErrorDomain || info.Reason != pb.ReasonInsufficientStock {
+			continue
+		}
+		available, _ := strconv.Atoi(info.Metadata["available"])
+		requested, _ := strconv.Atoi(info.Metadata["requested"])
+		return &InsufficientStockError{
+			ProductID: info.Metadata["product_id"],
+			Available: available,
+			Requested: requested,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package service_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/fardannozami/golang-microservice/inventory-service/proto"
+	"github.com/fardannozami/golang-microservice/order-service/service"
+)
+
+// fakeInventoryServer answers every ReserveItems and CommitStock call with a fixed error
+type fakeInventoryServer struct {
+	pb.UnimplementedInventoryServiceServer
+	err   error
+	calls int
+}
+
+func (s *fakeInventoryServer) ReserveItems(ctx context.Context, req *pb.ReserveItemsRequest) (*pb.ReserveItemsResponse, error) {
+	s.calls++
+	return nil, s.err
+}
+
+func (s *fakeInventoryServer) CommitStock(ctx context.Context, req *pb.CommitStockRequest) (*pb.CommitStockResponse, error) {
+	s.calls++
+	return nil, s.err
+}
+
+// startInventoryServer serves fake on a local port and returns a client connected to it
+func startInventoryServer(t *testing.T, fake *fakeInventoryServer) service.InventoryClient {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	pb.RegisterInventoryServiceServer(server, fake)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	client, err := service.NewInventoryClient(lis.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestInventoryClient_InsufficientStock(t *testing.T) {
+	st, err := status.New(codes.FailedPrecondition, "product prod-001: insufficient stock: available 3, requested 10").WithDetails(&errdetails.ErrorInfo{
+		Reason: pb.ReasonInsufficientStock,
+		Domain: pb.ErrorDomain,
+		Metadata: map[string]string{
+			"product_id": "prod-001",
+			"available":  "3",
+			"requested":  "10",
+		},
+	})
+	require.NoError(t, err)
+	client := startInventoryServer(t, &fakeInventoryServer{err: st.Err()})
+
+	err = client.ReserveItems(context.Background(), "order-123", []service.ReservationItem{{ProductID: "prod-001", Quantity: 10}})
+
+	var insufficient *service.InsufficientStockError
+	require.ErrorAs(t, err, &insufficient)
+	assert.Equal(t, "prod-001", insufficient.ProductID)
+	assert.Equal(t, 3, insufficient.Available)
+	assert.Equal(t, 10, insufficient.Requested)
+	assert.ErrorIs(t, err, service.ErrInventoryPrecondition)
+}
+
+func TestInventoryClient_StatusCodes(t *testing.T) {
+	tests := []struct {
+		code codes.Code
+		want error
+	}{
+		{codes.InvalidArgument, service.ErrInventoryInvalidArgument},
+		{codes.NotFound, service.ErrProductNotFound},
+		{codes.FailedPrecondition, service.ErrInventoryPrecondition},
+		{codes.Unavailable, service.ErrInventoryUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			client := startInventoryServer(t, &fakeInventoryServer{err: status.Error(tt.code, "no reservations found for order order-123")})
+
+			err := client.CommitStock(context.Background(), "order-123")
+
+			assert.ErrorIs(t, err, tt.want)
+			assert.Equal(t, "failed to commit stock: no reservations found for order order-123", err.Error())
+		})
+	}
+}
+
+func TestInventoryClient_RetriesUnavailable(t *testing.T) {
+	fake := &fakeInventoryServer{err: status.Error(codes.Unavailable, "failed to begin transaction: driver: bad connection")}
+	client := startInventoryServer(t, fake)
+
+	err := client.CommitStock(context.Background(), "order-123")
+
+	assert.ErrorIs(t, err, service.ErrInventoryUnavailable)
+	assert.Equal(t, 3, fake.calls)
+}
+
+func TestInventoryClient_InternalIsNotTyped(t *testing.T) {
+	client := startInventoryServer(t, &fakeInventoryServer{err: status.Error(codes.Internal, "boom")})
+
+	err := client.CommitStock(context.Background(), "order-123")
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, service.ErrInventoryUnavailable)
+	assert.NotErrorIs(t, err, service.ErrProductNotFound)
+}
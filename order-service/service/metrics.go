@@ -0,0 +1,58 @@
+package service
+
+import "errors"
+
+// Reasons a stock reservation failed, as reported to OrderMetrics
+const (
+	ReservationFailureInsufficientStock = "insufficient_stock"
+	ReservationFailureProductNotFound   = "product_not_found"
+	ReservationFailureInvalid           = "invalid"
+	ReservationFailureConflict          = "conflict"
+	ReservationFailureUnavailable       = "unavailable"
+	ReservationFailureError             = "error"
+)
+
+// OrderMetrics records what happens to orders, e.g. as Prometheus counters
+type OrderMetrics interface {
+	// OrderCreated is called once an order is stored as pending
+	OrderCreated()
+	// OrderConfirmed is called once the stock of an order is reserved
+	OrderConfirmed()
+	// OrderRejected is called once an order is rejected for lack of stock
+	OrderRejected()
+	// ReservationFailed is called for every failed reservation call with one
+	// of the ReservationFailure reasons
+	ReservationFailed(reason string)
+}
+
+// WithMetrics sets where order metrics are recorded; by default they are not
+func WithMetrics(metrics OrderMetrics) Option {
+	return func(s *orderService) {
+		s.metrics = metrics
+	}
+}
+
+// noMetrics discards order metrics
+type noMetrics struct{}
+
+func (noMetrics) OrderCreated()            {}
+func (noMetrics) OrderConfirmed()          {}
+func (noMetrics) OrderRejected()           {}
+func (noMetrics) ReservationFailed(string) {}
+
+// reservationFailure names the reason a reservation call failed with err
+func reservationFailure(err error) string {
+	switch {
+	case errors.Is(err, ErrInsufficientStock):
+		return ReservationFailureInsufficientStock
+	case errors.Is(err, ErrProductNotFound):
+		return ReservationFailureProductNotFound
+	case errors.Is(err, ErrValidation):
+		return ReservationFailureInvalid
+	case errors.Is(err, ErrConflict):
+		return ReservationFailureConflict
+	case errors.Is(err, ErrUnavailable):
+		return ReservationFailureUnavailable
+	}
+	return ReservationFailureError
+}
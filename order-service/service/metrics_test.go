@@ -0,0 +1,96 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fardannozami/golang-microservice/order-service/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// recordedMetrics records order metrics for assertions
+type recordedMetrics struct {
+	created, confirmed, rejected int
+	failures                     []string
+}
+
+func (m *recordedMetrics) OrderCreated()   { m.created++ }
+func (m *recordedMetrics) OrderConfirmed() { m.confirmed++ }
+func (m *recordedMetrics) OrderRejected()  { m.rejected++ }
+func (m *recordedMetrics) ReservationFailed(reason string) {
+	m.failures = append(m.failures, reason)
+}
+
+func TestCreateOrder_CountsConfirmedOrder(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+	metrics := &recordedMetrics{}
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient, service.WithMetrics(metrics))
+
+	// Set up expectations
+	inventoryClient.On("GetPrices", mock.Anything, []string{"product123"}).Return(map[string]float64{"product123": 10.0}, nil)
+	orderRepo.On("Create", mock.Anything, mock.AnythingOfType("*repository.Order"), mock.AnythingOfType("*repository.Saga")).Return(nil)
+	orderRepo.On("UpdateSaga", mock.Anything, mock.AnythingOfType("*repository.Saga")).Return(nil)
+	inventoryClient.On("ReserveItems", mock.Anything, mock.AnythingOfType("string"), mock.Anything).Return(nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*repository.Order"), mock.Anything).Return(nil)
+
+	// Call service
+	_, err := orderService.CreateOrder(context.Background(), &service.CreateOrderRequest{
+		UserID: "user123",
+		Items:  []service.OrderItemRequest{{ProductID: "product123", Quantity: 2}},
+	})
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, &recordedMetrics{created: 1, confirmed: 1}, metrics)
+}
+
+func TestCreateOrder_CountsRejectedOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		reason string
+	}{
+		{"insufficient stock", &service.InsufficientStockError{ProductID: "product123", Available: 1, Requested: 2}, service.ReservationFailureInsufficientStock},
+		{"product not found", service.ErrProductNotFound, service.ReservationFailureProductNotFound},
+		{"unavailable", service.ErrInventoryUnavailable, service.ReservationFailureUnavailable},
+		{"precondition", service.ErrInventoryPrecondition, service.ReservationFailureConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create mocks
+			orderRepo := new(MockOrderRepository)
+			inventoryClient := new(MockInventoryClient)
+			metrics := &recordedMetrics{}
+
+			// Create service
+			orderService := service.NewOrderService(orderRepo, inventoryClient, service.WithMetrics(metrics))
+
+			// Set up expectations
+			inventoryClient.On("GetPrices", mock.Anything, []string{"product123"}).Return(map[string]float64{"product123": 10.0}, nil)
+			orderRepo.On("Create", mock.Anything, mock.AnythingOfType("*repository.Order"), mock.AnythingOfType("*repository.Saga")).Return(nil)
+			orderRepo.On("UpdateSaga", mock.Anything, mock.AnythingOfType("*repository.Saga")).Return(nil)
+			inventoryClient.On("ReserveItems", mock.Anything, mock.AnythingOfType("string"), mock.Anything).Return(tt.err)
+			inventoryClient.On("ReleaseStock", mock.Anything, "product123", 2, mock.AnythingOfType("string")).Return(nil)
+			orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			orderRepo.On("ReleaseClaim", mock.Anything, mock.Anything).Return(nil)
+			orderRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*repository.Order"), mock.Anything).Return(nil)
+
+			// Call service
+			_, err := orderService.CreateOrder(context.Background(), &service.CreateOrderRequest{
+				UserID: "user123",
+				Items:  []service.OrderItemRequest{{ProductID: "product123", Quantity: 2}},
+			})
+
+			// Assert expectations
+			assert.Error(t, err)
+			assert.Equal(t, &recordedMetrics{created: 1, rejected: 1, failures: []string{tt.reason}}, metrics)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+)
+
+// ErrInvalidOrderItems is returned for an item change that cannot be applied
+var ErrInvalidOrderItems = errors.New("invalid order items")
+
+// UpdateOrderItemsRequest represents a change to the lines of an order. Lines
+// that are not mentioned stay as they are.
+type UpdateOrderItemsRequest struct {
+	Items []OrderItemChange
+}
+
+// OrderItemChange sets the quantity of one product on an order. A quantity of
+// zero removes the line and a product the order does not have adds one.
+type OrderItemChange struct {
+	ProductID string
+	Quantity  int
+}
+
+// UpdateOrderItems adds, removes or changes lines of a reserved or paid order.
+// The order is claimed first, so a concurrent change fails before it moves any
+// stock. The reservation of every changed line is then moved to its new
+// quantity in a single inventory call, then the lines are stored; if storing fails the
+// reservation is moved back to what the order holds now, so either both change
+// or neither does. New lines are charged the catalog price, kept lines keep the
+// price they were ordered at.
+func (s *orderService) UpdateOrderItems(ctx context.Context, id string, req *UpdateOrderItemsRequest) (*repository.Order, error) {
+	// Validate request
+	if err := validateOrderItemChanges(req); err != nil {
+		return nil, err
+	}
+
+	// Get order
+	order, err := s.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	// Check the caller's expected version
+	if err := checkVersion(ctx, order); err != nil {
+		return nil, err
+	}
+
+	// Only orders whose stock is reserved and not yet committed can change;
+	// pending orders are still being reserved by their creation saga, and
+	// fulfilled ones, although not shipped, have had their stock committed,
+	// which the inventory service cannot move the way it moves reservations
+	if !holdsReservation(OrderStatus(order.Status)) {
+		return nil, fmt.Errorf("cannot change the items of a %s order: %w", order.Status, ErrInvalidOrderStatus)
+	}
+
+	// Work out the new lines and the reservations that have to move
+	items, changed, err := s.applyItemChanges(ctx, order.Items, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) == 0 {
+		return order, nil
+	}
+
+	// Claim the order so that no concurrent change moves its stock as well
+	if err := s.claim(ctx, order); err != nil {
+		return nil, versionError(ctx, err)
+	}
+
+	// Move the reservations of every changed line at once
+	if err := s.inventoryClient.ReserveItems(ctx, order.ID, changed); err != nil {
+		s.releaseClaim(ctx, order)
+		s.metrics.ReservationFailed(reservationFailure(err))
+		return nil, fmt.Errorf("failed to reserve inventory: %w", goneError(err))
+	}
+
+	// Store the new lines, moving the reservations back if that fails
+	previous := order.Items
+	order.Items = items
+	if err := s.orderRepo.ReplaceItems(ctx, order); err != nil {
+		order.Items = previous
+		s.restoreReservations(ctx, order, changed)
+		return nil, versionError(ctx, fmt.Errorf("failed to update order items: %w", repoError(err)))
+	}
+
+	return order, nil
+}
+
+// holdsReservation reports whether orders in status hold reserved stock
+// that has not been committed yet
+func holdsReservation(status OrderStatus) bool {
+	return status == OrderStatusReserved || status == OrderStatusPaid
+}
+
+// restoreReservations moves the reservations of the changed lines back after
+// the new lines of order could not be stored. Another request may have changed
+// the order in the meantime, so the reservations are rebuilt from the order as
+// it is now rather than from the version that was read: the quantities of its
+// lines while it still holds a reservation, and nothing once it was cancelled
+// or its stock committed.
+func (s *orderService) restoreReservations(ctx context.Context, order *repository.Order, changed []ReservationItem) {
+	current, err := s.orderRepo.GetByID(ctx, order.ID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to reload order, restoring reservation from the version read", "order_id", order.ID, "error", err)
+		current = order
+	}
+
+	var reservations []ReservationItem
+	if holdsReservation(OrderStatus(current.Status)) {
+		reservations = reservationsOf(current.Items, changed)
+	} else {
+		reservations = reservationsOf(nil, changed)
+	}
+	if err := s.inventoryClient.ReserveItems(ctx, order.ID, reservations); err != nil {
+		slog.ErrorContext(ctx, "Failed to restore reservation", "order_id", order.ID, "error", err)
+	}
+}
+
+// applyItemChanges returns the lines of an order after changes, and the new
+// quantity of every product whose quantity changed, zero for removed lines
+func (s *orderService) applyItemChanges(ctx context.Context, current []repository.OrderItem, changes []OrderItemChange) ([]repository.OrderItem, []ReservationItem, error) {
+	// Index current lines by product
+	quantities := make(map[string]int, len(current))
+	for _, item := range current {
+		quantities[item.ProductID] = item.Quantity
+	}
+
+	// Look up catalog prices for added lines
+	var added []OrderItemRequest
+	var addedFields []string
+	for i, change := range changes {
+		if _, ok := quantities[change.ProductID]; !ok && change.Quantity > 0 {
+			added = append(added, OrderItemRequest{ProductID: change.ProductID, Quantity: change.Quantity})
+			addedFields = append(addedFields, fmt.Sprintf("items[%d]", i))
+		}
+	}
+	var prices map[string]float64
+	if len(added) > 0 {
+		var err error
+		if prices, err = s.catalogPrices(ctx, added); err != nil {
+			return nil, nil, err
+		}
+		var fields []FieldError
+		for i, item := range added {
+			fields = append(fields, s.checkPrice(addedFields[i], item, prices)...)
+		}
+		if len(fields) > 0 {
+			return nil, nil, &ValidationError{Err: ErrInvalidOrderItems, Fields: fields}
+		}
+	}
+
+	// Collect the reservations that move
+	var changed []ReservationItem
+	targets := make(map[string]int, len(changes))
+	for _, change := range changes {
+		if quantities[change.ProductID] != change.Quantity {
+			changed = append(changed, ReservationItem{ProductID: change.ProductID, Quantity: change.Quantity})
+		}
+		targets[change.ProductID] = change.Quantity
+	}
+
+	// Apply changes to the current lines, keeping their order
+	items := make([]repository.OrderItem, 0, len(current)+len(added))
+	for _, item := range current {
+		if quantity, ok := targets[item.ProductID]; ok {
+			item.Quantity = quantity
+		}
+		if item.Quantity > 0 {
+			items = append(items, item)
+		}
+	}
+	for _, item := range added {
+		items = append(items, repository.OrderItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Price:     prices[item.ProductID],
+		})
+	}
+	if len(items) == 0 {
+		return nil, nil, invalidField(ErrInvalidOrderItems, "items", "must leave the order at least one item; cancel it instead")
+	}
+
+	return items, changed, nil
+}
+
+// reservationsOf returns the quantity items hold of every changed product,
+// zero for products they do not contain
+func reservationsOf(items []repository.OrderItem, changed []ReservationItem) []ReservationItem {
+	quantities := make(map[string]int, len(items))
+	for _, item := range items {
+		quantities[item.ProductID] = item.Quantity
+	}
+
+	reservations := make([]ReservationItem, len(changed))
+	for i, item := range changed {
+		reservations[i] = ReservationItem{ProductID: item.ProductID, Quantity: quantities[item.ProductID]}
+	}
+	return reservations
+}
+
+// validateOrderItemChanges validates an item change request, reporting every invalid field
+func validateOrderItemChanges(req *UpdateOrderItemsRequest) error {
+	// Check if changes are provided
+	if len(req.Items) == 0 {
+		return invalidField(ErrInvalidOrderItems, "items", "must contain at least one item")
+	}
+
+	// Validate each change
+	var fields []FieldError
+	seen := make(map[string]bool, len(req.Items))
+	for i, item := range req.Items {
+		field := fmt.Sprintf("items[%d]", i)
+		if item.ProductID == "" {
+			fields = append(fields, FieldError{Field: field + ".product_id", Message: "is required"})
+		} else if seen[item.ProductID] {
+			fields = append(fields, FieldError{Field: field + ".product_id", Message: fmt.Sprintf("repeats product %s", item.ProductID)})
+		}
+		if item.Quantity < 0 {
+			fields = append(fields, FieldError{Field: field + ".quantity", Message: "must not be negative"})
+		}
+		seen[item.ProductID] = true
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Err: ErrInvalidOrderItems, Fields: fields}
+	}
+	return nil
+}
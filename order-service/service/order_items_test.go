@@ -0,0 +1,337 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+	"github.com/fardannozami/golang-microservice/order-service/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// reservedOrder returns a reserved order with two lines
+func reservedOrder() *repository.Order {
+	return &repository.Order{
+		ID:     "order123",
+		UserID: "user123",
+		Status: string(service.OrderStatusReserved),
+		Items: []repository.OrderItem{
+			{ID: "item1", OrderID: "order123", ProductID: "product123", Quantity: 2, Price: 10.0},
+			{ID: "item2", OrderID: "order123", ProductID: "product456", Quantity: 1, Price: 20.0},
+		},
+	}
+}
+
+func TestUpdateOrderItems_Success(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Create request: raise one line, remove another and add a third
+	req := &service.UpdateOrderItemsRequest{
+		Items: []service.OrderItemChange{
+			{ProductID: "product123", Quantity: 3},
+			{ProductID: "product456", Quantity: 0},
+			{ProductID: "product789", Quantity: 1},
+		},
+	}
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(reservedOrder(), nil)
+	inventoryClient.On("GetPrices", mock.Anything, []string{"product789"}).Return(map[string]float64{"product789": 5.0}, nil)
+	inventoryClient.On("ReserveItems", mock.Anything, "order123", []service.ReservationItem{
+		{ProductID: "product123", Quantity: 3},
+		{ProductID: "product456", Quantity: 0},
+		{ProductID: "product789", Quantity: 1},
+	}).Return(nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("ReplaceItems", mock.Anything, mock.AnythingOfType("*repository.Order")).Return(nil)
+
+	// Call service
+	order, err := orderService.UpdateOrderItems(context.Background(), "order123", req)
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, []repository.OrderItem{
+		{ID: "item1", OrderID: "order123", ProductID: "product123", Quantity: 3, Price: 10.0},
+		{ProductID: "product789", Quantity: 1, Price: 5.0},
+	}, order.Items)
+	assert.Equal(t, 35.0, order.Total())
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertExpectations(t)
+}
+
+func TestUpdateOrderItems_Unchanged(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(reservedOrder(), nil)
+
+	// Call service
+	order, err := orderService.UpdateOrderItems(context.Background(), "order123", &service.UpdateOrderItemsRequest{
+		Items: []service.OrderItemChange{{ProductID: "product123", Quantity: 2}},
+	})
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Len(t, order.Items, 2)
+	inventoryClient.AssertNotCalled(t, "ReserveItems", mock.Anything, mock.Anything, mock.Anything)
+	orderRepo.AssertNotCalled(t, "ReplaceItems", mock.Anything, mock.Anything)
+}
+
+func TestUpdateOrderItems_NotReserved(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	order := reservedOrder()
+	order.Status = string(service.OrderStatusShipped)
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(order, nil)
+
+	// Call service
+	result, err := orderService.UpdateOrderItems(context.Background(), "order123", &service.UpdateOrderItemsRequest{
+		Items: []service.OrderItemChange{{ProductID: "product123", Quantity: 3}},
+	})
+
+	// Assert expectations
+	assert.ErrorIs(t, err, service.ErrInvalidOrderStatus)
+	assert.Nil(t, result)
+	inventoryClient.AssertNotCalled(t, "ReserveItems", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateOrderItems_RemovesEveryLine(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(reservedOrder(), nil)
+
+	// Call service
+	result, err := orderService.UpdateOrderItems(context.Background(), "order123", &service.UpdateOrderItemsRequest{
+		Items: []service.OrderItemChange{
+			{ProductID: "product123", Quantity: 0},
+			{ProductID: "product456", Quantity: 0},
+		},
+	})
+
+	// Assert expectations
+	assert.ErrorIs(t, err, service.ErrInvalidOrderItems)
+	assert.Nil(t, result)
+	inventoryClient.AssertNotCalled(t, "ReserveItems", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateOrderItems_InsufficientStock(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(reservedOrder(), nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("ReleaseClaim", mock.Anything, mock.Anything).Return(nil)
+	inventoryClient.On("ReserveItems", mock.Anything, "order123", mock.Anything).Return(service.ErrInventoryPrecondition)
+
+	// Call service
+	result, err := orderService.UpdateOrderItems(context.Background(), "order123", &service.UpdateOrderItemsRequest{
+		Items: []service.OrderItemChange{{ProductID: "product123", Quantity: 50}},
+	})
+
+	// Assert expectations: nothing is stored
+	assert.ErrorIs(t, err, service.ErrInventoryPrecondition)
+	assert.Nil(t, result)
+	orderRepo.AssertNotCalled(t, "ReplaceItems", mock.Anything, mock.Anything)
+}
+
+func TestUpdateOrderItems_StoreFailureRestoresReservation(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(reservedOrder(), nil)
+	inventoryClient.On("ReserveItems", mock.Anything, "order123", []service.ReservationItem{
+		{ProductID: "product456", Quantity: 0},
+	}).Return(nil).Once()
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("ReplaceItems", mock.Anything, mock.AnythingOfType("*repository.Order")).Return(&repository.VersionConflictError{OrderID: "order123", Version: 1})
+	inventoryClient.On("ReserveItems", mock.Anything, "order123", []service.ReservationItem{
+		{ProductID: "product456", Quantity: 1},
+	}).Return(nil).Once()
+
+	// Call service
+	result, err := orderService.UpdateOrderItems(context.Background(), "order123", &service.UpdateOrderItemsRequest{
+		Items: []service.OrderItemChange{{ProductID: "product456", Quantity: 0}},
+	})
+
+	// Assert expectations
+	assert.ErrorIs(t, err, repository.ErrOrderConflict)
+	assert.Nil(t, result)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertExpectations(t)
+}
+
+func TestUpdateOrderItems_Paid(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	order := reservedOrder()
+	order.Status = string(service.OrderStatusPaid)
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(order, nil)
+	inventoryClient.On("ReserveItems", mock.Anything, "order123", []service.ReservationItem{
+		{ProductID: "product123", Quantity: 3},
+	}).Return(nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("ReplaceItems", mock.Anything, mock.AnythingOfType("*repository.Order")).Return(nil)
+
+	// Call service
+	result, err := orderService.UpdateOrderItems(context.Background(), "order123", &service.UpdateOrderItemsRequest{
+		Items: []service.OrderItemChange{{ProductID: "product123", Quantity: 3}},
+	})
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Items[0].Quantity)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertExpectations(t)
+}
+
+func TestUpdateOrderItems_ConcurrentUpdateKeepsWinnersReservation(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations: another request raises product456 to 4 between
+	// reading the order and storing its new lines
+	winner := reservedOrder()
+	winner.Version = 2
+	winner.Items[1].Quantity = 4
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(reservedOrder(), nil).Once()
+	inventoryClient.On("ReserveItems", mock.Anything, "order123", []service.ReservationItem{
+		{ProductID: "product456", Quantity: 2},
+	}).Return(nil).Once()
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("ReplaceItems", mock.Anything, mock.AnythingOfType("*repository.Order")).Return(&repository.VersionConflictError{OrderID: "order123", Version: 0})
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(winner, nil).Once()
+	inventoryClient.On("ReserveItems", mock.Anything, "order123", []service.ReservationItem{
+		{ProductID: "product456", Quantity: 4},
+	}).Return(nil).Once()
+
+	// Call service
+	result, err := orderService.UpdateOrderItems(context.Background(), "order123", &service.UpdateOrderItemsRequest{
+		Items: []service.OrderItemChange{{ProductID: "product456", Quantity: 2}},
+	})
+
+	// Assert expectations: the winner's quantity is reserved, not the one read
+	assert.ErrorIs(t, err, repository.ErrOrderConflict)
+	assert.Nil(t, result)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertExpectations(t)
+}
+
+func TestUpdateOrderItems_ConcurrentCancelReleasesReservation(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations: another request cancels the order between reading
+	// it and storing its new lines
+	cancelled := reservedOrder()
+	cancelled.Version = 2
+	cancelled.Status = string(service.OrderStatusCancelled)
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(reservedOrder(), nil).Once()
+	inventoryClient.On("ReserveItems", mock.Anything, "order123", []service.ReservationItem{
+		{ProductID: "product123", Quantity: 5},
+	}).Return(nil).Once()
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("ReplaceItems", mock.Anything, mock.AnythingOfType("*repository.Order")).Return(&repository.VersionConflictError{OrderID: "order123", Version: 0})
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(cancelled, nil).Once()
+	inventoryClient.On("ReserveItems", mock.Anything, "order123", []service.ReservationItem{
+		{ProductID: "product123", Quantity: 0},
+	}).Return(nil).Once()
+
+	// Call service
+	result, err := orderService.UpdateOrderItems(context.Background(), "order123", &service.UpdateOrderItemsRequest{
+		Items: []service.OrderItemChange{{ProductID: "product123", Quantity: 5}},
+	})
+
+	// Assert expectations: no stock stays reserved for the cancelled order
+	assert.ErrorIs(t, err, repository.ErrOrderConflict)
+	assert.Nil(t, result)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertExpectations(t)
+}
+
+func TestUpdateOrderItems_InvalidRequest(t *testing.T) {
+	tests := map[string]*service.UpdateOrderItemsRequest{
+		"no items":          {},
+		"missing product":   {Items: []service.OrderItemChange{{Quantity: 1}}},
+		"negative quantity": {Items: []service.OrderItemChange{{ProductID: "product123", Quantity: -1}}},
+		"duplicate product": {Items: []service.OrderItemChange{
+			{ProductID: "product123", Quantity: 1},
+			{ProductID: "product123", Quantity: 2},
+		}},
+	}
+
+	for name, req := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Create mocks
+			orderRepo := new(MockOrderRepository)
+			inventoryClient := new(MockInventoryClient)
+
+			// Create service
+			orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+			// Call service
+			result, err := orderService.UpdateOrderItems(context.Background(), "order123", req)
+
+			// Assert expectations
+			assert.ErrorIs(t, err, service.ErrInvalidOrderItems)
+			assert.Nil(t, result)
+			orderRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultPageSize is used when a listing does not specify a page size
+	defaultPageSize = 20
+	// maxPageSize caps the number of orders returned per page
+	maxPageSize = 100
+)
+
+// ErrInvalidOrderFilter is returned for a listing request that cannot be served
+var ErrInvalidOrderFilter = errors.New("invalid order filter")
+
+// ListOrdersRequest represents a request to list orders
+type ListOrdersRequest struct {
+	UserID      string
+	Status      string
+	CreatedFrom time.Time // inclusive; zero means unbounded
+	CreatedTo   time.Time // exclusive; zero means unbounded
+	Sort        string    // created_at_desc (default) or created_at_asc
+	PageSize    int
+	PageToken   string
+}
+
+// OrderPage represents a single page of orders
+type OrderPage struct {
+	Orders        []*repository.Order
+	NextPageToken string
+}
+
+// UserOrderSummary represents the order totals of a user
+type UserOrderSummary struct {
+	TotalOrders    int
+	TotalSpent     float64 // value of the orders the user has paid for and not been refunded
+	OrdersByStatus map[OrderStatus]int
+}
+
+// spentStatuses are the statuses of orders whose value the user has paid
+var spentStatuses = map[OrderStatus]bool{
+	OrderStatusPaid:      true,
+	OrderStatusFulfilled: true,
+	OrderStatusShipped:   true,
+	OrderStatusDelivered: true,
+}
+
+// ListOrders lists orders matching the request page by page. Pages are cut by
+// keyset rather than offset, so orders created while paging neither shift nor
+// repeat entries; a page token is only valid with the filter it was issued for.
+func (s *orderService) ListOrders(ctx context.Context, req *ListOrdersRequest) (*OrderPage, error) {
+	// Validate request
+	filter, err := newOrderFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	pageSize := filter.Limit
+
+	// Fetch one extra order to know whether another page exists
+	filter.Limit = pageSize + 1
+	orders, err := s.orderRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	page := &OrderPage{Orders: orders}
+	if len(orders) > pageSize {
+		page.Orders = orders[:pageSize]
+		last := page.Orders[pageSize-1]
+		page.NextPageToken = encodePageToken(repository.OrderCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	return page, nil
+}
+
+// SummarizeUserOrders counts the orders of a user per status and totals what the user has spent
+func (s *orderService) SummarizeUserOrders(ctx context.Context, userID string) (*UserOrderSummary, error) {
+	// Validate request
+	if userID == "" {
+		return nil, invalidField(ErrInvalidOrderFilter, "user_id", "is required")
+	}
+
+	// Get totals per status
+	totals, err := s.orderRepo.SummarizeByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize orders: %w", err)
+	}
+
+	// Add up totals
+	summary := &UserOrderSummary{OrdersByStatus: make(map[OrderStatus]int, len(totals))}
+	for _, total := range totals {
+		status := OrderStatus(total.Status)
+		summary.TotalOrders += total.Orders
+		summary.OrdersByStatus[status] = total.Orders
+		if spentStatuses[status] {
+			summary.TotalSpent += total.Amount
+		}
+	}
+	summary.TotalSpent = math.Round(summary.TotalSpent*100) / 100
+
+	return summary, nil
+}
+
+// newOrderFilter validates a listing request and turns it into a repository filter
+func newOrderFilter(req *ListOrdersRequest) (repository.OrderFilter, error) {
+	filter := repository.OrderFilter{
+		UserID:      req.UserID,
+		CreatedFrom: req.CreatedFrom,
+		CreatedTo:   req.CreatedTo,
+		Limit:       req.PageSize,
+	}
+
+	// Check page size
+	if filter.Limit < 0 {
+		return filter, invalidField(ErrInvalidOrderFilter, "page_size", "must not be negative")
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageSize
+	}
+	if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+
+	// Check status
+	if req.Status != "" {
+		status, err := ParseOrderStatus(req.Status)
+		if err != nil {
+			return filter, invalidField(ErrInvalidOrderFilter, "status", fmt.Sprintf("is not an order status: %q", req.Status))
+		}
+		filter.Status = string(status)
+	}
+
+	// Check creation range
+	if !req.CreatedFrom.IsZero() && !req.CreatedTo.IsZero() && !req.CreatedFrom.Before(req.CreatedTo) {
+		return filter, invalidField(ErrInvalidOrderFilter, "created_from", "must be before created_to")
+	}
+
+	// Check sort order
+	switch repository.OrderSort(req.Sort) {
+	case "", repository.SortNewestFirst:
+		filter.Sort = repository.SortNewestFirst
+	case repository.SortOldestFirst:
+		filter.Sort = repository.SortOldestFirst
+	default:
+		return filter, invalidField(ErrInvalidOrderFilter, "sort", fmt.Sprintf("is not a sort order: %q", req.Sort))
+	}
+
+	// Decode the position to continue from
+	if req.PageToken != "" {
+		cursor, err := decodePageToken(req.PageToken)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = cursor
+	}
+
+	return filter, nil
+}
+
+// encodePageToken encodes the last order of a page into an opaque page token
+func encodePageToken(cursor repository.OrderCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + cursor.ID))
+}
+
+// decodePageToken decodes a page token back into the last order of the previous page
+func decodePageToken(token string) (*repository.OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, invalidField(ErrInvalidOrderFilter, "page_token", "is not a page token")
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, invalidField(ErrInvalidOrderFilter, "page_token", "is not a page token")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, invalidField(ErrInvalidOrderFilter, "page_token", "is not a page token")
+	}
+	cursor := &repository.OrderCursor{ID: id}
+	cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, invalidField(ErrInvalidOrderFilter, "page_token", "is not a page token")
+	}
+	return cursor, nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+)
+
+// sagaRecoveryBatchSize limits how many sagas are recovered per pass
+const sagaRecoveryBatchSize = 100
+
+// rejectionReason is recorded when an order is rejected because its stock could not be reserved
+const rejectionReason = "stock reservation failed"
+
+// newCreateSaga returns the saga that is started together with a new order
+func newCreateSaga() *repository.Saga {
+	return &repository.Saga{
+		Status: repository.SagaStatusRunning,
+		Step:   repository.SagaStepReserveStock,
+	}
+}
+
+// runCreateSaga drives the creation saga of a pending order from its current
+// step. The forward step reserves the order's stock; if that fails the saga
+// compensates by releasing anything reserved and rejecting the order. Every
+// step is safe to repeat, so a saga interrupted by a crash or a failed
+// compensation is finished later by RecoverSagas. It returns the error that
+// made the order fail, if any.
+func (s *orderService) runCreateSaga(ctx context.Context, order *repository.Order, saga *repository.Saga) error {
+	var cause error
+	if saga.Status == repository.SagaStatusRunning {
+		// Reserve stock
+		cause = s.transition(ctx, order, OrderStatusReserved, "", nil)
+		if cause == nil {
+			s.saveSaga(ctx, saga, repository.SagaStatusCompleted, repository.SagaStepDone, nil)
+			return nil
+		}
+	} else {
+		cause = errors.New(saga.LastError)
+	}
+
+	// Compensate: release stock and reject the order
+	if err := s.transition(ctx, order, OrderStatusRejected, rejectionReason, cause); err != nil {
+		slog.ErrorContext(ctx, "Saga compensation failed", "order_id", order.ID, "error", err)
+		saga.Attempts++
+		s.saveSaga(ctx, saga, repository.SagaStatusCompensating, repository.SagaStepRejectOrder, cause)
+		return cause
+	}
+	s.saveSaga(ctx, saga, repository.SagaStatusCompensated, repository.SagaStepDone, cause)
+
+	return cause
+}
+
+// RecoverSagas finishes creation sagas that have not made progress since
+// staleBefore, e.g. because the process died part way or a compensation
+// failed, and returns how many were finished
+func (s *orderService) RecoverSagas(ctx context.Context, staleBefore time.Time) (int, error) {
+	// Find unfinished sagas
+	sagas, err := s.orderRepo.ListUnfinishedSagas(ctx, staleBefore, sagaRecoveryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// Recover each saga independently so one failure does not block the rest
+	finished := 0
+	for _, saga := range sagas {
+		if err := s.recoverSaga(ctx, saga); err != nil {
+			slog.ErrorContext(ctx, "Failed to recover saga", "order_id", saga.OrderID, "error", err)
+			continue
+		}
+		if saga.Finished() {
+			finished++
+		}
+	}
+
+	return finished, nil
+}
+
+// recoverSaga resumes or compensates a single saga
+func (s *orderService) recoverSaga(ctx context.Context, saga *repository.Saga) error {
+	// Get order
+	order, err := s.orderRepo.GetByID(ctx, saga.OrderID)
+	if err != nil {
+		return err
+	}
+
+	// An order that left pending has settled its stock in its current status,
+	// e.g. the process died after storing the status but before the saga
+	if OrderStatus(order.Status) != OrderStatusPending {
+		saga.Status = repository.SagaStatusCompleted
+		switch OrderStatus(order.Status) {
+		case OrderStatusRejected, OrderStatusCancelled, OrderStatusExpired:
+			saga.Status = repository.SagaStatusCompensated
+		}
+		saga.Step = repository.SagaStepDone
+		return s.orderRepo.UpdateSaga(ctx, saga)
+	}
+
+	// Run the remaining steps
+	slog.InfoContext(ctx, "Recovering saga", "order_id", saga.OrderID, "status", saga.Status, "step", saga.Step, "attempts", saga.Attempts)
+	_ = s.runCreateSaga(ctx, order, saga)
+	return nil
+}
+
+// saveSaga moves a saga to a new status and step and stores it. A saga that
+// cannot be stored keeps its previous state in the database, and since every
+// step is safe to repeat, recovery simply runs the step again.
+func (s *orderService) saveSaga(ctx context.Context, saga *repository.Saga, status repository.SagaStatus, step repository.SagaStep, cause error) {
+	saga.Status = status
+	saga.Step = step
+	if cause != nil {
+		saga.LastError = cause.Error()
+	}
+	if err := s.orderRepo.UpdateSaga(ctx, saga); err != nil {
+		slog.ErrorContext(ctx, "Failed to update saga", "order_id", saga.OrderID, "status", status, "error", err)
+	}
+}
@@ -0,0 +1,186 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+	"github.com/fardannozami/golang-microservice/order-service/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateOrder_CompensationFailedLeavesSagaForRecovery(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Create request
+	req := &service.CreateOrderRequest{
+		UserID: "user123",
+		Items: []service.OrderItemRequest{
+			{ProductID: "product123", Quantity: 2},
+		},
+	}
+
+	// Set up expectations
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("ReleaseClaim", mock.Anything, mock.Anything).Return(nil)
+	inventoryClient.On("GetPrices", mock.Anything, []string{"product123"}).Return(map[string]float64{"product123": 10.0}, nil)
+	orderRepo.On("Create", mock.Anything, mock.AnythingOfType("*repository.Order"), mock.AnythingOfType("*repository.Saga")).Return(nil)
+	inventoryClient.On("ReserveItems", mock.Anything, mock.AnythingOfType("string"), mock.Anything).Return(service.ErrInventoryUnavailable)
+	inventoryClient.On("ReleaseStock", mock.Anything, "product123", 2, mock.AnythingOfType("string")).Return(service.ErrInventoryUnavailable)
+	orderRepo.On("UpdateSaga", mock.Anything, mock.MatchedBy(func(saga *repository.Saga) bool {
+		return saga.Status == repository.SagaStatusCompensating &&
+			saga.Step == repository.SagaStepRejectOrder &&
+			saga.Attempts == 1
+	})).Return(nil)
+
+	// Call service
+	order, err := orderService.CreateOrder(context.Background(), req)
+
+	// Assert expectations
+	assert.ErrorIs(t, err, service.ErrInventoryUnavailable)
+	assert.Nil(t, order)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertExpectations(t)
+	orderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRecoverSagas_ResumesReservation(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Create saga
+	staleBefore := time.Now().Add(-time.Minute)
+	saga := &repository.Saga{
+		OrderID: "order123",
+		Status:  repository.SagaStatusRunning,
+		Step:    repository.SagaStepReserveStock,
+	}
+
+	// Set up expectations
+	orderRepo.On("ListUnfinishedSagas", mock.Anything, staleBefore, mock.Anything).Return([]*repository.Saga{saga}, nil)
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(&repository.Order{
+		ID:     "order123",
+		Status: string(service.OrderStatusPending),
+		Items: []repository.OrderItem{
+			{ProductID: "product123", Quantity: 2},
+		},
+	}, nil)
+	inventoryClient.On("ReserveItems", mock.Anything, "order123", []service.ReservationItem{
+		{ProductID: "product123", Quantity: 2},
+	}).Return(nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*repository.Order"), repository.StatusChange{FromStatus: string(service.OrderStatusPending)}).Return(nil)
+	orderRepo.On("UpdateSaga", mock.Anything, saga).Return(nil)
+
+	// Call service
+	finished, err := orderService.RecoverSagas(context.Background(), staleBefore)
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, 1, finished)
+	assert.Equal(t, repository.SagaStatusCompleted, saga.Status)
+	assert.Equal(t, repository.SagaStepDone, saga.Step)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertExpectations(t)
+}
+
+func TestRecoverSagas_RetriesCompensation(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Create saga
+	saga := &repository.Saga{
+		OrderID:   "order123",
+		Status:    repository.SagaStatusCompensating,
+		Step:      repository.SagaStepRejectOrder,
+		LastError: "failed to reserve inventory: inventory service unavailable",
+		Attempts:  1,
+	}
+
+	// Set up expectations
+	orderRepo.On("ListUnfinishedSagas", mock.Anything, mock.Anything, mock.Anything).Return([]*repository.Saga{saga}, nil)
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(&repository.Order{
+		ID:     "order123",
+		Status: string(service.OrderStatusPending),
+		Items: []repository.OrderItem{
+			{ProductID: "product123", Quantity: 2},
+		},
+	}, nil)
+	inventoryClient.On("ReleaseStock", mock.Anything, "product123", 2, "order123").Return(nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*repository.Order"), repository.StatusChange{
+		FromStatus: string(service.OrderStatusPending),
+		Reason:     "stock reservation failed",
+		Error:      "failed to reserve inventory: inventory service unavailable",
+	}).Return(nil)
+	orderRepo.On("UpdateSaga", mock.Anything, saga).Return(nil)
+
+	// Call service
+	finished, err := orderService.RecoverSagas(context.Background(), time.Now())
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, 1, finished)
+	assert.Equal(t, repository.SagaStatusCompensated, saga.Status)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertExpectations(t)
+	inventoryClient.AssertNotCalled(t, "ReserveItems", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRecoverSagas_OrderAlreadySettled(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Create saga
+	saga := &repository.Saga{
+		OrderID: "order123",
+		Status:  repository.SagaStatusRunning,
+		Step:    repository.SagaStepReserveStock,
+	}
+
+	// Set up expectations
+	orderRepo.On("ListUnfinishedSagas", mock.Anything, mock.Anything, mock.Anything).Return([]*repository.Saga{saga}, nil)
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(&repository.Order{
+		ID:     "order123",
+		Status: string(service.OrderStatusReserved),
+	}, nil)
+	orderRepo.On("UpdateSaga", mock.Anything, saga).Return(nil)
+
+	// Call service
+	finished, err := orderService.RecoverSagas(context.Background(), time.Now())
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, 1, finished)
+	assert.Equal(t, repository.SagaStatusCompleted, saga.Status)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertNotCalled(t, "ReserveItems", mock.Anything, mock.Anything, mock.Anything)
+	orderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -2,24 +2,27 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"math"
+	"time"
 
 	"github.com/fardannozami/golang-microservice/order-service/repository"
 )
 
-// OrderStatus represents the status of an order
-type OrderStatus string
+// PricePolicy decides what happens when a client-supplied price differs from the catalog price
+type PricePolicy string
 
 const (
-	// OrderStatusPending represents a pending order
-	OrderStatusPending OrderStatus = "pending"
-	// OrderStatusConfirmed represents a confirmed order
-	OrderStatusConfirmed OrderStatus = "confirmed"
-	// OrderStatusRejected represents a rejected order
-	OrderStatusRejected OrderStatus = "rejected"
+	// PricePolicyIgnore silently replaces client prices with catalog prices
+	PricePolicyIgnore PricePolicy = "ignore"
+	// PricePolicyReject rejects orders whose client prices differ from catalog prices
+	PricePolicyReject PricePolicy = "reject"
 )
 
+// ErrInvalidOrder is returned for a create order request that cannot be accepted
+var ErrInvalidOrder = errors.New("invalid order")
+
 // CreateOrderRequest represents a request to create an order
 type CreateOrderRequest struct {
 	UserID string
@@ -30,28 +33,53 @@ type CreateOrderRequest struct {
 type OrderItemRequest struct {
 	ProductID string
 	Quantity  int
-	Price     float64
+	Price     float64 // optional; the catalog price is authoritative
 }
 
 // OrderService defines the interface for order service operations
 type OrderService interface {
 	CreateOrder(ctx context.Context, req *CreateOrderRequest) (*repository.Order, error)
 	GetOrder(ctx context.Context, id string) (*repository.Order, error)
-	ListOrders(ctx context.Context) ([]*repository.Order, error)
+	ListOrders(ctx context.Context, req *ListOrdersRequest) (*OrderPage, error)
+	SummarizeUserOrders(ctx context.Context, userID string) (*UserOrderSummary, error)
+	FulfillOrder(ctx context.Context, id string) (*repository.Order, error)
+	CancelOrder(ctx context.Context, id, reason string) (*repository.Order, error)
+	UpdateOrderStatus(ctx context.Context, id, status, reason string) (*repository.Order, error)
+	UpdateOrderItems(ctx context.Context, id string, req *UpdateOrderItemsRequest) (*repository.Order, error)
+	GetOrderTimeline(ctx context.Context, id string) ([]repository.StatusChange, error)
+	RecoverSagas(ctx context.Context, staleBefore time.Time) (int, error)
 }
 
 // orderService implements OrderService interface
 type orderService struct {
 	orderRepo       repository.OrderRepository
 	inventoryClient InventoryClient
+	pricePolicy     PricePolicy
+	metrics         OrderMetrics
+}
+
+// Option configures an order service
+type Option func(*orderService)
+
+// WithPricePolicy sets how client prices that differ from the catalog are handled
+func WithPricePolicy(policy PricePolicy) Option {
+	return func(s *orderService) {
+		s.pricePolicy = policy
+	}
 }
 
 // NewOrderService creates a new order service
-func NewOrderService(orderRepo repository.OrderRepository, inventoryClient InventoryClient) OrderService {
-	return &orderService{
+func NewOrderService(orderRepo repository.OrderRepository, inventoryClient InventoryClient, opts ...Option) OrderService {
+	s := &orderService{
 		orderRepo:       orderRepo,
 		inventoryClient: inventoryClient,
+		pricePolicy:     PricePolicyIgnore,
+		metrics:         noMetrics{},
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // CreateOrder creates a new order
@@ -63,6 +91,19 @@ func (s *orderService) CreateOrder(ctx context.Context, req *CreateOrderRequest)
 
 	// Do not pre-check inventory to avoid TOCTOU; rely on atomic reservation
 
+	// Look up authoritative unit prices
+	prices, err := s.catalogPrices(ctx, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	var fields []FieldError
+	for i, item := range req.Items {
+		fields = append(fields, s.checkPrice(fmt.Sprintf("items[%d]", i), item, prices)...)
+	}
+	if len(fields) > 0 {
+		return nil, &ValidationError{Err: ErrInvalidOrder, Fields: fields}
+	}
+
 	// Create order
 	order := &repository.Order{
 		UserID: req.UserID,
@@ -70,92 +111,196 @@ func (s *orderService) CreateOrder(ctx context.Context, req *CreateOrderRequest)
 		Items:  make([]repository.OrderItem, len(req.Items)),
 	}
 
-	// Convert order items
+	// Convert order items, snapshotting the catalog price
 	for i, item := range req.Items {
 		order.Items[i] = repository.OrderItem{
 			ProductID: item.ProductID,
 			Quantity:  item.Quantity,
-			Price:     item.Price,
+			Price:     prices[item.ProductID],
 		}
 	}
 
-	// Create order in database
-	if err := s.orderRepo.Create(ctx, order); err != nil {
+	// Create order in database together with its creation saga
+	saga := newCreateSaga()
+	if err := s.orderRepo.Create(ctx, order, saga); err != nil {
 		return nil, fmt.Errorf("failed to create order: %w", err)
 	}
+	s.metrics.OrderCreated()
 
-	// Reserve inventory for all items
-	var reservationErrors []error
-	for _, item := range order.Items {
-		log.Printf("[order-service] Reserving stock product_id=%s qty=%d order_id=%s", item.ProductID, item.Quantity, order.ID)
-		err := s.inventoryClient.ReserveStock(ctx, item.ProductID, item.Quantity, order.ID)
-		if err != nil {
-			reservationErrors = append(reservationErrors, err)
-		}
+	// Reserve inventory for all items in a single all-or-nothing call,
+	// rejecting the order if that fails
+	if err := s.runCreateSaga(ctx, order, saga); err != nil {
+		return nil, err
 	}
 
-	// If any reservation failed, release all reservations and reject order
-	if len(reservationErrors) > 0 {
-		// Release all successful reservations
-		for _, item := range order.Items {
-			_ = s.inventoryClient.ReleaseStock(ctx, item.ProductID, item.Quantity, order.ID)
-		}
+	return order, nil
+}
+
+// GetOrder gets an order by ID
+func (s *orderService) GetOrder(ctx context.Context, id string) (*repository.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	return order, nil
+}
 
-		// Update order status to rejected
-		order.Status = string(OrderStatusRejected)
-		_ = s.orderRepo.Update(ctx, order)
+// FulfillOrder commits the order's reserved stock and marks it fulfilled.
+// Fulfilling an already fulfilled order is a no-op.
+func (s *orderService) FulfillOrder(ctx context.Context, id string) (*repository.Order, error) {
+	return s.changeStatus(ctx, id, OrderStatusFulfilled, "")
+}
 
-		return nil, fmt.Errorf("failed to reserve inventory: %v", reservationErrors[0])
+// CancelOrder releases the stock reserved for an order and marks it cancelled
+// with the given reason. Cancelling a cancelled order returns it unchanged, and
+// a cancellation that failed part way can simply be retried.
+func (s *orderService) CancelOrder(ctx context.Context, id, reason string) (*repository.Order, error) {
+	return s.changeStatus(ctx, id, OrderStatusCancelled, reason)
+}
+
+// UpdateOrderStatus moves an order to any status the lifecycle allows from its
+// current one, applying the stock effect of the transition. Setting the status
+// an order already has returns it unchanged.
+func (s *orderService) UpdateOrderStatus(ctx context.Context, id, status, reason string) (*repository.Order, error) {
+	// Validate input
+	orderStatus, err := ParseOrderStatus(status)
+	if err != nil {
+		return nil, err
 	}
 
-	// Update order status to confirmed
-	order.Status = string(OrderStatusConfirmed)
-	if err := s.orderRepo.Update(ctx, order); err != nil {
-		return nil, fmt.Errorf("failed to update order status: %w", err)
+	return s.changeStatus(ctx, id, orderStatus, reason)
+}
+
+// GetOrderTimeline lists every status change of an order, oldest first
+func (s *orderService) GetOrderTimeline(ctx context.Context, id string) ([]repository.StatusChange, error) {
+	// Check the order exists
+	if _, err := s.orderRepo.GetByID(ctx, id); err != nil {
+		return nil, repoError(err)
+	}
+
+	return s.orderRepo.ListStatusHistory(ctx, id)
+}
+
+// changeStatus loads an order and moves it to status unless it is already there
+func (s *orderService) changeStatus(ctx context.Context, id string, status OrderStatus, reason string) (*repository.Order, error) {
+	// Get order
+	order, err := s.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	// Check the caller's expected version
+	if err := checkVersion(ctx, order); err != nil {
+		return nil, err
+	}
+
+	// Repeating a change is a no-op
+	if OrderStatus(order.Status) == status {
+		return order, nil
+	}
+
+	// Apply the transition
+	if err := s.transition(ctx, order, status, reason, nil); err != nil {
+		return nil, versionError(ctx, err)
 	}
 
 	return order, nil
 }
 
-// GetOrder gets an order by ID
-func (s *orderService) GetOrder(ctx context.Context, id string) (*repository.Order, error) {
-	return s.orderRepo.GetByID(ctx, id)
+// catalogPrices fetches the catalog price of every requested product; products
+// that are not in the catalog have no price
+func (s *orderService) catalogPrices(ctx context.Context, items []OrderItemRequest) (map[string]float64, error) {
+	// Collect distinct product IDs
+	var productIDs []string
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		if !seen[item.ProductID] {
+			seen[item.ProductID] = true
+			productIDs = append(productIDs, item.ProductID)
+		}
+	}
+
+	// Fetch prices from the catalog
+	prices, err := s.inventoryClient.GetPrices(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product prices: %w", err)
+	}
+
+	return prices, nil
+}
+
+// checkPrice checks the item at field against the catalog, applying the price
+// policy to a client-supplied price
+func (s *orderService) checkPrice(field string, item OrderItemRequest, prices map[string]float64) []FieldError {
+	price, ok := prices[item.ProductID]
+	if !ok {
+		return []FieldError{{Field: field + ".product_id", Message: fmt.Sprintf("is not a catalog product: %s", item.ProductID)}}
+	}
+	if item.Price > 0 && s.pricePolicy == PricePolicyReject && !samePrice(item.Price, price) {
+		return []FieldError{{Field: field + ".price", Message: fmt.Sprintf("does not match the catalog price: expected %.2f, got %.2f", price, item.Price)}}
+	}
+	return nil
 }
 
-// ListOrders lists all orders
-func (s *orderService) ListOrders(ctx context.Context) ([]*repository.Order, error) {
-	return s.orderRepo.List(ctx)
+// samePrice compares two prices to the cent
+func samePrice(a, b float64) bool {
+	return math.Round(a*100) == math.Round(b*100)
 }
 
-// validateCreateOrderRequest validates a create order request
+// reservationItems merges order items into one reservation line per product
+func reservationItems(items []repository.OrderItem) []ReservationItem {
+	var result []ReservationItem
+	index := make(map[string]int, len(items))
+	for _, item := range items {
+		if i, ok := index[item.ProductID]; ok {
+			result[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.ProductID] = len(result)
+		result = append(result, ReservationItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+		})
+	}
+	return result
+}
+
+// validateCreateOrderRequest validates a create order request, reporting every invalid field
 func validateCreateOrderRequest(req *CreateOrderRequest) error {
+	var fields []FieldError
+
 	// Check if user ID is provided
 	if req.UserID == "" {
-		return fmt.Errorf("user ID is required")
+		fields = append(fields, FieldError{Field: "user_id", Message: "is required"})
 	}
 
 	// Check if items are provided
 	if len(req.Items) == 0 {
-		return fmt.Errorf("at least one item is required")
+		fields = append(fields, FieldError{Field: "items", Message: "must contain at least one item"})
 	}
 
 	// Validate each item
 	for i, item := range req.Items {
+		field := fmt.Sprintf("items[%d]", i)
+
 		// Check if product ID is provided
 		if item.ProductID == "" {
-			return fmt.Errorf("product ID is required for item %d", i)
+			fields = append(fields, FieldError{Field: field + ".product_id", Message: "is required"})
 		}
 
 		// Check if quantity is valid
 		if item.Quantity <= 0 {
-			return fmt.Errorf("quantity must be positive for item %d", i)
+			fields = append(fields, FieldError{Field: field + ".quantity", Message: "must be positive"})
 		}
 
-		// Check if price is valid
-		if item.Price <= 0 {
-			return fmt.Errorf("price must be positive for item %d", i)
+		// Check if price is valid when provided
+		if item.Price < 0 {
+			fields = append(fields, FieldError{Field: field + ".price", Message: "must not be negative"})
 		}
 	}
 
+	if len(fields) > 0 {
+		return &ValidationError{Err: ErrInvalidOrder, Fields: fields}
+	}
 	return nil
 }
@@ -3,12 +3,15 @@ package service_test
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/fardannozami/golang-microservice/order-service/repository"
 	"github.com/fardannozami/golang-microservice/order-service/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockOrderRepository is a mock implementation of OrderRepository
@@ -16,8 +19,8 @@ type MockOrderRepository struct {
 	mock.Mock
 }
 
-func (m *MockOrderRepository) Create(ctx context.Context, order *repository.Order) error {
-	args := m.Called(ctx, order)
+func (m *MockOrderRepository) Create(ctx context.Context, order *repository.Order, saga *repository.Saga) error {
+	args := m.Called(ctx, order, saga)
 	return args.Error(0)
 }
 
@@ -29,19 +32,63 @@ func (m *MockOrderRepository) GetByID(ctx context.Context, id string) (*reposito
 	return args.Get(0).(*repository.Order), args.Error(1)
 }
 
-func (m *MockOrderRepository) List(ctx context.Context) ([]*repository.Order, error) {
-	args := m.Called(ctx)
+func (m *MockOrderRepository) List(ctx context.Context, filter repository.OrderFilter) ([]*repository.Order, error) {
+	args := m.Called(ctx, filter)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*repository.Order), args.Error(1)
 }
 
-func (m *MockOrderRepository) Update(ctx context.Context, order *repository.Order) error {
+func (m *MockOrderRepository) SummarizeByUser(ctx context.Context, userID string) ([]repository.StatusTotal, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.StatusTotal), args.Error(1)
+}
+
+func (m *MockOrderRepository) Claim(ctx context.Context, order *repository.Order, until time.Time) error {
+	args := m.Called(ctx, order, until)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) ReleaseClaim(ctx context.Context, order *repository.Order) error {
+	args := m.Called(ctx, order)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) UpdateStatus(ctx context.Context, order *repository.Order, change repository.StatusChange) error {
+	args := m.Called(ctx, order, change)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) UpdateSaga(ctx context.Context, saga *repository.Saga) error {
+	args := m.Called(ctx, saga)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) ReplaceItems(ctx context.Context, order *repository.Order) error {
 	args := m.Called(ctx, order)
 	return args.Error(0)
 }
 
+func (m *MockOrderRepository) ListUnfinishedSagas(ctx context.Context, updatedBefore time.Time, limit int) ([]*repository.Saga, error) {
+	args := m.Called(ctx, updatedBefore, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.Saga), args.Error(1)
+}
+
+func (m *MockOrderRepository) ListStatusHistory(ctx context.Context, orderID string) ([]repository.StatusChange, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.StatusChange), args.Error(1)
+}
+
 // MockInventoryClient is a mock implementation of InventoryClient
 type MockInventoryClient struct {
 	mock.Mock
@@ -62,6 +109,29 @@ func (m *MockInventoryClient) ReleaseStock(ctx context.Context, productID string
 	return args.Error(0)
 }
 
+func (m *MockInventoryClient) ReserveItems(ctx context.Context, orderID string, items []service.ReservationItem) error {
+	args := m.Called(ctx, orderID, items)
+	return args.Error(0)
+}
+
+func (m *MockInventoryClient) CommitStock(ctx context.Context, orderID string) error {
+	args := m.Called(ctx, orderID)
+	return args.Error(0)
+}
+
+func (m *MockInventoryClient) GetPrices(ctx context.Context, productIDs []string) (map[string]float64, error) {
+	args := m.Called(ctx, productIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]float64), args.Error(1)
+}
+
+func (m *MockInventoryClient) Ready(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
 func (m *MockInventoryClient) Close() error {
 	args := m.Called()
 	return args.Error(0)
@@ -88,10 +158,14 @@ func TestCreateOrder_Success(t *testing.T) {
 	}
 
 	// Set up expectations
-	inventoryClient.On("CheckStock", mock.Anything, "product123", 2).Return(true, nil)
-	orderRepo.On("Create", mock.Anything, mock.AnythingOfType("*repository.Order")).Return(nil)
-	inventoryClient.On("ReserveStock", mock.Anything, "product123", 2, mock.AnythingOfType("string")).Return(nil)
-	orderRepo.On("Update", mock.Anything, mock.AnythingOfType("*repository.Order")).Return(nil)
+	inventoryClient.On("GetPrices", mock.Anything, []string{"product123"}).Return(map[string]float64{"product123": 10.0}, nil)
+	orderRepo.On("Create", mock.Anything, mock.AnythingOfType("*repository.Order"), mock.AnythingOfType("*repository.Saga")).Return(nil)
+	orderRepo.On("UpdateSaga", mock.Anything, mock.AnythingOfType("*repository.Saga")).Return(nil)
+	inventoryClient.On("ReserveItems", mock.Anything, mock.AnythingOfType("string"), []service.ReservationItem{
+		{ProductID: "product123", Quantity: 2},
+	}).Return(nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*repository.Order"), mock.Anything).Return(nil)
 
 	// Call service
 	order, err := orderService.CreateOrder(context.Background(), req)
@@ -100,7 +174,7 @@ func TestCreateOrder_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, order)
 	assert.Equal(t, "user123", order.UserID)
-	assert.Equal(t, string(service.OrderStatusConfirmed), order.Status)
+	assert.Equal(t, string(service.OrderStatusReserved), order.Status)
 	assert.Len(t, order.Items, 1)
 	assert.Equal(t, "product123", order.Items[0].ProductID)
 	assert.Equal(t, 2, order.Items[0].Quantity)
@@ -132,7 +206,20 @@ func TestCreateOrder_InventoryUnavailable(t *testing.T) {
 	}
 
 	// Set up expectations
-	inventoryClient.On("CheckStock", mock.Anything, "product123", 2).Return(false, nil)
+	inventoryClient.On("GetPrices", mock.Anything, []string{"product123"}).Return(map[string]float64{"product123": 10.0}, nil)
+	orderRepo.On("Create", mock.Anything, mock.AnythingOfType("*repository.Order"), mock.AnythingOfType("*repository.Saga")).Return(nil)
+	orderRepo.On("UpdateSaga", mock.Anything, mock.MatchedBy(func(saga *repository.Saga) bool {
+		return saga.Status == repository.SagaStatusCompensated && strings.Contains(saga.LastError, "insufficient stock")
+	})).Return(nil)
+	inventoryClient.On("ReserveItems", mock.Anything, mock.AnythingOfType("string"), mock.Anything).Return(&service.InsufficientStockError{ProductID: "product123", Available: 1, Requested: 2})
+	inventoryClient.On("ReleaseStock", mock.Anything, "product123", 2, mock.AnythingOfType("string")).Return(nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("ReleaseClaim", mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(order *repository.Order) bool {
+		return order.Status == string(service.OrderStatusRejected)
+	}), mock.MatchedBy(func(change repository.StatusChange) bool {
+		return change.FromStatus == string(service.OrderStatusPending) && strings.Contains(change.Error, "insufficient stock")
+	})).Return(nil)
 
 	// Call service
 	order, err := orderService.CreateOrder(context.Background(), req)
@@ -140,7 +227,10 @@ func TestCreateOrder_InventoryUnavailable(t *testing.T) {
 	// Assert expectations
 	assert.Error(t, err)
 	assert.Nil(t, order)
-	assert.Contains(t, err.Error(), "not available")
+	assert.Contains(t, err.Error(), "insufficient stock")
+	var insufficient *service.InsufficientStockError
+	assert.ErrorAs(t, err, &insufficient)
+	assert.Equal(t, 1, insufficient.Available)
 
 	// Verify mocks
 	orderRepo.AssertExpectations(t)
@@ -168,11 +258,14 @@ func TestCreateOrder_ReservationFailed(t *testing.T) {
 	}
 
 	// Set up expectations
-	inventoryClient.On("CheckStock", mock.Anything, "product123", 2).Return(true, nil)
-	orderRepo.On("Create", mock.Anything, mock.AnythingOfType("*repository.Order")).Return(nil)
-	inventoryClient.On("ReserveStock", mock.Anything, "product123", 2, mock.AnythingOfType("string")).Return(errors.New("reservation failed"))
+	inventoryClient.On("GetPrices", mock.Anything, []string{"product123"}).Return(map[string]float64{"product123": 10.0}, nil)
+	orderRepo.On("Create", mock.Anything, mock.AnythingOfType("*repository.Order"), mock.AnythingOfType("*repository.Saga")).Return(nil)
+	orderRepo.On("UpdateSaga", mock.Anything, mock.AnythingOfType("*repository.Saga")).Return(nil)
+	inventoryClient.On("ReserveItems", mock.Anything, mock.AnythingOfType("string"), mock.Anything).Return(errors.New("reservation failed"))
 	inventoryClient.On("ReleaseStock", mock.Anything, "product123", 2, mock.AnythingOfType("string")).Return(nil)
-	orderRepo.On("Update", mock.Anything, mock.AnythingOfType("*repository.Order")).Return(nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("ReleaseClaim", mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*repository.Order"), mock.Anything).Return(nil)
 
 	// Call service
 	order, err := orderService.CreateOrder(context.Background(), req)
@@ -187,6 +280,158 @@ func TestCreateOrder_ReservationFailed(t *testing.T) {
 	inventoryClient.AssertExpectations(t)
 }
 
+func TestCreateOrder_MergesDuplicateProducts(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Create request
+	req := &service.CreateOrderRequest{
+		UserID: "user123",
+		Items: []service.OrderItemRequest{
+			{ProductID: "product123", Quantity: 2, Price: 10.0},
+			{ProductID: "product456", Quantity: 1, Price: 5.0},
+			{ProductID: "product123", Quantity: 3, Price: 10.0},
+		},
+	}
+
+	// Set up expectations
+	inventoryClient.On("GetPrices", mock.Anything, []string{"product123", "product456"}).Return(map[string]float64{"product123": 10.0, "product456": 5.0}, nil)
+	orderRepo.On("Create", mock.Anything, mock.AnythingOfType("*repository.Order"), mock.AnythingOfType("*repository.Saga")).Return(nil)
+	orderRepo.On("UpdateSaga", mock.Anything, mock.AnythingOfType("*repository.Saga")).Return(nil)
+	inventoryClient.On("ReserveItems", mock.Anything, mock.AnythingOfType("string"), []service.ReservationItem{
+		{ProductID: "product123", Quantity: 5},
+		{ProductID: "product456", Quantity: 1},
+	}).Return(nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*repository.Order"), mock.Anything).Return(nil)
+
+	// Call service
+	order, err := orderService.CreateOrder(context.Background(), req)
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Len(t, order.Items, 3)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertExpectations(t)
+	inventoryClient.AssertNotCalled(t, "ReserveStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateOrder_UsesCatalogPrice(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Create request with a client price far below the catalog price
+	req := &service.CreateOrderRequest{
+		UserID: "user123",
+		Items: []service.OrderItemRequest{
+			{ProductID: "prod-001", Quantity: 1, Price: 10.99},
+			{ProductID: "prod-002", Quantity: 1},
+		},
+	}
+
+	// Set up expectations
+	inventoryClient.On("GetPrices", mock.Anything, []string{"prod-001", "prod-002"}).Return(map[string]float64{"prod-001": 15000000, "prod-002": 8000000}, nil)
+	orderRepo.On("Create", mock.Anything, mock.AnythingOfType("*repository.Order"), mock.AnythingOfType("*repository.Saga")).Return(nil)
+	orderRepo.On("UpdateSaga", mock.Anything, mock.AnythingOfType("*repository.Saga")).Return(nil)
+	inventoryClient.On("ReserveItems", mock.Anything, mock.AnythingOfType("string"), mock.Anything).Return(nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*repository.Order"), mock.Anything).Return(nil)
+
+	// Call service
+	order, err := orderService.CreateOrder(context.Background(), req)
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, 15000000.0, order.Items[0].Price)
+	assert.Equal(t, 8000000.0, order.Items[1].Price)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertExpectations(t)
+}
+
+func TestCreateOrder_RejectsPriceMismatch(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient, service.WithPricePolicy(service.PricePolicyReject))
+
+	// Create request
+	req := &service.CreateOrderRequest{
+		UserID: "user123",
+		Items: []service.OrderItemRequest{
+			{ProductID: "prod-001", Quantity: 1, Price: 10.99},
+		},
+	}
+
+	// Set up expectations
+	inventoryClient.On("GetPrices", mock.Anything, []string{"prod-001"}).Return(map[string]float64{"prod-001": 15000000}, nil)
+
+	// Call service
+	order, err := orderService.CreateOrder(context.Background(), req)
+
+	// Assert expectations
+	var invalid *service.ValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.ErrorIs(t, err, service.ErrValidation)
+	assert.Nil(t, order)
+	assert.Equal(t, []service.FieldError{
+		{Field: "items[0].price", Message: "does not match the catalog price: expected 15000000.00, got 10.99"},
+	}, invalid.Fields)
+
+	// Verify mocks
+	orderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	inventoryClient.AssertExpectations(t)
+}
+
+func TestCreateOrder_AcceptsMatchingPriceUnderRejectPolicy(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient, service.WithPricePolicy(service.PricePolicyReject))
+
+	// Create request
+	req := &service.CreateOrderRequest{
+		UserID: "user123",
+		Items: []service.OrderItemRequest{
+			{ProductID: "product123", Quantity: 2, Price: 10.0},
+		},
+	}
+
+	// Set up expectations
+	inventoryClient.On("GetPrices", mock.Anything, []string{"product123"}).Return(map[string]float64{"product123": 10.0}, nil)
+	orderRepo.On("Create", mock.Anything, mock.AnythingOfType("*repository.Order"), mock.AnythingOfType("*repository.Saga")).Return(nil)
+	orderRepo.On("UpdateSaga", mock.Anything, mock.AnythingOfType("*repository.Saga")).Return(nil)
+	inventoryClient.On("ReserveItems", mock.Anything, mock.AnythingOfType("string"), mock.Anything).Return(nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*repository.Order"), mock.Anything).Return(nil)
+
+	// Call service
+	order, err := orderService.CreateOrder(context.Background(), req)
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.NotNil(t, order)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertExpectations(t)
+}
+
 func TestGetOrder_Success(t *testing.T) {
 	// Create mocks
 	orderRepo := new(MockOrderRepository)
@@ -199,7 +444,7 @@ func TestGetOrder_Success(t *testing.T) {
 	order := &repository.Order{
 		ID:     "order123",
 		UserID: "user123",
-		Status: string(service.OrderStatusConfirmed),
+		Status: string(service.OrderStatusReserved),
 		Items: []repository.OrderItem{
 			{
 				ID:        "item123",
@@ -235,15 +480,15 @@ func TestGetOrder_NotFound(t *testing.T) {
 	orderService := service.NewOrderService(orderRepo, inventoryClient)
 
 	// Set up expectations
-	orderRepo.On("GetByID", mock.Anything, "order123").Return(nil, errors.New("order not found"))
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(nil, repository.ErrOrderNotFound)
 
 	// Call service
 	result, err := orderService.GetOrder(context.Background(), "order123")
 
 	// Assert expectations
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, service.ErrNotFound)
+	assert.ErrorIs(t, err, repository.ErrOrderNotFound)
 	assert.Nil(t, result)
-	assert.Contains(t, err.Error(), "order not found")
 
 	// Verify mocks
 	orderRepo.AssertExpectations(t)
@@ -263,7 +508,7 @@ func TestListOrders_Success(t *testing.T) {
 		{
 			ID:     "order123",
 			UserID: "user123",
-			Status: string(service.OrderStatusConfirmed),
+			Status: string(service.OrderStatusReserved),
 			Items: []repository.OrderItem{
 				{
 					ID:        "item123",
@@ -291,15 +536,18 @@ func TestListOrders_Success(t *testing.T) {
 	}
 
 	// Set up expectations
-	orderRepo.On("List", mock.Anything).Return(orders, nil)
+	orderRepo.On("List", mock.Anything, repository.OrderFilter{
+		Sort:  repository.SortNewestFirst,
+		Limit: 21,
+	}).Return(orders, nil)
 
 	// Call service
-	result, err := orderService.ListOrders(context.Background())
+	result, err := orderService.ListOrders(context.Background(), &service.ListOrdersRequest{})
 
 	// Assert expectations
 	assert.NoError(t, err)
-	assert.Equal(t, orders, result)
-	assert.Len(t, result, 2)
+	assert.Equal(t, orders, result.Orders)
+	assert.Empty(t, result.NextPageToken)
 
 	// Verify mocks
 	orderRepo.AssertExpectations(t)
@@ -315,10 +563,10 @@ func TestListOrders_Error(t *testing.T) {
 	orderService := service.NewOrderService(orderRepo, inventoryClient)
 
 	// Set up expectations
-	orderRepo.On("List", mock.Anything).Return(nil, errors.New("database error"))
+	orderRepo.On("List", mock.Anything, mock.Anything).Return(nil, errors.New("database error"))
 
 	// Call service
-	result, err := orderService.ListOrders(context.Background())
+	result, err := orderService.ListOrders(context.Background(), &service.ListOrdersRequest{})
 
 	// Assert expectations
 	assert.Error(t, err)
@@ -329,3 +577,411 @@ func TestListOrders_Error(t *testing.T) {
 	orderRepo.AssertExpectations(t)
 	inventoryClient.AssertExpectations(t)
 }
+
+func TestListOrders_NextPage(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Create orders
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	orders := []*repository.Order{
+		{ID: "00000000-0000-0000-0000-000000000003", UserID: "user123", CreatedAt: createdAt.Add(2 * time.Minute)},
+		{ID: "00000000-0000-0000-0000-000000000002", UserID: "user123", CreatedAt: createdAt.Add(time.Minute)},
+		{ID: "00000000-0000-0000-0000-000000000001", UserID: "user123", CreatedAt: createdAt},
+	}
+
+	// Set up expectations: the first page asks for one extra order
+	orderRepo.On("List", mock.Anything, repository.OrderFilter{
+		UserID: "user123",
+		Status: string(service.OrderStatusReserved),
+		Sort:   repository.SortNewestFirst,
+		Limit:  3,
+	}).Return(orders, nil).Once()
+
+	// Call service
+	first, err := orderService.ListOrders(context.Background(), &service.ListOrdersRequest{
+		UserID:   "user123",
+		Status:   "reserved",
+		PageSize: 2,
+	})
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, orders[:2], first.Orders)
+	assert.NotEmpty(t, first.NextPageToken)
+
+	// Set up expectations: the next page continues after the last order
+	orderRepo.On("List", mock.Anything, repository.OrderFilter{
+		UserID: "user123",
+		Status: string(service.OrderStatusReserved),
+		Sort:   repository.SortNewestFirst,
+		After:  &repository.OrderCursor{CreatedAt: orders[1].CreatedAt, ID: "00000000-0000-0000-0000-000000000002"},
+		Limit:  3,
+	}).Return(orders[2:], nil).Once()
+
+	// Call service
+	second, err := orderService.ListOrders(context.Background(), &service.ListOrdersRequest{
+		UserID:    "user123",
+		Status:    "reserved",
+		PageSize:  2,
+		PageToken: first.NextPageToken,
+	})
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, orders[2:], second.Orders)
+	assert.Empty(t, second.NextPageToken)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+}
+
+func TestListOrders_InvalidFilter(t *testing.T) {
+	now := time.Now()
+	tests := map[string]*service.ListOrdersRequest{
+		"negative page size": {PageSize: -1},
+		"unknown status":     {Status: "lost"},
+		"unknown sort":       {Sort: "total_desc"},
+		"inverted range":     {CreatedFrom: now, CreatedTo: now.Add(-time.Hour)},
+		"bad page token":     {PageToken: "not a token"},
+		"bad page token ID":  {PageToken: "MjAyNC0wMS0wMVQwMDowMDowMFp8MScgT1IgJzEnPScx"}, // 2024-01-01T00:00:00Z|1' OR '1'='1
+	}
+
+	for name, req := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Create mocks
+			orderRepo := new(MockOrderRepository)
+			inventoryClient := new(MockInventoryClient)
+
+			// Create service
+			orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+			// Call service
+			result, err := orderService.ListOrders(context.Background(), req)
+
+			// Assert expectations
+			assert.ErrorIs(t, err, service.ErrInvalidOrderFilter)
+			assert.Nil(t, result)
+			orderRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestSummarizeUserOrders_Success(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	orderRepo.On("SummarizeByUser", mock.Anything, "user123").Return([]repository.StatusTotal{
+		{Status: "cancelled", Orders: 1, Amount: 15.0},
+		{Status: "delivered", Orders: 2, Amount: 30.1},
+		{Status: "paid", Orders: 1, Amount: 9.2},
+		{Status: "reserved", Orders: 3, Amount: 42.0},
+	}, nil)
+
+	// Call service
+	summary, err := orderService.SummarizeUserOrders(context.Background(), "user123")
+
+	// Assert expectations: only paid orders count towards spending
+	assert.NoError(t, err)
+	assert.Equal(t, 7, summary.TotalOrders)
+	assert.Equal(t, 39.3, summary.TotalSpent)
+	assert.Equal(t, 3, summary.OrdersByStatus[service.OrderStatusReserved])
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+}
+
+func TestSummarizeUserOrders_MissingUser(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Call service
+	summary, err := orderService.SummarizeUserOrders(context.Background(), "")
+
+	// Assert expectations
+	assert.ErrorIs(t, err, service.ErrInvalidOrderFilter)
+	assert.Nil(t, summary)
+	orderRepo.AssertNotCalled(t, "SummarizeByUser", mock.Anything, mock.Anything)
+}
+
+func TestFulfillOrder_Success(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Create order
+	order := &repository.Order{
+		ID:     "order123",
+		UserID: "user123",
+		Status: string(service.OrderStatusReserved),
+	}
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(order, nil)
+	inventoryClient.On("CommitStock", mock.Anything, "order123").Return(nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*repository.Order"), mock.Anything).Return(nil)
+
+	// Call service
+	result, err := orderService.FulfillOrder(context.Background(), "order123")
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, string(service.OrderStatusFulfilled), result.Status)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertExpectations(t)
+}
+
+func TestFulfillOrder_AlreadyFulfilled(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(&repository.Order{
+		ID:     "order123",
+		Status: string(service.OrderStatusFulfilled),
+	}, nil)
+
+	// Call service
+	result, err := orderService.FulfillOrder(context.Background(), "order123")
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, string(service.OrderStatusFulfilled), result.Status)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertNotCalled(t, "CommitStock", mock.Anything, mock.Anything)
+}
+
+func TestFulfillOrder_InvalidStatus(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(&repository.Order{
+		ID:     "order123",
+		Status: string(service.OrderStatusRejected),
+	}, nil)
+
+	// Call service
+	result, err := orderService.FulfillOrder(context.Background(), "order123")
+
+	// Assert expectations
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, service.ErrInvalidOrderStatus)
+	assert.Contains(t, err.Error(), "from rejected to fulfilled")
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertNotCalled(t, "CommitStock", mock.Anything, mock.Anything)
+}
+
+func TestCancelOrder_Success(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Create order
+	order := &repository.Order{
+		ID:     "order123",
+		UserID: "user123",
+		Status: string(service.OrderStatusReserved),
+		Items: []repository.OrderItem{
+			{ProductID: "product123", Quantity: 2},
+			{ProductID: "product456", Quantity: 1},
+			{ProductID: "product123", Quantity: 3},
+		},
+	}
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(order, nil)
+	inventoryClient.On("ReleaseStock", mock.Anything, "product123", 5, "order123").Return(nil)
+	inventoryClient.On("ReleaseStock", mock.Anything, "product456", 1, "order123").Return(nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*repository.Order"), mock.Anything).Return(nil)
+
+	// Call service
+	result, err := orderService.CancelOrder(context.Background(), "order123", "customer request")
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, string(service.OrderStatusCancelled), result.Status)
+	assert.Equal(t, "customer request", result.CancelReason)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertExpectations(t)
+}
+
+func TestCancelOrder_AlreadyCancelled(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(&repository.Order{
+		ID:           "order123",
+		Status:       string(service.OrderStatusCancelled),
+		CancelReason: "customer request",
+	}, nil)
+
+	// Call service
+	result, err := orderService.CancelOrder(context.Background(), "order123", "duplicate click")
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, "customer request", result.CancelReason)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertNotCalled(t, "ReleaseStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	orderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCancelOrder_InvalidStatus(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(&repository.Order{
+		ID:     "order123",
+		Status: string(service.OrderStatusFulfilled),
+	}, nil)
+
+	// Call service
+	result, err := orderService.CancelOrder(context.Background(), "order123", "")
+
+	// Assert expectations
+	assert.ErrorIs(t, err, service.ErrInvalidOrderStatus)
+	assert.Nil(t, result)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertNotCalled(t, "ReleaseStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCancelOrder_ReleaseFailedKeepsStatus(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Create order
+	order := &repository.Order{
+		ID:     "order123",
+		Status: string(service.OrderStatusReserved),
+		Items: []repository.OrderItem{
+			{ProductID: "product123", Quantity: 2},
+		},
+	}
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(order, nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("ReleaseClaim", mock.Anything, mock.Anything).Return(nil)
+	inventoryClient.On("ReleaseStock", mock.Anything, "product123", 2, "order123").Return(service.ErrInventoryUnavailable)
+
+	// Call service
+	result, err := orderService.CancelOrder(context.Background(), "order123", "")
+
+	// Assert expectations
+	assert.ErrorIs(t, err, service.ErrInventoryUnavailable)
+	assert.Nil(t, result)
+	assert.Equal(t, string(service.OrderStatusReserved), order.Status)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertExpectations(t)
+	orderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetOrderTimeline_Success(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Create history
+	history := []repository.StatusChange{
+		{ID: 1, OrderID: "order123", ToStatus: string(service.OrderStatusPending), Actor: "customer"},
+		{ID: 2, OrderID: "order123", FromStatus: string(service.OrderStatusPending), ToStatus: string(service.OrderStatusRejected), Reason: "stock reservation failed", Actor: "customer", Error: "insufficient stock"},
+	}
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(&repository.Order{ID: "order123", Status: string(service.OrderStatusRejected)}, nil)
+	orderRepo.On("ListStatusHistory", mock.Anything, "order123").Return(history, nil)
+
+	// Call service
+	result, err := orderService.GetOrderTimeline(context.Background(), "order123")
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, history, result)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+}
+
+func TestGetOrderTimeline_NotFound(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "missing").Return(nil, repository.ErrOrderNotFound)
+
+	// Call service
+	result, err := orderService.GetOrderTimeline(context.Background(), "missing")
+
+	// Assert expectations
+	assert.ErrorIs(t, err, repository.ErrOrderNotFound)
+	assert.Nil(t, result)
+
+	// Verify mocks
+	orderRepo.AssertNotCalled(t, "ListStatusHistory", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+)
+
+// OrderStatus represents the status of an order
+type OrderStatus string
+
+const (
+	// OrderStatusPending represents an order whose stock is not reserved yet
+	OrderStatusPending OrderStatus = "pending"
+	// OrderStatusReserved represents an order whose stock is reserved
+	OrderStatusReserved OrderStatus = "reserved"
+	// OrderStatusRejected represents an order whose stock could not be reserved
+	OrderStatusRejected OrderStatus = "rejected"
+	// OrderStatusPaid represents a reserved order that has been paid for
+	OrderStatusPaid OrderStatus = "paid"
+	// OrderStatusFulfilled represents an order whose stock has been deducted
+	OrderStatusFulfilled OrderStatus = "fulfilled"
+	// OrderStatusShipped represents a fulfilled order handed to the carrier
+	OrderStatusShipped OrderStatus = "shipped"
+	// OrderStatusDelivered represents a shipped order that reached the customer
+	OrderStatusDelivered OrderStatus = "delivered"
+	// OrderStatusCancelled represents an order whose reserved stock has been released
+	OrderStatusCancelled OrderStatus = "cancelled"
+	// OrderStatusRefunded represents an order whose payment has been returned
+	OrderStatusRefunded OrderStatus = "refunded"
+	// OrderStatusExpired represents an order that was not paid for in time
+	OrderStatusExpired OrderStatus = "expired"
+)
+
+// ErrInvalidOrderStatus is returned when the current status of an order does
+// not allow the requested change. It matches ErrConflict.
+var ErrInvalidOrderStatus = newKindError(ErrConflict, "invalid order status")
+
+// ErrUnknownOrderStatus is returned for a status that is not part of the order lifecycle
+var ErrUnknownOrderStatus = errors.New("unknown order status")
+
+// stockEffect is the inventory operation that accompanies a status change.
+// It runs before the new status is stored, so a failed effect leaves the
+// order in its previous status and the change can be retried.
+type stockEffect func(s *orderService, ctx context.Context, order *repository.Order) error
+
+// orderTransitions lists every allowed status change and its stock effect;
+// a nil effect changes the status only. Statuses without outgoing transitions
+// are final.
+var orderTransitions = map[OrderStatus]map[OrderStatus]stockEffect{
+	OrderStatusPending: {
+		OrderStatusReserved:  (*orderService).reserveStock,
+		OrderStatusRejected:  (*orderService).releaseStock,
+		OrderStatusCancelled: (*orderService).releaseStock,
+		OrderStatusExpired:   (*orderService).releaseStock,
+	},
+	OrderStatusReserved: {
+		OrderStatusPaid:      nil,
+		OrderStatusFulfilled: (*orderService).commitStock,
+		OrderStatusCancelled: (*orderService).releaseStock,
+		OrderStatusExpired:   (*orderService).releaseStock,
+	},
+	OrderStatusPaid: {
+		OrderStatusFulfilled: (*orderService).commitStock,
+		OrderStatusRefunded:  (*orderService).releaseStock,
+	},
+	OrderStatusFulfilled: {
+		OrderStatusShipped:  nil,
+		OrderStatusRefunded: nil,
+	},
+	OrderStatusShipped: {
+		OrderStatusDelivered: nil,
+	},
+	OrderStatusDelivered: {
+		OrderStatusRefunded: nil,
+	},
+}
+
+// ParseOrderStatus validates a status name
+func ParseOrderStatus(status string) (OrderStatus, error) {
+	switch orderStatus := OrderStatus(status); orderStatus {
+	case OrderStatusPending, OrderStatusReserved, OrderStatusRejected, OrderStatusPaid, OrderStatusFulfilled,
+		OrderStatusShipped, OrderStatusDelivered, OrderStatusCancelled, OrderStatusRefunded, OrderStatusExpired:
+		return orderStatus, nil
+	}
+	return "", invalidField(ErrUnknownOrderStatus, "status", fmt.Sprintf("is not an order status: %q", status))
+}
+
+// CanTransition reports whether an order may move from one status to another
+func CanTransition(from, to OrderStatus) bool {
+	_, ok := orderTransitions[from][to]
+	return ok
+}
+
+// transition moves an order to a new status, running the stock effect of the
+// transition first. The order is claimed before the effect runs, so of two
+// concurrent changes only one moves stock and stores its status; the other
+// fails without touching either.
+// The change is recorded in the order's status history together with reason
+// and cause, the failure that led to it if any.
+func (s *orderService) transition(ctx context.Context, order *repository.Order, to OrderStatus, reason string, cause error) error {
+	from := OrderStatus(order.Status)
+	effect, ok := orderTransitions[from][to]
+	if !ok {
+		return fmt.Errorf("cannot change order status from %s to %s: %w", from, to, ErrInvalidOrderStatus)
+	}
+
+	// Claim the order
+	if err := s.claim(ctx, order); err != nil {
+		return err
+	}
+
+	// Run the stock effect, giving up the claim if it fails
+	if effect != nil {
+		if err := effect(s, ctx, order); err != nil {
+			s.releaseClaim(ctx, order)
+			return err
+		}
+	}
+
+	// Store the new status
+	slog.InfoContext(ctx, "Order status changed", "order_id", order.ID, "from", from, "to", to)
+	order.Status = string(to)
+	if to == OrderStatusCancelled {
+		order.CancelReason = reason
+	}
+	change := repository.StatusChange{FromStatus: string(from), Reason: reason}
+	if cause != nil {
+		change.Error = cause.Error()
+	}
+	if err := s.orderRepo.UpdateStatus(ctx, order, change); err != nil {
+		order.Status = string(from)
+		return fmt.Errorf("failed to update order status: %w", repoError(err))
+	}
+
+	// Count the outcomes of order creation
+	switch to {
+	case OrderStatusReserved:
+		s.metrics.OrderConfirmed()
+	case OrderStatusRejected:
+		s.metrics.OrderRejected()
+	}
+
+	return nil
+}
+
+// reserveStock reserves the stock of every order item in a single all-or-nothing call
+func (s *orderService) reserveStock(ctx context.Context, order *repository.Order) error {
+	slog.InfoContext(ctx, "Reserving stock", "order_id", order.ID, "items", len(order.Items))
+	if err := s.inventoryClient.ReserveItems(ctx, order.ID, reservationItems(order.Items)); err != nil {
+		s.metrics.ReservationFailed(reservationFailure(err))
+		return fmt.Errorf("failed to reserve inventory: %w", goneError(err))
+	}
+	return nil
+}
+
+// releaseStock releases the stock reserved for an order. The inventory service
+// never releases more than the order still holds, so lines released by an
+// earlier attempt, by reservation expiry, or never reserved at all are a no-op.
+func (s *orderService) releaseStock(ctx context.Context, order *repository.Order) error {
+	slog.InfoContext(ctx, "Releasing stock", "order_id", order.ID, "items", len(order.Items))
+	for _, item := range reservationItems(order.Items) {
+		err := s.inventoryClient.ReleaseStock(ctx, item.ProductID, item.Quantity, order.ID)
+		if err != nil && !errors.Is(err, ErrProductNotFound) {
+			return fmt.Errorf("failed to release inventory: %w", err)
+		}
+	}
+	return nil
+}
+
+// commitStock deducts the order's reserved stock; the inventory service makes this idempotent per order
+func (s *orderService) commitStock(ctx context.Context, order *repository.Order) error {
+	slog.InfoContext(ctx, "Committing stock", "order_id", order.ID)
+	if err := s.inventoryClient.CommitStock(ctx, order.ID); err != nil {
+		return fmt.Errorf("failed to commit inventory: %w", goneError(err))
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+	"github.com/fardannozami/golang-microservice/order-service/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCanTransition(t *testing.T) {
+	assert.True(t, service.CanTransition(service.OrderStatusPending, service.OrderStatusReserved))
+	assert.True(t, service.CanTransition(service.OrderStatusReserved, service.OrderStatusPaid))
+	assert.True(t, service.CanTransition(service.OrderStatusPaid, service.OrderStatusFulfilled))
+	assert.True(t, service.CanTransition(service.OrderStatusShipped, service.OrderStatusDelivered))
+	assert.False(t, service.CanTransition(service.OrderStatusReserved, service.OrderStatusShipped))
+	assert.False(t, service.CanTransition(service.OrderStatusCancelled, service.OrderStatusReserved))
+	assert.False(t, service.CanTransition(service.OrderStatusDelivered, service.OrderStatusCancelled))
+}
+
+func TestUpdateOrderStatus_Paid(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(&repository.Order{
+		ID:     "order123",
+		Status: string(service.OrderStatusReserved),
+	}, nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*repository.Order"), repository.StatusChange{FromStatus: string(service.OrderStatusReserved)}).Return(nil)
+
+	// Call service
+	result, err := orderService.UpdateOrderStatus(context.Background(), "order123", "paid", "")
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, string(service.OrderStatusPaid), result.Status)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertExpectations(t)
+}
+
+func TestUpdateOrderStatus_RefundReleasesStock(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(&repository.Order{
+		ID:     "order123",
+		Status: string(service.OrderStatusPaid),
+		Items: []repository.OrderItem{
+			{ProductID: "product123", Quantity: 2},
+		},
+	}, nil)
+	inventoryClient.On("ReleaseStock", mock.Anything, "product123", 2, "order123").Return(nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*repository.Order"), repository.StatusChange{FromStatus: string(service.OrderStatusPaid)}).Return(nil)
+
+	// Call service
+	result, err := orderService.UpdateOrderStatus(context.Background(), "order123", "refunded", "")
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, string(service.OrderStatusRefunded), result.Status)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+	inventoryClient.AssertExpectations(t)
+}
+
+func TestUpdateOrderStatus_IllegalTransition(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(&repository.Order{
+		ID:     "order123",
+		Status: string(service.OrderStatusReserved),
+	}, nil)
+
+	// Call service
+	result, err := orderService.UpdateOrderStatus(context.Background(), "order123", "delivered", "")
+
+	// Assert expectations
+	assert.ErrorIs(t, err, service.ErrInvalidOrderStatus)
+	assert.Nil(t, result)
+
+	// Verify mocks
+	orderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateOrderStatus_UnknownStatus(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Call service
+	result, err := orderService.UpdateOrderStatus(context.Background(), "order123", "lost", "")
+
+	// Assert expectations
+	assert.ErrorIs(t, err, service.ErrUnknownOrderStatus)
+	assert.Nil(t, result)
+
+	// Verify mocks
+	orderRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+func TestUpdateOrderStatus_ConcurrentChange(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Create order
+	order := &repository.Order{
+		ID:     "order123",
+		Status: string(service.OrderStatusFulfilled),
+	}
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(order, nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("UpdateStatus", mock.Anything, order, repository.StatusChange{FromStatus: string(service.OrderStatusFulfilled)}).Return(&repository.VersionConflictError{OrderID: "order123", Version: 1})
+
+	// Call service
+	result, err := orderService.UpdateOrderStatus(context.Background(), "order123", "shipped", "")
+
+	// Assert expectations
+	assert.ErrorIs(t, err, repository.ErrOrderConflict)
+	assert.Nil(t, result)
+	assert.Equal(t, string(service.OrderStatusFulfilled), order.Status)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+}
+
+// claimingOrderRepository keeps a single order in memory and applies the
+// version and claim checks of the Postgres repository to it. Reads wait on
+// readers so that concurrent changes all start from the same version.
+type claimingOrderRepository struct {
+	*MockOrderRepository
+	readers     *sync.WaitGroup
+	mu          sync.Mutex
+	order       repository.Order
+	lockedUntil time.Time
+}
+
+func (r *claimingOrderRepository) GetByID(ctx context.Context, id string) (*repository.Order, error) {
+	r.mu.Lock()
+	order := r.order
+	r.mu.Unlock()
+	r.readers.Done()
+	r.readers.Wait()
+	return &order, nil
+}
+
+func (r *claimingOrderRepository) Claim(ctx context.Context, order *repository.Order, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.order.Version != order.Version || time.Now().Before(r.lockedUntil) {
+		return &repository.VersionConflictError{OrderID: order.ID, Version: order.Version}
+	}
+	r.order.Version++
+	r.lockedUntil = until
+	order.Version++
+	return nil
+}
+
+func (r *claimingOrderRepository) ReleaseClaim(ctx context.Context, order *repository.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.order.Version != order.Version {
+		return &repository.VersionConflictError{OrderID: order.ID, Version: order.Version}
+	}
+	r.order.Version--
+	r.lockedUntil = time.Time{}
+	order.Version--
+	return nil
+}
+
+func (r *claimingOrderRepository) UpdateStatus(ctx context.Context, order *repository.Order, change repository.StatusChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.order.Version != order.Version {
+		return &repository.VersionConflictError{OrderID: order.ID, Version: order.Version}
+	}
+	order.Version++
+	r.order = *order
+	r.lockedUntil = time.Time{}
+	return nil
+}
+
+func TestConcurrentTransitions_OnlyOneMovesStock(t *testing.T) {
+	// Create mocks
+	readers := new(sync.WaitGroup)
+	readers.Add(2)
+	orderRepo := &claimingOrderRepository{
+		MockOrderRepository: new(MockOrderRepository),
+		readers:             readers,
+		order: repository.Order{
+			ID:     "order123",
+			Status: string(service.OrderStatusReserved),
+			Items:  []repository.OrderItem{{ProductID: "product123", Quantity: 2}},
+		},
+	}
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	inventoryClient.On("CommitStock", mock.Anything, "order123").Return(nil).Maybe()
+	inventoryClient.On("ReleaseStock", mock.Anything, "product123", 2, "order123").Return(nil).Maybe()
+
+	// Call service
+	var wg sync.WaitGroup
+	var fulfilErr, cancelErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, fulfilErr = orderService.FulfillOrder(context.Background(), "order123")
+	}()
+	go func() {
+		defer wg.Done()
+		_, cancelErr = orderService.CancelOrder(context.Background(), "order123", "")
+	}()
+	wg.Wait()
+
+	// Assert expectations
+	switch orderRepo.order.Status {
+	case string(service.OrderStatusFulfilled):
+		assert.NoError(t, fulfilErr)
+		assert.ErrorIs(t, cancelErr, repository.ErrOrderConflict)
+		inventoryClient.AssertNumberOfCalls(t, "CommitStock", 1)
+		inventoryClient.AssertNotCalled(t, "ReleaseStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	case string(service.OrderStatusCancelled):
+		assert.NoError(t, cancelErr)
+		assert.ErrorIs(t, fulfilErr, repository.ErrOrderConflict)
+		inventoryClient.AssertNumberOfCalls(t, "ReleaseStock", 1)
+		inventoryClient.AssertNotCalled(t, "CommitStock", mock.Anything, mock.Anything)
+	default:
+		t.Fatalf("unexpected order status %s", orderRepo.order.Status)
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+)
+
+// claimLease bounds how long a change may hold an order while its stock
+// effect runs; it outlasts the timeouts of the inventory calls an effect makes
+const claimLease = 30 * time.Second
+
+// ErrVersionMismatch is returned when an order no longer has the version the
+// caller expected it to have. It is not one of the error kinds: a lost race
+// reported this way also matches ErrConflict, so check for it first.
+var ErrVersionMismatch = errors.New("order version does not match")
+
+// expectedVersionKey is the context key for the version a change expects
+type expectedVersionKey struct{}
+
+// WithExpectedVersion returns a context under which order changes only apply
+// to an order that still has one of versions, e.g. those named by an If-Match
+// header
+func WithExpectedVersion(ctx context.Context, versions ...int64) context.Context {
+	return context.WithValue(ctx, expectedVersionKey{}, versions)
+}
+
+// expectedVersion returns the versions attached to the context, if any
+func expectedVersion(ctx context.Context) ([]int64, bool) {
+	versions, ok := ctx.Value(expectedVersionKey{}).([]int64)
+	return versions, ok
+}
+
+// checkVersion fails with ErrVersionMismatch when the context expects order to
+// have another version
+func checkVersion(ctx context.Context, order *repository.Order) error {
+	if versions, ok := expectedVersion(ctx); ok && !slices.Contains(versions, order.Version) {
+		return fmt.Errorf("%w: order %s is at version %d, not %s", ErrVersionMismatch, order.ID, order.Version, formatVersions(versions))
+	}
+	return nil
+}
+
+// formatVersions lists versions for an error message, e.g. "3 or 4"
+func formatVersions(versions []int64) string {
+	names := make([]string, len(versions))
+	for i, version := range versions {
+		names[i] = strconv.FormatInt(version, 10)
+	}
+	return strings.Join(names, " or ")
+}
+
+// versionError reports a write that lost a race with another change as a
+// version mismatch when the caller named the version it expected, since the
+// order it read no longer is the current one
+func versionError(ctx context.Context, err error) error {
+	if _, ok := expectedVersion(ctx); ok && errors.Is(err, repository.ErrOrderConflict) {
+		return fmt.Errorf("%w: %w", ErrVersionMismatch, err)
+	}
+	return err
+}
+
+// claim reserves order for a change before its stock effect runs, so that a
+// concurrent change that read the same version fails before it moves any
+// stock rather than after, when storing its result
+func (s *orderService) claim(ctx context.Context, order *repository.Order) error {
+	if err := s.orderRepo.Claim(ctx, order, time.Now().Add(claimLease)); err != nil {
+		return fmt.Errorf("failed to claim order: %w", repoError(err))
+	}
+	return nil
+}
+
+// releaseClaim gives up the claim on order after its change failed, so that
+// the change can be retried right away
+func (s *orderService) releaseClaim(ctx context.Context, order *repository.Order) {
+	if err := s.orderRepo.ReleaseClaim(ctx, order); err != nil {
+		slog.WarnContext(ctx, "Failed to release order claim", "order_id", order.ID, "error", err)
+	}
+}
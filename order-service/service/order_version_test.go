@@ -0,0 +1,131 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fardannozami/golang-microservice/order-service/repository"
+	"github.com/fardannozami/golang-microservice/order-service/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCancelOrder_ExpectedVersionMatches(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Create order
+	order := &repository.Order{
+		ID:      "order123",
+		Status:  string(service.OrderStatusPending),
+		Version: 3,
+	}
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(order, nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("UpdateStatus", mock.Anything, order, mock.Anything).Return(nil)
+
+	// Call service
+	ctx := service.WithExpectedVersion(context.Background(), 3)
+	result, err := orderService.CancelOrder(ctx, "order123", "")
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, string(service.OrderStatusCancelled), result.Status)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+}
+
+func TestCancelOrder_AnyExpectedVersionMatches(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Create order
+	order := &repository.Order{
+		ID:      "order123",
+		Status:  string(service.OrderStatusPending),
+		Version: 4,
+	}
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(order, nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("UpdateStatus", mock.Anything, order, mock.Anything).Return(nil)
+
+	// Call service
+	ctx := service.WithExpectedVersion(context.Background(), 3, 4)
+	result, err := orderService.CancelOrder(ctx, "order123", "")
+
+	// Assert expectations
+	assert.NoError(t, err)
+	assert.Equal(t, string(service.OrderStatusCancelled), result.Status)
+
+	// Verify mocks
+	orderRepo.AssertExpectations(t)
+}
+
+func TestCancelOrder_ExpectedVersionStale(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Set up expectations
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(&repository.Order{
+		ID:      "order123",
+		Status:  string(service.OrderStatusReserved),
+		Version: 4,
+	}, nil)
+
+	// Call service
+	ctx := service.WithExpectedVersion(context.Background(), 3)
+	result, err := orderService.CancelOrder(ctx, "order123", "")
+
+	// Assert expectations: nothing is released or stored
+	assert.ErrorIs(t, err, service.ErrVersionMismatch)
+	assert.Nil(t, result)
+	inventoryClient.AssertNotCalled(t, "ReleaseStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	orderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateOrderStatus_ConcurrentChangeWithExpectedVersion(t *testing.T) {
+	// Create mocks
+	orderRepo := new(MockOrderRepository)
+	inventoryClient := new(MockInventoryClient)
+
+	// Create service
+	orderService := service.NewOrderService(orderRepo, inventoryClient)
+
+	// Create order
+	order := &repository.Order{
+		ID:      "order123",
+		Status:  string(service.OrderStatusFulfilled),
+		Version: 3,
+	}
+
+	// Set up expectations: another request wins between read and write
+	orderRepo.On("GetByID", mock.Anything, "order123").Return(order, nil)
+	orderRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("UpdateStatus", mock.Anything, order, mock.Anything).Return(&repository.VersionConflictError{OrderID: "order123", Version: 3})
+
+	// Call service
+	ctx := service.WithExpectedVersion(context.Background(), 3)
+	result, err := orderService.UpdateOrderStatus(ctx, "order123", "shipped", "")
+
+	// Assert expectations
+	assert.ErrorIs(t, err, service.ErrVersionMismatch)
+	assert.ErrorIs(t, err, repository.ErrOrderConflict)
+	assert.Nil(t, result)
+}
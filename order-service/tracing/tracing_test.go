@@ -0,0 +1,139 @@
+package tracing_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pb "github.com/fardannozami/golang-microservice/inventory-service/proto"
+	"github.com/fardannozami/golang-microservice/order-service/handler"
+	"github.com/fardannozami/golang-microservice/order-service/service"
+	"github.com/fardannozami/golang-microservice/order-service/tracing"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// productServer answers BatchGetProducts with a fixed price
+type productServer struct {
+	pb.UnimplementedInventoryServiceServer
+}
+
+func (productServer) BatchGetProducts(ctx context.Context, req *pb.BatchGetProductsRequest) (*pb.BatchGetProductsResponse, error) {
+	products := make([]*pb.Product, len(req.Ids))
+	for i, id := range req.Ids {
+		products[i] = &pb.Product{Id: id, Price: 10}
+	}
+	return &pb.BatchGetProductsResponse{Success: true, Products: products}, nil
+}
+
+// setupTracing records every span in memory for the duration of the test
+func setupTracing(t *testing.T) *tracetest.InMemoryExporter {
+	previous := otel.GetTracerProvider()
+	_, err := tracing.Setup(context.Background(), tracing.Config{Exporter: "none"})
+	require.NoError(t, err)
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewTracerProvider(sdktrace.NewSimpleSpanProcessor(exporter), 0)
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previous)
+	})
+	return exporter
+}
+
+// startInventoryServer serves productServer on a local port with server tracing
+func startInventoryServer(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler()))
+	pb.RegisterInventoryServiceServer(server, productServer{})
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func TestTracing_PropagatesFromHTTPThroughGRPC(t *testing.T) {
+	exporter := setupTracing(t)
+
+	// Create inventory client
+	inventoryClient, err := service.NewInventoryClient(startInventoryServer(t))
+	require.NoError(t, err)
+	defer inventoryClient.Close()
+
+	// Create router
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(handler.Tracing())
+	router.GET("/prices/:id", func(c *gin.Context) {
+		prices, err := inventoryClient.GetPrices(c.Request.Context(), []string{c.Param("id")})
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusOK, prices)
+	})
+
+	// Send a request continuing a sampled trace; the sample ratio of 0 only
+	// applies to new traces
+	req := httptest.NewRequest(http.MethodGet, "/prices/prod-001", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Find the spans of both services
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	httpSpan := findSpan(t, spans, "GET /prices/:id", trace.SpanKindServer)
+	clientSpan := findSpan(t, spans, "inventory.InventoryService/BatchGetProducts", trace.SpanKindClient)
+	serverSpan := findSpan(t, spans, "inventory.InventoryService/BatchGetProducts", trace.SpanKindServer)
+	for _, span := range spans {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String(), span.Name)
+	}
+
+	// Assert the HTTP span continues the caller's span, the gRPC client span
+	// is its child and the inventory server span is the client span's child
+	assert.Equal(t, "00f067aa0ba902b7", httpSpan.Parent.SpanID().String())
+	assert.Equal(t, httpSpan.SpanContext.SpanID(), clientSpan.Parent.SpanID())
+	assert.Equal(t, clientSpan.SpanContext.SpanID(), serverSpan.Parent.SpanID())
+}
+
+func TestTracing_SkipsProbes(t *testing.T) {
+	exporter := setupTracing(t)
+
+	// Create router
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(handler.Tracing())
+	router.GET("/healthz", handler.NewHealthHandler().Healthz)
+
+	// Send a sampled probe
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Assert nothing was recorded
+	assert.Empty(t, exporter.GetSpans())
+}
+
+// findSpan returns the span with the given name and kind
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string, kind trace.SpanKind) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name == name && span.SpanKind == kind {
+			return span
+		}
+	}
+	t.Fatalf("no %s span named %s", kind, name)
+	return tracetest.SpanStub{}
+}
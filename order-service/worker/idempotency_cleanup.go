@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/fardannozami/golang-microservice/order-service/service"
+)
+
+// IdempotencyCleanup periodically removes expired idempotency keys, so that
+// requests do not pay for the cleanup
+type IdempotencyCleanup struct {
+	service  service.IdempotencyService
+	interval time.Duration
+}
+
+// NewIdempotencyCleanup creates a new idempotency key cleanup worker
+func NewIdempotencyCleanup(service service.IdempotencyService, interval time.Duration) *IdempotencyCleanup {
+	return &IdempotencyCleanup{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Run removes expired keys at startup and then every interval until the
+// context is cancelled. A non-positive interval disables the worker.
+func (c *IdempotencyCleanup) Run(ctx context.Context) {
+	if c.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge runs a single pass over expired keys
+func (c *IdempotencyCleanup) purge(ctx context.Context) {
+	deleted, err := c.service.PurgeExpired(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Idempotency key cleanup failed", "error", err)
+		}
+		return
+	}
+	if deleted > 0 {
+		slog.InfoContext(ctx, "Idempotency key cleanup removed expired keys", "keys", deleted)
+	}
+}
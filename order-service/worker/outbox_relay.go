@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/fardannozami/golang-microservice/order-service/events"
+)
+
+// OutboxRelay periodically publishes the events waiting in the outbox
+type OutboxRelay struct {
+	relay    *events.Relay
+	interval time.Duration
+}
+
+// NewOutboxRelay creates a new outbox relay worker
+func NewOutboxRelay(relay *events.Relay, interval time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		relay:    relay,
+		interval: interval,
+	}
+}
+
+// Run publishes outbox events every interval until the context is cancelled.
+// A non-positive interval disables the worker.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	if r.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		// Publish everything written so far
+		r.publish(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publish runs a single pass over the outbox
+func (r *OutboxRelay) publish(ctx context.Context) {
+	published, err := r.relay.RelayEvents(ctx)
+	if published > 0 {
+		slog.InfoContext(ctx, "Outbox relay published events", "events", published)
+	}
+	if err != nil && ctx.Err() == nil {
+		slog.ErrorContext(ctx, "Outbox relay failed", "error", err)
+	}
+}
@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/fardannozami/golang-microservice/order-service/service"
+)
+
+// SagaRecovery periodically resumes or compensates unfinished order sagas
+type SagaRecovery struct {
+	service    service.OrderService
+	interval   time.Duration
+	staleAfter time.Duration
+}
+
+// NewSagaRecovery creates a new saga recovery worker that picks up sagas
+// which have made no progress for staleAfter
+func NewSagaRecovery(service service.OrderService, interval, staleAfter time.Duration) *SagaRecovery {
+	return &SagaRecovery{
+		service:    service,
+		interval:   interval,
+		staleAfter: staleAfter,
+	}
+}
+
+// Run recovers unfinished sagas at startup and then every interval until the
+// context is cancelled. A non-positive interval disables the worker.
+func (r *SagaRecovery) Run(ctx context.Context) {
+	if r.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		// Recover everything that has stalled so far
+		r.recover(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recover runs a single pass over unfinished sagas
+func (r *SagaRecovery) recover(ctx context.Context) {
+	finished, err := r.service.RecoverSagas(ctx, time.Now().Add(-r.staleAfter))
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Saga recovery failed", "error", err)
+		}
+		return
+	}
+	if finished > 0 {
+		slog.InfoContext(ctx, "Saga recovery finished sagas", "sagas", finished)
+	}
+}